@@ -11,4 +11,10 @@ const (
 	XAmzServerSideEncryptionCustomerKeyMd5    = "x-amz-server-side-encryption-customer-key-md5"
 	XAmzServerSideEncryptionAwsKmsKeyId       = "x-amz-server-side-encryption-aws-kms-key-id"
 	XAmzBucketRegion                          = "x-amz-bucket-region"
+	XAmzStorageClass                          = "x-amz-storage-class"
+	XAmzAcl                                   = "x-amz-acl"
+	XAmzTagging                               = "x-amz-tagging"
+	XAmzVersionId                             = "x-amz-version-id"
+	XAmzDeleteMarker                          = "x-amz-delete-marker"
+	ContentMD5                                = "content-md5"
 )
@@ -1,11 +1,15 @@
 package errors
 
 import (
+	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/textproto"
 	"os"
+	"strconv"
 )
 
 var (
@@ -22,24 +26,84 @@ var (
 	ErrWriteMultipartFailed    = errors.New("write multipart operation failed")
 	ErrCompleteMultipartFailed = errors.New("complete multipart operation failed")
 	ErrAbortMultipartFailed    = errors.New("abort multipart operation failed")
+	ErrListMultipartFailed     = errors.New("list multipart operation failed")
+
+	ErrGetTagsFailed = errors.New("get tags operation failed")
+	ErrSetTagsFailed = errors.New("set tags operation failed")
 
 	ErrUnknownPreSignOperation = errors.New("unknown presign operation")
+	ErrUploadIdRequired        = errors.New("upload id required")
+	ErrSignatureMismatched     = errors.New("signature mismatched")
+	ErrSignatureExpired        = errors.New("signature expired")
 
 	ErrDetectRegionFailed = errors.New("detect region failed")
 
-	ErrListFailed = errors.New("list operation failed")
+	ErrListFailed         = errors.New("list operation failed")
+	ErrListVersionsFailed = errors.New("list versions operation failed")
 
 	ErrNotFound         = errors.New("not found")
 	ErrPermissionDenied = errors.New("permission denied")
 	ErrInterrupted      = errors.New("err interrupted")
+	ErrPathEscaped      = errors.New("path escapes from root")
 	ErrOther            = errors.New("unknown error")
+
+	// the kinds of S3 error codes, they wrap the general kinds above.
+
+	ErrNoSuchKey          = fmt.Errorf("%w: no such key", ErrNotFound)
+	ErrNoSuchBucket       = fmt.Errorf("%w: no such bucket", ErrNotFound)
+	ErrSlowDown           = fmt.Errorf("%w: slow down", ErrInterrupted)
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrEntityTooSmall     = errors.New("entity too small")
+	ErrInvalidPart        = errors.New("invalid part")
 )
 
+var s3Code2Kind = map[string]error{
+	"NoSuchKey":          ErrNoSuchKey,
+	"NoSuchVersion":      ErrNoSuchKey,
+	"NoSuchUpload":       ErrNotFound,
+	"NoSuchBucket":       ErrNoSuchBucket,
+	"AccessDenied":       ErrPermissionDenied,
+	"SlowDown":           ErrSlowDown,
+	"RequestTimeout":     ErrInterrupted,
+	"InternalError":      ErrInterrupted,
+	"ServiceUnavailable": ErrInterrupted,
+	"PreconditionFailed": ErrPreconditionFailed,
+	"EntityTooSmall":     ErrEntityTooSmall,
+	"InvalidPart":        ErrInvalidPart,
+	"InvalidPartOrder":   ErrInvalidPart,
+}
+
 type ObjectError struct {
 	source error
 	kind   error
 	path   string
 	body   []byte
+
+	statusCode int
+	code       string
+	message    string
+	requestId  string
+	hostId     string
+}
+
+// Code returns the error code of the service, e.g. `NoSuchKey`, it's empty if unknown.
+func (error ObjectError) Code() string {
+	return error.code
+}
+
+// RequestID returns the id of the failed request, it's empty if unknown.
+func (error ObjectError) RequestID() string {
+	return error.requestId
+}
+
+// HostID returns the id of the host serving the failed request, it's empty if unknown.
+func (error ObjectError) HostID() string {
+	return error.hostId
+}
+
+// StatusCode returns the http status code, it's 0 if the error is not from an http response.
+func (error ObjectError) StatusCode() int {
+	return error.statusCode
 }
 
 func (error ObjectError) Is(other error) bool {
@@ -47,6 +111,9 @@ func (error ObjectError) Is(other error) bool {
 }
 
 func (error ObjectError) Error() string {
+	if error.code != "" {
+		return fmt.Sprintf("kind %s\nsource:%s\npath: %s\ncode: %s\nmessage: %s\nrequest id: %s\n", error.kind, error.source, error.path, error.code, error.message, error.requestId)
+	}
 	if error.body != nil {
 		return fmt.Sprintf("kind %s\nsource:%s\npath: %s\nbody: %s\n", error.kind, error.source, error.path, string(error.body))
 	}
@@ -68,24 +135,236 @@ func ParseFsError(src, err error, path string) error {
 	}
 }
 
-func ParseS3Error(err error, path string, resp *http.Response) error {
-	var kind error
-	switch resp.StatusCode {
+// s3ErrorResponse see https://docs.aws.amazon.com/AmazonS3/latest/API/ErrorResponses.html
+type s3ErrorResponse struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	RequestId string   `xml:"RequestId"`
+	HostId    string   `xml:"HostId"`
+}
+
+// kindFromStatus returns the general kind of the http status.
+func kindFromStatus(status int) error {
+	switch status {
 	case http.StatusNotFound:
-		kind = ErrNotFound
-	case http.StatusForbidden:
-		kind = ErrPermissionDenied
-	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
-		kind = ErrInterrupted
+		return ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrPermissionDenied
+	case http.StatusPreconditionFailed:
+		return ErrPreconditionFailed
+	case http.StatusTooManyRequests:
+		return ErrSlowDown
+	case http.StatusRequestTimeout, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return ErrInterrupted
 	default:
-		kind = ErrOther
+		return ErrOther
 	}
+}
+
+func ParseS3Error(err error, path string, resp *http.Response) error {
+	b, _ := io.ReadAll(resp.Body)
+	objectErr := &ObjectError{
+		source:     err,
+		kind:       kindFromStatus(resp.StatusCode),
+		path:       path,
+		body:       b,
+		statusCode: resp.StatusCode,
+		// the body of HEAD responses is empty, the ids are in the headers.
+		requestId: resp.Header.Get("x-amz-request-id"),
+		hostId:    resp.Header.Get("x-amz-id-2"),
+	}
+	output := s3ErrorResponse{}
+	if len(b) > 0 && xml.Unmarshal(b, &output) == nil {
+		objectErr.code = output.Code
+		objectErr.message = output.Message
+		if output.RequestId != "" {
+			objectErr.requestId = output.RequestId
+		}
+		if output.HostId != "" {
+			objectErr.hostId = output.HostId
+		}
+		if codeKind, ok := s3Code2Kind[output.Code]; ok {
+			objectErr.kind = codeKind
+		}
+	}
+	return objectErr
+}
+
+// gcsErrorResponse see https://cloud.google.com/storage/docs/json_api/v1/status-codes
+type gcsErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Errors  []struct {
+			Reason string `json:"reason"`
+		} `json:"errors"`
+	} `json:"error"`
+}
+
+var gcsReason2Kind = map[string]error{
+	"notFound":           ErrNotFound,
+	"forbidden":          ErrPermissionDenied,
+	"conditionNotMet":    ErrPreconditionFailed,
+	"rateLimitExceeded":  ErrSlowDown,
+	"backendError":       ErrInterrupted,
+	"internalError":      ErrInterrupted,
+	"serviceUnavailable": ErrInterrupted,
+}
+
+func ParseGcsError(err error, path string, resp *http.Response) error {
+	b, _ := io.ReadAll(resp.Body)
+	objectErr := &ObjectError{
+		source:     err,
+		kind:       kindFromStatus(resp.StatusCode),
+		path:       path,
+		body:       b,
+		statusCode: resp.StatusCode,
+		requestId:  resp.Header.Get("x-guploader-uploadid"),
+	}
+	output := gcsErrorResponse{}
+	if len(b) > 0 && json.Unmarshal(b, &output) == nil && len(output.Error.Errors) > 0 {
+		objectErr.code = output.Error.Errors[0].Reason
+		objectErr.message = output.Error.Message
+		if reasonKind, ok := gcsReason2Kind[objectErr.code]; ok {
+			objectErr.kind = reasonKind
+		}
+	}
+	return objectErr
+}
+
+var azblobCode2Kind = map[string]error{
+	"BlobNotFound":          ErrNoSuchKey,
+	"ContainerNotFound":     ErrNoSuchBucket,
+	"AuthenticationFailed":  ErrPermissionDenied,
+	"AuthorizationFailure":  ErrPermissionDenied,
+	"ConditionNotMet":       ErrPreconditionFailed,
+	"ServerBusy":            ErrSlowDown,
+	"OperationTimedOut":     ErrInterrupted,
+	"InternalError":         ErrInterrupted,
+	"InvalidBlockList":      ErrInvalidPart,
+	"InvalidBlockId":        ErrInvalidPart,
+	"BlobArchived":          ErrPermissionDenied,
+	"AuthorizationMismatch": ErrPermissionDenied,
+}
+
+// ParseAzblobError parses the error of azure blob storage, see https://learn.microsoft.com/en-us/rest/api/storageservices/status-and-error-codes2
+func ParseAzblobError(err error, path string, resp *http.Response) error {
+	b, _ := io.ReadAll(resp.Body)
+	objectErr := &ObjectError{
+		source:     err,
+		kind:       kindFromStatus(resp.StatusCode),
+		path:       path,
+		body:       b,
+		statusCode: resp.StatusCode,
+		// the body of HEAD responses is empty, the code is in the header as well.
+		code:      resp.Header.Get("x-ms-error-code"),
+		requestId: resp.Header.Get("x-ms-request-id"),
+	}
+	output := s3ErrorResponse{}
+	if len(b) > 0 && xml.Unmarshal(b, &output) == nil {
+		objectErr.code = output.Code
+		objectErr.message = output.Message
+	}
+	if codeKind, ok := azblobCode2Kind[objectErr.code]; ok {
+		objectErr.kind = codeKind
+	}
+	return objectErr
+}
+
+// ParseHttpError parses the error of the providers speaking plain http, e.g. webdav,
+// the kind is derived from the status code only.
+func ParseHttpError(err error, path string, resp *http.Response) error {
 	b, _ := io.ReadAll(resp.Body)
 	return &ObjectError{
-		source: err,
-		kind:   kind,
-		path:   path,
-		body:   b,
+		source:     err,
+		kind:       kindFromStatus(resp.StatusCode),
+		path:       path,
+		body:       b,
+		statusCode: resp.StatusCode,
+	}
+}
+
+// webhdfsErrorResponse see https://hadoop.apache.org/docs/stable/hadoop-project-dist/hadoop-hdfs/WebHDFS.html#Error_Responses
+type webhdfsErrorResponse struct {
+	RemoteException struct {
+		Exception     string `json:"exception"`
+		JavaClassName string `json:"javaClassName"`
+		Message       string `json:"message"`
+	} `json:"RemoteException"`
+}
+
+var webhdfsException2Kind = map[string]error{
+	"FileNotFoundException":  ErrNotFound,
+	"AccessControlException": ErrPermissionDenied,
+	"SecurityException":      ErrPermissionDenied,
+	"InvalidToken":           ErrPermissionDenied,
+	"StandbyException":       ErrInterrupted,
+	"RetriableException":     ErrInterrupted,
+	"SafeModeException":      ErrInterrupted,
+}
+
+// ParseWebhdfsError parses the RemoteException of WebHDFS, the code is the name of the exception.
+func ParseWebhdfsError(err error, path string, resp *http.Response) error {
+	b, _ := io.ReadAll(resp.Body)
+	objectErr := &ObjectError{
+		source:     err,
+		kind:       kindFromStatus(resp.StatusCode),
+		path:       path,
+		body:       b,
+		statusCode: resp.StatusCode,
+	}
+	output := webhdfsErrorResponse{}
+	if len(b) > 0 && json.Unmarshal(b, &output) == nil {
+		objectErr.code = output.RemoteException.Exception
+		objectErr.message = output.RemoteException.Message
+		if exceptionKind, ok := webhdfsException2Kind[objectErr.code]; ok {
+			objectErr.kind = exceptionKind
+		}
+	}
+	return objectErr
+}
+
+var ftpCode2Kind = map[int]error{
+	// service not available, closing control connection
+	421: ErrInterrupted,
+	// can't open data connection
+	425: ErrInterrupted,
+	// connection closed, transfer aborted
+	426: ErrInterrupted,
+	// file unavailable, e.g. busy
+	450: ErrInterrupted,
+	// local error in processing
+	451: ErrInterrupted,
+	// not logged in
+	530: ErrPermissionDenied,
+	// need account for storing files
+	532: ErrPermissionDenied,
+	// file unavailable, e.g. not found or no access
+	550: ErrNotFound,
+}
+
+// ParseFtpError parses the error replied by the FTP server, the kind of other errors is the error itself.
+func ParseFtpError(src, err error, path string) error {
+	var protoErr *textproto.Error
+	if !errors.As(err, &protoErr) {
+		return ObjectError{
+			source: src,
+			kind:   err,
+			path:   path,
+		}
+	}
+	kind, ok := ftpCode2Kind[protoErr.Code]
+	if !ok {
+		kind = ErrOther
+	}
+	return ObjectError{
+		source:     src,
+		kind:       kind,
+		path:       path,
+		statusCode: protoErr.Code,
+		code:       strconv.Itoa(protoErr.Code),
+		message:    protoErr.Msg,
 	}
 }
 
@@ -96,3 +375,7 @@ func Wrap(err error, child error) error {
 func Is(err, target error) bool {
 	return errors.Is(err, target)
 }
+
+func As(err error, target any) bool {
+	return errors.As(err, target)
+}
@@ -0,0 +1,139 @@
+package errors
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/textproto"
+	"testing"
+)
+
+func s3Response(status int, body string) *http.Response {
+	header := http.Header{}
+	header.Set("x-amz-request-id", "header-request-id")
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(bytes.NewBufferString(body))}
+}
+
+func TestParseS3Error(t *testing.T) {
+	err := ParseS3Error(ErrReadFailed, "a.txt", s3Response(http.StatusNotFound, `<?xml version="1.0" encoding="UTF-8"?>
+<Error>
+  <Code>NoSuchKey</Code>
+  <Message>The resource you requested does not exist</Message>
+  <Resource>/bucket/a.txt</Resource>
+  <RequestId>4442587FB7D0A2F9</RequestId>
+  <HostId>host-id</HostId>
+</Error>`))
+	assert.True(t, Is(err, ErrReadFailed))
+	assert.True(t, Is(err, ErrNoSuchKey))
+	assert.True(t, Is(err, ErrNotFound))
+	assert.False(t, Is(err, ErrNoSuchBucket))
+	var objectErr *ObjectError
+	assert.True(t, As(err, &objectErr))
+	assert.Equal(t, "NoSuchKey", objectErr.Code())
+	assert.Equal(t, "4442587FB7D0A2F9", objectErr.RequestID())
+	assert.Equal(t, "host-id", objectErr.HostID())
+	assert.Equal(t, http.StatusNotFound, objectErr.StatusCode())
+
+	for code, kind := range map[string]error{
+		"NoSuchBucket":       ErrNoSuchBucket,
+		"PreconditionFailed": ErrPreconditionFailed,
+		"EntityTooSmall":     ErrEntityTooSmall,
+		"InvalidPart":        ErrInvalidPart,
+		"SlowDown":           ErrSlowDown,
+	} {
+		err = ParseS3Error(ErrWriteFailed, "a.txt", s3Response(http.StatusBadRequest, "<Error><Code>"+code+"</Code></Error>"))
+		assert.True(t, Is(err, kind), code)
+	}
+
+	// retryable
+	err = ParseS3Error(ErrWriteFailed, "a.txt", s3Response(http.StatusServiceUnavailable, "<Error><Code>SlowDown</Code></Error>"))
+	assert.True(t, Is(err, ErrInterrupted))
+	err = ParseS3Error(ErrWriteFailed, "a.txt", s3Response(http.StatusTooManyRequests, ""))
+	assert.True(t, Is(err, ErrSlowDown))
+	assert.True(t, Is(err, ErrInterrupted))
+
+	// HEAD responses don't have a body
+	err = ParseS3Error(ErrStatFailed, "a.txt", s3Response(http.StatusPreconditionFailed, ""))
+	assert.True(t, Is(err, ErrPreconditionFailed))
+	assert.True(t, As(err, &objectErr))
+	assert.Equal(t, "", objectErr.Code())
+	assert.Equal(t, "header-request-id", objectErr.RequestID())
+}
+
+func TestParseGcsError(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusNotFound,
+		Header:     http.Header{},
+		Body:       io.NopCloser(bytes.NewBufferString(`{"error":{"code":404,"message":"No such object: bucket/a.txt","errors":[{"message":"No such object: bucket/a.txt","domain":"global","reason":"notFound"}]}}`)),
+	}
+	err := ParseGcsError(ErrReadFailed, "a.txt", resp)
+	assert.True(t, Is(err, ErrReadFailed))
+	assert.True(t, Is(err, ErrNotFound))
+	var objectErr *ObjectError
+	assert.True(t, As(err, &objectErr))
+	assert.Equal(t, "notFound", objectErr.Code())
+	assert.Equal(t, "No such object: bucket/a.txt", objectErr.message)
+
+	resp = &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}, Body: io.NopCloser(bytes.NewBufferString(""))}
+	assert.True(t, Is(ParseGcsError(ErrWriteFailed, "a.txt", resp), ErrInterrupted))
+}
+
+func TestParseAzblobError(t *testing.T) {
+	header := http.Header{}
+	header.Set("x-ms-request-id", "request-id")
+	header.Set("x-ms-error-code", "BlobNotFound")
+	// HEAD responses don't have a body
+	err := ParseAzblobError(ErrStatFailed, "a.txt", &http.Response{StatusCode: http.StatusNotFound, Header: header, Body: io.NopCloser(bytes.NewBufferString(""))})
+	assert.True(t, Is(err, ErrNoSuchKey))
+	assert.True(t, Is(err, ErrNotFound))
+	var objectErr *ObjectError
+	assert.True(t, As(err, &objectErr))
+	assert.Equal(t, "BlobNotFound", objectErr.Code())
+	assert.Equal(t, "request-id", objectErr.RequestID())
+
+	err = ParseAzblobError(ErrCompleteMultipartFailed, "a.txt", &http.Response{StatusCode: http.StatusBadRequest, Header: http.Header{}, Body: io.NopCloser(bytes.NewBufferString(`<?xml version="1.0" encoding="utf-8"?><Error><Code>InvalidBlockList</Code><Message>The specified block list is invalid.</Message></Error>`))})
+	assert.True(t, Is(err, ErrInvalidPart))
+	assert.True(t, As(err, &objectErr))
+	assert.Equal(t, "The specified block list is invalid.", objectErr.message)
+}
+
+func TestParseHttpError(t *testing.T) {
+	err := ParseHttpError(ErrReadFailed, "a.txt", &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}, Body: io.NopCloser(bytes.NewBufferString("Not Found"))})
+	assert.True(t, Is(err, ErrNotFound))
+	assert.True(t, Is(err, ErrReadFailed))
+	var objectErr *ObjectError
+	assert.True(t, As(err, &objectErr))
+	assert.Equal(t, http.StatusNotFound, objectErr.StatusCode())
+
+	err = ParseHttpError(ErrWriteFailed, "a.txt", &http.Response{StatusCode: http.StatusInsufficientStorage, Header: http.Header{}, Body: io.NopCloser(bytes.NewBufferString(""))})
+	assert.True(t, Is(err, ErrOther))
+}
+
+func TestParseWebhdfsError(t *testing.T) {
+	err := ParseWebhdfsError(ErrStatFailed, "a.txt", &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}, Body: io.NopCloser(bytes.NewBufferString(`{"RemoteException":{"exception":"FileNotFoundException","javaClassName":"java.io.FileNotFoundException","message":"File does not exist: /a.txt"}}`))})
+	assert.True(t, Is(err, ErrNotFound))
+	assert.True(t, Is(err, ErrStatFailed))
+	var objectErr *ObjectError
+	assert.True(t, As(err, &objectErr))
+	assert.Equal(t, "FileNotFoundException", objectErr.Code())
+	assert.Equal(t, "File does not exist: /a.txt", objectErr.message)
+
+	// the standby namenode refuses the requests with 403
+	err = ParseWebhdfsError(ErrReadFailed, "a.txt", &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}, Body: io.NopCloser(bytes.NewBufferString(`{"RemoteException":{"exception":"StandbyException","javaClassName":"org.apache.hadoop.ipc.StandbyException","message":"Operation category READ is not supported in state standby"}}`))})
+	assert.True(t, Is(err, ErrInterrupted))
+}
+
+func TestParseFtpError(t *testing.T) {
+	err := ParseFtpError(ErrStatFailed, &textproto.Error{Code: 550, Msg: "No such file or directory"}, "a.txt")
+	assert.True(t, Is(err, ErrNotFound))
+	assert.True(t, Is(err, ErrStatFailed))
+	var objectErr ObjectError
+	assert.True(t, As(err, &objectErr))
+	assert.Equal(t, "550", objectErr.Code())
+	assert.Equal(t, 550, objectErr.StatusCode())
+
+	assert.True(t, Is(ParseFtpError(ErrReadFailed, &textproto.Error{Code: 530, Msg: "Not logged in"}, "a.txt"), ErrPermissionDenied))
+	assert.True(t, Is(ParseFtpError(ErrReadFailed, &textproto.Error{Code: 553, Msg: "File name not allowed"}, "a.txt"), ErrOther))
+	assert.True(t, Is(ParseFtpError(ErrReadFailed, io.ErrUnexpectedEOF, "a.txt"), io.ErrUnexpectedEOF))
+}
@@ -0,0 +1,64 @@
+package yadal
+
+import (
+	"context"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/utils"
+	"time"
+)
+
+type MultipartGCOptions struct {
+	// OlderThan only uploads initiated before `now - OlderThan` are collected.
+	OlderThan time.Duration
+	// DryRun reports the stale uploads without aborting them.
+	DryRun bool
+}
+
+type MultipartGCReport struct {
+	// Uploads the stale uploads, they are aborted unless DryRun is set.
+	Uploads []interfaces.MultipartUpload
+	// Aborted the number of aborted uploads.
+	Aborted int
+	// BytesReclaimed the total size of parts of the stale uploads.
+	BytesReclaimed uint64
+}
+
+// CollectStaleMultipart lists in-progress multipart uploads under the prefix
+// and aborts the ones initiated before `now - OlderThan`.
+//
+// # Behavior
+//
+//   - Requires capability: `Multipart`
+//   - It stops at the first failed abort, the returned report holds uploads aborted so far.
+func CollectStaleMultipart(ctx context.Context, acc interfaces.Accessor, prefix string, opt MultipartGCOptions) (*MultipartGCReport, error) {
+	if !acc.Metadata().Capability().Has(interfaces.Multipart) {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	// only the sizes of the stale uploads are summed up, it costs a request per upload on S3
+	deadline := time.Now().Add(-opt.OlderThan)
+	uploads, err := acc.ListMultipart(ctx, utils.NormalizePath(prefix), options.ListMultipart{WithSize: true, InitiatedBefore: deadline})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MultipartGCReport{}
+	for _, upload := range uploads {
+		if !upload.GetInitiated().Before(deadline) {
+			continue
+		}
+		if !opt.DryRun {
+			err = acc.AbortMultipart(ctx, upload.GetPath(), options.AbortMultipart{UploadId: upload.GetUploadId()})
+			if err != nil {
+				return report, err
+			}
+			report.Aborted++
+		}
+		report.Uploads = append(report.Uploads, upload)
+		if size := upload.GetSize(); size != nil {
+			report.BytesReclaimed += *size
+		}
+	}
+	return report, nil
+}
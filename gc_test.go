@@ -0,0 +1,73 @@
+package yadal
+
+import (
+	"context"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/providers"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type multipartAccessor struct {
+	interfaces.Accessor
+	uploads []interfaces.MultipartUpload
+	aborted []string
+}
+
+func (m *multipartAccessor) Metadata() interfaces.Metadata {
+	return providers.NewMetadata(interfaces.S3, "/", "", interfaces.Multipart)
+}
+
+func (m *multipartAccessor) ListMultipart(_ context.Context, _ string, args options.ListMultipart) ([]interfaces.MultipartUpload, error) {
+	var uploads []interfaces.MultipartUpload
+	for _, upload := range m.uploads {
+		if args.Includes(upload.GetInitiated()) {
+			uploads = append(uploads, upload)
+		}
+	}
+	return uploads, nil
+}
+
+func (m *multipartAccessor) AbortMultipart(_ context.Context, _ string, args options.AbortMultipart) error {
+	m.aborted = append(m.aborted, args.UploadId)
+	return nil
+}
+
+func newMultipartAccessor() *multipartAccessor {
+	size := uint64(1024)
+	return &multipartAccessor{
+		uploads: []interfaces.MultipartUpload{
+			object.MultipartUpload{Path: "a", UploadId: "stale", Initiated: time.Now().Add(-48 * time.Hour), Size: &size},
+			object.MultipartUpload{Path: "b", UploadId: "fresh", Initiated: time.Now(), Size: &size},
+		},
+	}
+}
+
+func TestCollectStaleMultipart(t *testing.T) {
+	acc := newMultipartAccessor()
+	report, err := CollectStaleMultipart(context.TODO(), acc, "", MultipartGCOptions{OlderThan: 24 * time.Hour})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, report.Aborted)
+	assert.Equal(t, uint64(1024), report.BytesReclaimed)
+	assert.Equal(t, []string{"stale"}, acc.aborted)
+}
+
+func TestCollectStaleMultipart_dryRun(t *testing.T) {
+	acc := newMultipartAccessor()
+	report, err := CollectStaleMultipart(context.TODO(), acc, "", MultipartGCOptions{OlderThan: 24 * time.Hour, DryRun: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, report.Aborted)
+	assert.Len(t, report.Uploads, 1)
+	assert.Equal(t, uint64(1024), report.BytesReclaimed)
+	assert.Empty(t, acc.aborted)
+}
+
+func TestListMultipartInitiatedBefore(t *testing.T) {
+	now := time.Now()
+	assert.True(t, options.ListMultipart{}.Includes(now))
+	assert.True(t, options.ListMultipart{InitiatedBefore: now}.Includes(now.Add(-time.Second)))
+	assert.False(t, options.ListMultipart{InitiatedBefore: now}.Includes(now))
+}
@@ -6,16 +6,25 @@ require (
 	github.com/Rican7/retry v0.3.1
 	github.com/aws/aws-sdk-go v1.44.115
 	github.com/google/uuid v1.3.0
-	github.com/stretchr/testify v1.8.0
+	github.com/jlaffaye/ftp v0.2.0
+	github.com/joho/godotenv v1.4.0
+	github.com/pkg/sftp v1.13.6
+	github.com/stretchr/testify v1.8.3
+	go.etcd.io/bbolt v1.3.9
 	go.uber.org/zap v1.23.0
+	golang.org/x/crypto v0.10.0
+	golang.org/x/net v0.11.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/hashicorp/errwrap v1.0.0 // indirect
+	github.com/hashicorp/go-multierror v1.1.1 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
-	github.com/joho/godotenv v1.4.0 // indirect
+	github.com/kr/fs v0.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	go.uber.org/atomic v1.7.0 // indirect
 	go.uber.org/multierr v1.6.0 // indirect
+	golang.org/x/sys v0.9.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
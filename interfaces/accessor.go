@@ -25,11 +25,12 @@ type Accessor interface {
 	// 	 - Input path MUST match with object.ObjectMode, WITHOUT checking ObjectMode.
 	Read(ctx context.Context, path string, args options.ReadOptions) (io.ReadCloser, error)
 
-	// Write returns written size if operation succeeded
+	// Write returns written size and the version if operation succeeded
 	//
 	// # Behavior
 	// 	 - Input path MUST be file path, WITHOUT checking ObjectMode.
-	Write(ctx context.Context, path string, args options.WriteOptions, reader io.ReadSeeker) (uint64, error)
+	// 	 - The reader is NOT required to be seekable, args.Size MUST be the exact size of it.
+	Write(ctx context.Context, path string, args options.WriteOptions, reader io.Reader) (WriteResult, error)
 
 	// Stat
 	//
@@ -75,7 +76,8 @@ type Accessor interface {
 	// # Behavior
 	//
 	//  - Requires capability: `Multipart`
-	WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.ReadSeeker) (ObjectPart, error)
+	//  - The reader is NOT required to be seekable, args.Size MUST be the exact size of it.
+	WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.Reader) (ObjectPart, error)
 
 	// CompleteMultipart
 	// # Behavior
@@ -88,6 +90,32 @@ type Accessor interface {
 	//
 	//  - Requires capability: `Multipart`
 	AbortMultipart(ctx context.Context, path string, args options.AbortMultipart) error
+
+	// ListMultipart returns in-progress multipart uploads
+	// # Behavior
+	//
+	//  - Requires capability: `Multipart`
+	//  - Input path is used as a prefix, uploads of all sub paths are returned.
+	ListMultipart(ctx context.Context, path string, args options.ListMultipart) ([]MultipartUpload, error)
+
+	// GetTags returns the tags of the object
+	// # Behavior
+	//
+	//  - Requires capability: `Tagging`
+	GetTags(ctx context.Context, path string, args options.GetTags) (map[string]string, error)
+
+	// SetTags replaces the tags of the object
+	// # Behavior
+	//
+	//  - Requires capability: `Tagging`
+	SetTags(ctx context.Context, path string, args options.SetTags) error
+
+	// ListVersions returns all versions and delete markers of the objects in the dir
+	// # Behavior
+	//
+	//  - Requires capability: `Versioning`
+	//  - Input path MUST be dir path, the versions are listed as entries of the same path.
+	ListVersions(ctx context.Context, path string, args options.ListVersions) (ObjectStream, error)
 }
 
 type Capability uint8
@@ -112,7 +140,7 @@ func (c Capability) String() string {
 }
 
 var (
-	cRange = []Capability{Read, Write, List, PreSign, Multipart, Blocking}
+	cRange = []Capability{Read, Write, List, PreSign, Multipart, Blocking, Tagging, Versioning}
 )
 
 func (c Capability) CapString() string {
@@ -129,6 +157,10 @@ func (c Capability) CapString() string {
 		return "Multipart"
 	case Blocking:
 		return "Blocking"
+	case Tagging:
+		return "Tagging"
+	case Versioning:
+		return "Versioning"
 	default:
 		return "Unknown"
 	}
@@ -152,6 +184,12 @@ const (
 
 	// Blocking `blocking`
 	Blocking
+
+	// Tagging `getTags` and `setTags`
+	Tagging
+
+	// Versioning `listVersions` and the version of `read`, `stat` and `delete`
+	Versioning
 )
 
 type Metadata interface {
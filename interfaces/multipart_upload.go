@@ -0,0 +1,11 @@
+package interfaces
+
+import "time"
+
+type MultipartUpload interface {
+	GetPath() string
+	GetUploadId() string
+	GetInitiated() time.Time
+	// GetSize returns the total size of uploaded parts, nil if unknown.
+	GetSize() *uint64
+}
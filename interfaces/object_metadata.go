@@ -10,4 +10,11 @@ type ObjectMetadata interface {
 	ContentMD5() *string
 	LastModified() *time.Time
 	ETag() *string
+	// StorageClass returns nil if the provider doesn't report it.
+	StorageClass() *string
+	// VersionId returns nil if the object is not versioned.
+	VersionId() *string
+	// IsLatest returns nil if it's unknown.
+	IsLatest() *bool
+	IsDeleteMarker() bool
 }
@@ -15,6 +15,10 @@ const (
 	WriteMultipartOp
 	CompleteMultipartOp
 	AbortMultipartOp
+	ListMultipartOp
+	GetTagsOp
+	SetTagsOp
+	ListVersionsOp
 )
 
 var (
@@ -32,6 +36,10 @@ var (
 		"WriteMultipart",
 		"CompleteMultipart",
 		"AbortMultipart",
+		"ListMultipart",
+		"GetTags",
+		"SetTags",
+		"ListVersions",
 	}
 )
 
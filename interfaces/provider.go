@@ -3,12 +3,22 @@ package interfaces
 type Provider int
 
 var (
-	provider2Str = []string{"Unknown", "S3", "FS"}
+	provider2Str = []string{"Unknown", "S3", "FS", "GCS", "AZBLOB", "WEBDAV", "SFTP", "FTP", "HTTP", "WEBHDFS", "BOLT", "ARCHIVE", "IOFS"}
 )
 
 const (
 	S3 Provider = iota + 1
 	Fs
+	Gcs
+	Azblob
+	Webdav
+	Sftp
+	Ftp
+	Http
+	Webhdfs
+	Bolt
+	Archive
+	IoFs
 )
 
 func (p Provider) String() string {
@@ -0,0 +1,9 @@
+package interfaces
+
+type WriteResult interface {
+	GetSize() uint64
+	// GetETag returns nil if the provider doesn't report it.
+	GetETag() *string
+	// GetVersionId returns nil if the object is not versioned.
+	GetVersionId() *string
+}
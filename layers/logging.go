@@ -76,14 +76,14 @@ func (l loggingAccessor) Read(ctx context.Context, path string, args options.Rea
 	return reader, err
 }
 
-func (l loggingAccessor) Write(ctx context.Context, path string, args options.WriteOptions, reader io.ReadSeeker) (uint64, error) {
+func (l loggingAccessor) Write(ctx context.Context, path string, args options.WriteOptions, reader io.Reader) (interfaces.WriteResult, error) {
 	l.Infof("dal::service service=%s operation=%s path=%s size=%d -> starting", interfaces.WriteOp, path, l.innerProvider(), args.Size)
-	size, err := l.inner.Write(ctx, path, args, reader)
+	result, err := l.inner.Write(ctx, path, args, reader)
 	l.Infof("dal::service service=%s operation=%s path=%s size=%d -> finished", interfaces.WriteOp, path, l.innerProvider(), args.Size)
 	if err != nil {
 		l.Infof("dal::service service=%s operation=%s path=%s size=%d -> error: %s", interfaces.WriteOp, path, l.innerProvider(), args.Size, err)
 	}
-	return size, err
+	return result, err
 }
 
 func (l loggingAccessor) Stat(ctx context.Context, path string, args options.StatOptions) (interfaces.ObjectMetadata, error) {
@@ -136,7 +136,7 @@ func (l loggingAccessor) CreateMultipart(ctx context.Context, path string, args
 	return uploadId, err
 }
 
-func (l loggingAccessor) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.ReadSeeker) (interfaces.ObjectPart, error) {
+func (l loggingAccessor) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.Reader) (interfaces.ObjectPart, error) {
 	l.Infof("dal::service service=%s operation=%s -> starting", interfaces.WriteMultipartOp, l.innerProvider())
 	part, err := l.inner.WriteMultipart(ctx, path, args, reader)
 	l.Infof("dal::service service=%s operation=%s -> finished", interfaces.WriteMultipartOp, l.innerProvider())
@@ -166,6 +166,46 @@ func (l loggingAccessor) AbortMultipart(ctx context.Context, path string, args o
 	return err
 }
 
+func (l loggingAccessor) GetTags(ctx context.Context, path string, args options.GetTags) (map[string]string, error) {
+	l.Infof("dal::service service=%s operation=%s path=%s -> starting", interfaces.GetTagsOp, l.innerProvider(), path)
+	tags, err := l.inner.GetTags(ctx, path, args)
+	l.Infof("dal::service service=%s operation=%s path=%s -> finished", interfaces.GetTagsOp, l.innerProvider(), path)
+	if err != nil {
+		l.Infof("dal::service service=%s operation=%s path=%s -> error: %s", interfaces.GetTagsOp, l.innerProvider(), path, err)
+	}
+	return tags, err
+}
+
+func (l loggingAccessor) SetTags(ctx context.Context, path string, args options.SetTags) error {
+	l.Infof("dal::service service=%s operation=%s path=%s -> starting", interfaces.SetTagsOp, l.innerProvider(), path)
+	err := l.inner.SetTags(ctx, path, args)
+	l.Infof("dal::service service=%s operation=%s path=%s -> finished", interfaces.SetTagsOp, l.innerProvider(), path)
+	if err != nil {
+		l.Infof("dal::service service=%s operation=%s path=%s -> error: %s", interfaces.SetTagsOp, l.innerProvider(), path, err)
+	}
+	return err
+}
+
+func (l loggingAccessor) ListVersions(ctx context.Context, path string, args options.ListVersions) (interfaces.ObjectStream, error) {
+	l.Infof("dal::service service=%s operation=%s path=%s -> starting", interfaces.ListVersionsOp, l.innerProvider(), path)
+	stream, err := l.inner.ListVersions(ctx, path, args)
+	l.Infof("dal::service service=%s operation=%s path=%s -> finished", interfaces.ListVersionsOp, l.innerProvider(), path)
+	if err != nil {
+		l.Infof("dal::service service=%s operation=%s path=%s -> error: %s", interfaces.ListVersionsOp, l.innerProvider(), path, err)
+	}
+	return stream, err
+}
+
+func (l loggingAccessor) ListMultipart(ctx context.Context, path string, args options.ListMultipart) ([]interfaces.MultipartUpload, error) {
+	l.Infof("dal::service service=%s operation=%s path=%s -> starting", interfaces.ListMultipartOp, l.innerProvider(), path)
+	uploads, err := l.inner.ListMultipart(ctx, path, args)
+	l.Infof("dal::service service=%s operation=%s path=%s -> finished", interfaces.ListMultipartOp, l.innerProvider(), path)
+	if err != nil {
+		l.Infof("dal::service service=%s operation=%s path=%s -> error: %s", interfaces.ListMultipartOp, l.innerProvider(), path, err)
+	}
+	return uploads, err
+}
+
 func SetLogger(logger Logger) LoggingOption {
 	return func(r *LoggingOptions) {
 		r.Logger = logger
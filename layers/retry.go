@@ -31,6 +31,17 @@ func IsErrInterrupted(err error) bool {
 	return errors.Is(err, errors.ErrInterrupted)
 }
 
+// rewind seeks the reader back to the start before a retry,
+// a reader which is not seekable has been consumed and can't be retried.
+func rewind(reader io.Reader) bool {
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		return false
+	}
+	_, err := seeker.Seek(0, io.SeekStart)
+	return err == nil
+}
+
 func (r retryAccessor) Metadata() interfaces.Metadata {
 	return r.inner.Metadata()
 }
@@ -51,9 +62,12 @@ func (r retryAccessor) Read(ctx context.Context, path string, args options.ReadO
 	return
 }
 
-func (r retryAccessor) Write(ctx context.Context, path string, args options.WriteOptions, reader io.ReadSeeker) (size uint64, innerErr error) {
-	_ = retry.Retry(func(_ uint) error {
-		size, innerErr = r.inner.Write(ctx, path, args, reader)
+func (r retryAccessor) Write(ctx context.Context, path string, args options.WriteOptions, reader io.Reader) (result interfaces.WriteResult, innerErr error) {
+	_ = retry.Retry(func(attempt uint) error {
+		if attempt > 0 && !rewind(reader) {
+			return nil
+		}
+		result, innerErr = r.inner.Write(ctx, path, args, reader)
 		return RetryWhen(innerErr, IsErrInterrupted)
 	}, r.Strategies...)
 	return
@@ -99,8 +113,11 @@ func (r retryAccessor) CreateMultipart(ctx context.Context, path string, args op
 	return
 }
 
-func (r retryAccessor) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.ReadSeeker) (part interfaces.ObjectPart, innerErr error) {
-	_ = retry.Retry(func(_ uint) error {
+func (r retryAccessor) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.Reader) (part interfaces.ObjectPart, innerErr error) {
+	_ = retry.Retry(func(attempt uint) error {
+		if attempt > 0 && !rewind(reader) {
+			return nil
+		}
 		part, innerErr = r.inner.WriteMultipart(ctx, path, args, reader)
 		return RetryWhen(innerErr, IsErrInterrupted)
 	}, r.Strategies...)
@@ -123,6 +140,38 @@ func (r retryAccessor) AbortMultipart(ctx context.Context, path string, args opt
 	return
 }
 
+func (r retryAccessor) GetTags(ctx context.Context, path string, args options.GetTags) (tags map[string]string, innerErr error) {
+	_ = retry.Retry(func(_ uint) error {
+		tags, innerErr = r.inner.GetTags(ctx, path, args)
+		return RetryWhen(innerErr, IsErrInterrupted)
+	}, r.Strategies...)
+	return
+}
+
+func (r retryAccessor) SetTags(ctx context.Context, path string, args options.SetTags) (innerErr error) {
+	_ = retry.Retry(func(_ uint) error {
+		innerErr = r.inner.SetTags(ctx, path, args)
+		return RetryWhen(innerErr, IsErrInterrupted)
+	}, r.Strategies...)
+	return
+}
+
+func (r retryAccessor) ListVersions(ctx context.Context, path string, args options.ListVersions) (stream interfaces.ObjectStream, innerErr error) {
+	_ = retry.Retry(func(_ uint) error {
+		stream, innerErr = r.inner.ListVersions(ctx, path, args)
+		return RetryWhen(innerErr, IsErrInterrupted)
+	}, r.Strategies...)
+	return
+}
+
+func (r retryAccessor) ListMultipart(ctx context.Context, path string, args options.ListMultipart) (uploads []interfaces.MultipartUpload, innerErr error) {
+	_ = retry.Retry(func(_ uint) error {
+		uploads, innerErr = r.inner.ListMultipart(ctx, path, args)
+		return RetryWhen(innerErr, IsErrInterrupted)
+	}, r.Strategies...)
+	return
+}
+
 type RetryOption func(r *RetryOptions)
 
 func SetStrategy(s ...strategy.Strategy) RetryOption {
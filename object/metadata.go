@@ -17,6 +17,10 @@ type Metadata struct {
 	contentMD5    *string
 	lastModified  *time.Time
 	etag          *string
+	storageClass  *string
+	versionId     *string
+	isLatest      *bool
+	deleteMarker  bool
 }
 
 func (m Metadata) Mode() interfaces.ObjectMode {
@@ -39,6 +43,22 @@ func (m Metadata) ETag() *string {
 	return m.etag
 }
 
+func (m Metadata) StorageClass() *string {
+	return m.storageClass
+}
+
+func (m Metadata) VersionId() *string {
+	return m.versionId
+}
+
+func (m Metadata) IsLatest() *bool {
+	return m.isLatest
+}
+
+func (m Metadata) IsDeleteMarker() bool {
+	return m.deleteMarker
+}
+
 type MetadataOptions = func(metadata *Metadata) error
 
 func NewMetadata(opts ...MetadataOptions) (interfaces.ObjectMetadata, error) {
@@ -125,10 +145,70 @@ func ParseLastModified(header http.Header) MetadataOptions {
 	}
 }
 
+func ParseStorageClass(header http.Header) MetadataOptions {
+	return func(metadata *Metadata) error {
+		storageClass := header.Get(constants.XAmzStorageClass)
+		if storageClass != "" {
+			metadata.storageClass = &storageClass
+		}
+		return nil
+	}
+}
+
+func ParseVersionId(header http.Header) MetadataOptions {
+	return func(metadata *Metadata) error {
+		versionId := header.Get(constants.XAmzVersionId)
+		if versionId != "" {
+			metadata.versionId = &versionId
+		}
+		metadata.deleteMarker = header.Get(constants.XAmzDeleteMarker) == "true"
+		return nil
+	}
+}
+
+// SetContentMD5 overrides the md5 derived from the etag, for the providers whose etag is not the md5.
+func SetContentMD5(md5 string) MetadataOptions {
+	return func(metadata *Metadata) error {
+		metadata.contentMD5 = &md5
+		return nil
+	}
+}
+
+// SetETag sets the etag only, for the providers whose etag is not the md5.
+func SetETag(etag string) MetadataOptions {
+	return func(metadata *Metadata) error {
+		if etag != "" {
+			metadata.etag = &etag
+		}
+		return nil
+	}
+}
+
+func SetStorageClass(storageClass string) MetadataOptions {
+	return func(metadata *Metadata) error {
+		if storageClass != "" {
+			metadata.storageClass = &storageClass
+		}
+		return nil
+	}
+}
+
+// SetVersion sets the version of an object listed among versions.
+func SetVersion(versionId string, isLatest bool, deleteMarker bool) MetadataOptions {
+	return func(metadata *Metadata) error {
+		metadata.versionId = &versionId
+		metadata.isLatest = &isLatest
+		metadata.deleteMarker = deleteMarker
+		return nil
+	}
+}
+
 var metadataOptions = []func(header http.Header) MetadataOptions{
 	ParseContentLength,
 	ParseETag,
 	ParseLastModified,
+	ParseStorageClass,
+	ParseVersionId,
 }
 
 func SetMetadataFromHeader(header http.Header) MetadataOptions {
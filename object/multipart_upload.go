@@ -0,0 +1,26 @@
+package object
+
+import "time"
+
+type MultipartUpload struct {
+	Path      string
+	UploadId  string
+	Initiated time.Time
+	Size      *uint64
+}
+
+func (m MultipartUpload) GetPath() string {
+	return m.Path
+}
+
+func (m MultipartUpload) GetUploadId() string {
+	return m.UploadId
+}
+
+func (m MultipartUpload) GetInitiated() time.Time {
+	return m.Initiated
+}
+
+func (m MultipartUpload) GetSize() *uint64 {
+	return m.Size
+}
@@ -0,0 +1,19 @@
+package object
+
+type WriteResult struct {
+	Size      uint64
+	ETag      *string
+	VersionId *string
+}
+
+func (w WriteResult) GetSize() uint64 {
+	return w.Size
+}
+
+func (w WriteResult) GetETag() *string {
+	return w.ETag
+}
+
+func (w WriteResult) GetVersionId() *string {
+	return w.VersionId
+}
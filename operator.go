@@ -1,6 +1,7 @@
 package yadal
 
 import (
+	"context"
 	"github.com/senrok/yadal/interfaces"
 	"github.com/senrok/yadal/object"
 )
@@ -13,6 +14,11 @@ func (o *Operator) Metadata() interfaces.Metadata {
 	return o.accessor.Metadata()
 }
 
+// Accessor returns the underlying interfaces.Accessor, layers included
+func (o *Operator) Accessor() interfaces.Accessor {
+	return o.accessor
+}
+
 // Object returns an object.Object handler
 func (o *Operator) Object(path string) object.Object {
 	return object.NewObject(o.accessor, path)
@@ -24,6 +30,11 @@ func (o *Operator) Layer(layer interfaces.Layer) *Operator {
 	return o
 }
 
+// CollectStaleMultipart aborts in-progress multipart uploads under the prefix which are older than `opt.OlderThan`
+func (o *Operator) CollectStaleMultipart(ctx context.Context, prefix string, opt MultipartGCOptions) (*MultipartGCReport, error) {
+	return CollectStaleMultipart(ctx, o.accessor, prefix, opt)
+}
+
 // NewOperatorFromAccessor returns the Operator from the interfaces.Accessor
 func NewOperatorFromAccessor(acc interfaces.Accessor) Operator {
 	return Operator{
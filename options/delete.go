@@ -1,4 +1,6 @@
 package options
 
 type DeleteOptions struct {
+	// VersionId the version of the object, defaults to the latest one.
+	VersionId string
 }
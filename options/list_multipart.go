@@ -0,0 +1,17 @@
+package options
+
+import "time"
+
+type ListMultipart struct {
+	// WithSize sums up the size of uploaded parts for each upload,
+	// it may cost extra requests on some providers.
+	WithSize bool
+	// InitiatedBefore only lists the uploads initiated before the time if it's set,
+	// the sizes of the newer uploads are not summed up.
+	InitiatedBefore time.Time
+}
+
+// Includes reports whether the upload initiated at the time should be listed.
+func (l ListMultipart) Includes(initiated time.Time) bool {
+	return l.InitiatedBefore.IsZero() || initiated.Before(l.InitiatedBefore)
+}
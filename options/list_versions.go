@@ -0,0 +1,4 @@
+package options
+
+type ListVersions struct {
+}
@@ -1,13 +1,37 @@
 package options
 
-import "time"
+import (
+	"net/http"
+	"time"
+)
 
 type PreSignOptions struct {
 	Op PreSignOperation
 	*ReadOptions
 	*WriteOptions
 	*WriteMultipart
-	Expire time.Duration
+	// StatOptions the version and SSE-C settings of StatOp.
+	StatOptions *StatOptions
+	// CreateMultipart the storage class, ACL, tags and SSE settings of CreateMultipartOp.
+	CreateMultipart *CreateMultipart
+	// CompleteMultipart the upload of CompleteMultipartOp, it's named to keep the fields of WriteMultipart promoted.
+	CompleteMultipart *CompleteMultipart
+	// Headers are signed into the request, the caller MUST send them as is,
+	// e.g. Content-Type, Content-MD5 or SSE-C headers.
+	Headers http.Header
+	// Response overrides the response headers of ReadOp.
+	Response *ResponseOverrides
+	Expire   time.Duration
+}
+
+// ResponseOverrides overrides the headers of the response, e.g. `response-content-disposition`.
+type ResponseOverrides struct {
+	ContentType        string
+	ContentDisposition string
+	ContentEncoding    string
+	ContentLanguage    string
+	CacheControl       string
+	Expires            string
 }
 
 type PreSignOperation int
@@ -16,10 +40,14 @@ const (
 	ReadOp PreSignOperation = iota + 1
 	WriteOp
 	WriteMultipartOp
+	StatOp
+	DeleteOp
+	CreateMultipartOp
+	CompleteMultipartOp
 )
 
 var (
-	op2str = []string{"Unknown", "Read", "Write", "WriteMultipart"}
+	op2str = []string{"Unknown", "Read", "Write", "WriteMultipart", "Stat", "Delete", "CreateMultipart", "CompleteMultipart"}
 )
 
 func (p PreSignOperation) String() string {
@@ -3,4 +3,8 @@ package options
 type ReadOptions struct {
 	Offset *uint64
 	Size   *uint64
+	// SSE overrides the server side encryption settings of the provider.
+	SSE *ServerSideEncryption
+	// VersionId the version of the object, defaults to the latest one.
+	VersionId string
 }
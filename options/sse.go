@@ -0,0 +1,34 @@
+package options
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+)
+
+// ServerSideEncryption overrides the server side encryption settings of the provider,
+// it replaces the provider defaults as a whole if any field is set.
+type ServerSideEncryption struct {
+	// Encryption e.g. `AES256` or `aws:kms`, only sent on writes.
+	Encryption *string
+	// AwsKmsKeyId only sent on writes.
+	AwsKmsKeyId *string
+	// CustomerAlgo defaults to `AES256` if CustomerKey is set.
+	CustomerAlgo *string
+	// CustomerKey the base64 encoded key.
+	CustomerKey *string
+	// CustomerKeyMD5 the base64 encoded md5 of the key, it's derived from CustomerKey if nil.
+	CustomerKeyMD5 *string
+}
+
+// NewSSECustomerKey returns the SSE-C settings of the raw 256-bit key.
+func NewSSECustomerKey(key []byte) *ServerSideEncryption {
+	algo := "AES256"
+	encoded := base64.StdEncoding.EncodeToString(key)
+	sum := md5.Sum(key)
+	keyMD5 := base64.StdEncoding.EncodeToString(sum[:])
+	return &ServerSideEncryption{
+		CustomerAlgo:   &algo,
+		CustomerKey:    &encoded,
+		CustomerKeyMD5: &keyMD5,
+	}
+}
@@ -1,4 +1,8 @@
 package options
 
 type StatOptions struct {
+	// SSE overrides the server side encryption settings of the provider.
+	SSE *ServerSideEncryption
+	// VersionId the version of the object, defaults to the latest one.
+	VersionId string
 }
@@ -0,0 +1,9 @@
+package options
+
+type GetTags struct {
+}
+
+type SetTags struct {
+	// Tags replaces all tags of the object, an empty Tags removes them.
+	Tags map[string]string
+}
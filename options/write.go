@@ -2,4 +2,12 @@ package options
 
 type WriteOptions struct {
 	Size uint64
+	// StorageClass e.g. `STANDARD_IA` or `GLACIER_IR`, defaults to the provider default.
+	StorageClass string
+	// ACL the canned ACL, e.g. `private` or `public-read`.
+	ACL string
+	// Tags the tags of the object.
+	Tags map[string]string
+	// SSE overrides the server side encryption settings of the provider.
+	SSE *ServerSideEncryption
 }
@@ -4,4 +4,6 @@ type WriteMultipart struct {
 	UploadId   string
 	PartNumber uint
 	Size       uint64
+	// SSE overrides the server side encryption settings of the provider.
+	SSE *ServerSideEncryption
 }
@@ -0,0 +1,245 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"context"
+	"fmt"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/logger"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/providers"
+	"github.com/senrok/yadal/utils"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+type Driver struct {
+	acc    interfaces.Accessor
+	path   string
+	format Format
+	root   string
+
+	// mu guards idx, which is loaded at the first access.
+	mu  sync.Mutex
+	idx index
+	logger.Logger
+}
+
+func (d *Driver) Metadata() interfaces.Metadata {
+	return providers.NewMetadata(interfaces.Archive, d.root, d.path, interfaces.Read|interfaces.List)
+}
+
+// index returns the index of the archive, it's loaded once, and it's reloaded if the loading fails.
+func (d *Driver) index(ctx context.Context) (index, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.idx != nil {
+		return d.idx, nil
+	}
+	idx, err := load(ctx, d.acc, d.path, d.format)
+	if err != nil {
+		return nil, err
+	}
+	d.idx = idx
+	return idx, nil
+}
+
+// lookup returns the node of the path, the error is ErrNotFound if it doesn't exist.
+func (d *Driver) lookup(ctx context.Context, path string, kind error) (*node, error) {
+	idx, err := d.index(ctx)
+	if err != nil {
+		return nil, errors.ParseFsError(kind, err, path)
+	}
+	if path == "/" {
+		path = ""
+	}
+	key, err := utils.BuildAbsPath(d.root, path)
+	if err != nil {
+		return nil, errors.ParseFsError(kind, err, path)
+	}
+	n, ok := idx[key]
+	if !ok {
+		return nil, errors.ParseFsError(kind, os.ErrNotExist, path)
+	}
+	return n, nil
+}
+
+func (d *Driver) Create(ctx context.Context, path string, args options.CreateOptions) error {
+	return errors.ErrUnsupportedMethod
+}
+
+// Read reads the file in ranges if the data is stored as is, otherwise the data is decompressed or scanned,
+// and the bytes before the offset are discarded.
+func (d *Driver) Read(ctx context.Context, path string, args options.ReadOptions) (io.ReadCloser, error) {
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	n, err := d.lookup(ctx, path, errors.ErrReadFailed)
+	if err != nil {
+		return nil, err
+	}
+	if n.dir {
+		return nil, errors.ParseFsError(errors.ErrReadFailed, os.ErrNotExist, path)
+	}
+	var offset uint64
+	if args.Offset != nil {
+		offset = *args.Offset
+	}
+	size := uint64(0)
+	if offset < n.size {
+		size = n.size - offset
+	}
+	if args.Size != nil && *args.Size < size {
+		size = *args.Size
+	}
+	// an empty range is invalid, the servers would return the whole archive instead
+	if size == 0 {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+
+	var reader io.ReadCloser
+	switch {
+	case n.offset >= 0 && (d.format == FormatTar || n.method == zip.Store):
+		o := uint64(n.offset) + offset
+		return d.acc.Read(ctx, d.path, options.ReadOptions{Offset: &o, Size: &size})
+	case n.offset >= 0 && n.method == zip.Deflate:
+		o := uint64(n.offset)
+		raw, err := d.acc.Read(ctx, d.path, options.ReadOptions{Offset: &o, Size: &n.compressed})
+		if err != nil {
+			return nil, err
+		}
+		reader = utils.NewReadCloser(flate.NewReader(raw), raw)
+	case d.format == FormatTar || d.format == FormatTarGz:
+		if reader, err = d.scan(ctx, n.ordinal); err != nil {
+			return nil, errors.ParseFsError(errors.ErrReadFailed, err, path)
+		}
+	default:
+		return nil, errors.ParseFsError(errors.ErrReadFailed, zip.ErrAlgorithm, path)
+	}
+	if offset > 0 {
+		if _, err = io.CopyN(io.Discard, reader, int64(offset)); err != nil && err != io.EOF {
+			_ = reader.Close()
+			return nil, errors.ParseFsError(errors.ErrReadFailed, err, path)
+		}
+	}
+	return utils.NewReadCloser(io.LimitReader(reader, int64(size)), reader), nil
+}
+
+// scan reads the tar from the beginning until the entry of the ordinal.
+func (d *Driver) scan(ctx context.Context, ordinal int) (io.ReadCloser, error) {
+	closer, reader, err := openTar(ctx, d.acc, d.path, d.format == FormatTarGz)
+	if err != nil {
+		return nil, err
+	}
+	r := tar.NewReader(reader)
+	for i := 0; i <= ordinal; i++ {
+		if _, err = r.Next(); err != nil {
+			_ = closer.Close()
+			if err == io.EOF {
+				err = fmt.Errorf("the archive %s is changed", d.path)
+			}
+			return nil, err
+		}
+	}
+	return utils.NewReadCloser(r, closer), nil
+}
+
+func (d *Driver) Write(ctx context.Context, path string, args options.WriteOptions, reader io.Reader) (interfaces.WriteResult, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func metadata(n *node) (interfaces.ObjectMetadata, error) {
+	if n.dir {
+		return object.NewMetadata(object.SetMode(interfaces.DIR))
+	}
+	return object.NewMetadata(
+		object.SetMode(interfaces.FILE),
+		object.SetMetadata(n.size, n.modified, ""),
+	)
+}
+
+func (d *Driver) Stat(ctx context.Context, path string, args options.StatOptions) (interfaces.ObjectMetadata, error) {
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	n, err := d.lookup(ctx, path, errors.ErrStatFailed)
+	if err != nil {
+		return nil, err
+	}
+	return metadata(n)
+}
+
+func (d *Driver) Delete(ctx context.Context, path string, args options.DeleteOptions) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) List(ctx context.Context, path string, args options.ListOptions) (interfaces.ObjectStream, error) {
+	return object.NewObjectStream(NewDirStream(d, d.root, path)), nil
+}
+
+func (d *Driver) ListVersions(ctx context.Context, path string, args options.ListVersions) (interfaces.ObjectStream, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) PreSign(ctx context.Context, path string, args options.PreSignOptions) (*http.Request, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) GetTags(ctx context.Context, path string, args options.GetTags) (map[string]string, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) SetTags(ctx context.Context, path string, args options.SetTags) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) CreateMultipart(ctx context.Context, path string, args options.CreateMultipart) (string, error) {
+	return "", errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.Reader) (interfaces.ObjectPart, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) CompleteMultipart(ctx context.Context, path string, args options.CompleteMultipart) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) AbortMultipart(ctx context.Context, path string, args options.AbortMultipart) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) ListMultipart(ctx context.Context, path string, args options.ListMultipart) ([]interfaces.MultipartUpload, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+// NewDriver returns a read-only driver of the contents of the archive stored in the accessor,
+// the archive is indexed at the first access, and it's assumed not to be changed afterwards.
+func NewDriver(ctx context.Context, opt Options) (interfaces.Accessor, error) {
+	if opt.Accessor == nil {
+		return nil, fmt.Errorf("accessor is required")
+	}
+	if opt.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	format := opt.Format
+	if format == FormatAuto {
+		var err error
+		if format, err = detectFormat(opt.Path); err != nil {
+			return nil, err
+		}
+	}
+	return &Driver{
+		acc:    opt.Accessor,
+		path:   opt.Path,
+		format: format,
+		root:   utils.NormalizeRoot(opt.Root),
+	}, nil
+}
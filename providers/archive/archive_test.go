@@ -0,0 +1,250 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/providers/fs"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var (
+	content = []byte("Hello,World!")
+	large   = bytes.Repeat([]byte("0123456789"), 20000)
+)
+
+// recorder records the read ranges of the archive.
+type recorder struct {
+	interfaces.Accessor
+	reads []options.ReadOptions
+}
+
+func (r *recorder) Read(ctx context.Context, path string, args options.ReadOptions) (io.ReadCloser, error) {
+	r.reads = append(r.reads, args)
+	return r.Accessor.Read(ctx, path, args)
+}
+
+func buildZip(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for _, f := range []struct {
+		name   string
+		method uint16
+		data   []byte
+	}{
+		{"dir/", zip.Store, nil},
+		{"dir/stored.txt", zip.Store, content},
+		{"dir/empty", zip.Store, nil},
+		{"dir/sub/deflated.txt", zip.Deflate, content},
+		{"./large.bin", zip.Deflate, large},
+		{"../escaped", zip.Store, content},
+	} {
+		fw, err := w.CreateHeader(&zip.FileHeader{Name: f.name, Method: f.method, Modified: time.Unix(1136214245, 0)})
+		assert.Nil(t, err)
+		_, err = fw.Write(f.data)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, w.Close())
+	return buf.Bytes()
+}
+
+func buildTar(t *testing.T, gzipped bool) []byte {
+	buf := &bytes.Buffer{}
+	var out io.Writer = buf
+	var gz *gzip.Writer
+	if gzipped {
+		gz = gzip.NewWriter(buf)
+		out = gz
+	}
+	w := tar.NewWriter(out)
+	for _, f := range []struct {
+		name string
+		flag byte
+		data []byte
+	}{
+		{"dir/", tar.TypeDir, nil},
+		{"dir/stored.txt", tar.TypeReg, []byte("replaced")},
+		{"dir/sub/deflated.txt", tar.TypeReg, content},
+		{"dir/empty", tar.TypeReg, nil},
+		{"link", tar.TypeSymlink, nil},
+		{"large.bin", tar.TypeReg, large},
+		// the later one replaces the former one like extracting
+		{"dir/stored.txt", tar.TypeReg, content},
+	} {
+		assert.Nil(t, w.WriteHeader(&tar.Header{Name: f.name, Typeflag: f.flag, Size: int64(len(f.data)), Mode: 0644, ModTime: time.Unix(1136214245, 0), Linkname: "large.bin"}))
+		_, err := w.Write(f.data)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, w.Close())
+	if gz != nil {
+		assert.Nil(t, gz.Close())
+	}
+	return buf.Bytes()
+}
+
+func setupDriver(t *testing.T, name string, b []byte, root string) (*Driver, *recorder) {
+	dir, err := os.MkdirTemp(".", "archive-")
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, name), b, 0644))
+	r := &recorder{Accessor: fs.NewDriver(fs.Options{Root: dir})}
+	d, err := NewDriver(context.Background(), Options{Accessor: r, Path: name, Root: root})
+	assert.Nil(t, err)
+	return d.(*Driver), r
+}
+
+func TestDriver(t *testing.T) {
+	ctx := context.Background()
+	for name, b := range map[string][]byte{
+		"archive.zip":    buildZip(t),
+		"archive.tar":    buildTar(t, false),
+		"archive.tar.gz": buildTar(t, true),
+	} {
+		d, r := setupDriver(t, name, b, "/")
+		assert.Equal(t, interfaces.Archive, d.Metadata().Provider())
+		assert.True(t, d.Metadata().Capability().Has(interfaces.Read, interfaces.List))
+		assert.False(t, d.Metadata().Capability().Has(interfaces.Write))
+
+		for _, path := range []string{"dir/stored.txt", "dir/sub/deflated.txt"} {
+			meta, err := d.Stat(ctx, path, options.StatOptions{})
+			assert.Nil(t, err, name)
+			assert.Equal(t, interfaces.FILE, meta.Mode())
+			assert.Equal(t, uint64(len(content)), *meta.ContentLength())
+			assert.Equal(t, int64(1136214245), meta.LastModified().Unix())
+
+			for _, c := range []struct {
+				offset, size uint64
+				expected     string
+			}{
+				{0, 100, "Hello,World!"},
+				{6, 5, "World"},
+				{11, 5, "!"},
+				{12, 5, ""},
+			} {
+				reader, err := d.Read(ctx, path, options.ReadOptions{Offset: &c.offset, Size: &c.size})
+				assert.Nil(t, err, name)
+				b, err := io.ReadAll(reader)
+				assert.Nil(t, err)
+				assert.Nil(t, reader.Close())
+				assert.Equal(t, c.expected, string(b), name)
+			}
+		}
+
+		offset, size := uint64(150005), uint64(10)
+		reader, err := d.Read(ctx, "large.bin", options.ReadOptions{Offset: &offset, Size: &size})
+		assert.Nil(t, err)
+		b, _ := io.ReadAll(reader)
+		assert.Equal(t, "5678901234", string(b), name)
+
+		for _, path := range []string{"/", "dir/", "dir/sub/"} {
+			meta, err := d.Stat(ctx, path, options.StatOptions{})
+			assert.Nil(t, err)
+			assert.Equal(t, interfaces.DIR, meta.Mode())
+		}
+
+		// the links and the escaped entries are skipped
+		for _, path := range []string{"not-exist", "dir", "link", "escaped"} {
+			_, err = d.Stat(ctx, path, options.StatOptions{})
+			assert.True(t, errors.Is(err, errors.ErrNotFound), path)
+		}
+		_, err = d.Read(ctx, "dir/", options.ReadOptions{})
+		assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+		_, err = d.Write(ctx, "file", options.WriteOptions{}, bytes.NewReader(content))
+		assert.Equal(t, errors.ErrUnsupportedMethod, err)
+
+		// the stored data is read in ranges without reading the whole archive
+		if name != "archive.tar.gz" {
+			for _, read := range r.reads[1:] {
+				assert.NotNil(t, read.Size, name)
+			}
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	for name, b := range map[string][]byte{
+		"archive.zip": buildZip(t),
+		"archive.tgz": buildTar(t, true),
+	} {
+		d, _ := setupDriver(t, name, b, "/dir/")
+		for path, expected := range map[string]map[string]interfaces.ObjectMode{
+			"/": {
+				"empty":      interfaces.FILE,
+				"stored.txt": interfaces.FILE,
+				"sub/":       interfaces.DIR,
+			},
+			"sub/": {
+				"sub/deflated.txt": interfaces.FILE,
+			},
+		} {
+			stream, err := d.List(ctx, path, options.ListOptions{})
+			assert.Nil(t, err)
+			entries := map[string]interfaces.ObjectMode{}
+			for stream.HasNext() {
+				entry, err := stream.Next(ctx)
+				assert.Nil(t, err)
+				entries[entry.Path()] = entry.Metadata().Mode()
+			}
+			assert.Equal(t, expected, entries, name)
+		}
+
+		_, err := NewDirStream(d, d.root, "not-exist/").NextPage(ctx)
+		assert.True(t, errors.Is(err, errors.ErrNotFound))
+	}
+}
+
+func TestNewDriver(t *testing.T) {
+	ctx := context.Background()
+	_, err := NewDriver(ctx, Options{Accessor: fs.NewDriver(fs.Options{}), Path: "archive.rar"})
+	assert.NotNil(t, err)
+
+	// the missing archive is not found
+	d, err := NewDriver(ctx, Options{Accessor: fs.NewDriver(fs.Options{Root: "not-exist"}), Path: "archive.zip"})
+	assert.Nil(t, err)
+	_, err = d.Stat(ctx, "file", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+}
+
+func TestReadEmptyRange(t *testing.T) {
+	ctx := context.Background()
+	for name, b := range map[string][]byte{
+		"archive.zip": buildZip(t),
+		"archive.tar": buildTar(t, false),
+	} {
+		d, r := setupDriver(t, name, b, "/")
+		for _, c := range []struct {
+			path   string
+			offset uint64
+			size   *uint64
+		}{
+			{"dir/empty", 0, nil},
+			{"dir/stored.txt", 12, nil},
+			{"dir/stored.txt", 20, nil},
+			{"dir/stored.txt", 0, new(uint64)},
+		} {
+			reader, err := d.Read(ctx, c.path, options.ReadOptions{Offset: &c.offset, Size: c.size})
+			assert.Nil(t, err, name)
+			b, err := io.ReadAll(reader)
+			assert.Nil(t, err)
+			assert.Empty(t, b, name)
+		}
+		// the archive is only read to load the index, no empty range is requested
+		for _, read := range r.reads {
+			assert.True(t, read.Size == nil || *read.Size > 0, name)
+		}
+	}
+}
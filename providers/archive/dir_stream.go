@@ -0,0 +1,60 @@
+package archive
+
+import (
+	"context"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/utils"
+)
+
+// DirStream lists the children of the dir from the index, they are returned in a single page.
+type DirStream struct {
+	*Driver
+	root string
+	path string
+
+	done bool
+}
+
+func (d *DirStream) NextPage(ctx context.Context) ([]interfaces.Entry, error) {
+	if d.done {
+		return nil, nil
+	}
+	n, err := d.lookup(ctx, d.path, errors.ErrListFailed)
+	if err != nil {
+		return nil, err
+	}
+	d.done = true
+	path := d.path
+	if path == "/" {
+		path = ""
+	}
+	prefix, err := utils.BuildAbsPath(d.root, path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrListFailed, err)
+	}
+
+	entries := make([]interfaces.Entry, 0, len(n.children))
+	for _, name := range n.children {
+		meta, err := metadata(d.idx[prefix+name])
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrListFailed, err)
+		}
+		p, err := utils.BuildRealPath(d.root, "/"+prefix+name)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrListFailed, err)
+		}
+		entries = append(entries, object.NewEntry(d.Driver, p, meta, meta.Mode() == interfaces.FILE))
+	}
+	return entries, nil
+}
+
+func NewDirStream(d *Driver, root, path string) interfaces.ObjectPageStream {
+	return &DirStream{
+		Driver: d,
+		root:   root,
+		path:   path,
+		done:   false,
+	}
+}
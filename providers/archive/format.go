@@ -0,0 +1,33 @@
+package archive
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Format int
+
+const (
+	FormatAuto Format = iota
+	// FormatZip the objects are read in ranges, the central directory is read by range reads.
+	FormatZip
+	// FormatTar the objects are read in ranges, the headers are read by scanning the archive once.
+	FormatTar
+	// FormatTarGz the objects are read by scanning the archive, since gzip doesn't support random access.
+	FormatTarGz
+)
+
+// detectFormat returns the format by the extension of the path.
+func detectFormat(path string) (Format, error) {
+	p := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(p, ".zip"):
+		return FormatZip, nil
+	case strings.HasSuffix(p, ".tar"):
+		return FormatTar, nil
+	case strings.HasSuffix(p, ".tar.gz"), strings.HasSuffix(p, ".tgz"):
+		return FormatTarGz, nil
+	default:
+		return FormatAuto, fmt.Errorf("failed to detect the format of %s", path)
+	}
+}
@@ -0,0 +1,229 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/utils"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// node a file or a dir in the archive, the dirs missing in the archive are implied by their children.
+type node struct {
+	dir      bool
+	size     uint64
+	modified time.Time
+	// children the names of the children, the names of dirs end with `/`
+	children []string
+
+	// offset the offset of the data in the archive, it's -1 if the data is not stored as is,
+	// compressed the size of the data in the archive, and method the compression method of zip.
+	offset     int64
+	compressed uint64
+	method     uint16
+	// ordinal the ordinal of the header in tar, which is used to find the entry by scanning
+	ordinal int
+}
+
+// index the nodes of the archive by the keys like `dir/a` and `dir/`, the key of the top dir is empty.
+type index map[string]*node
+
+func newIndex() index {
+	return index{"": {dir: true}}
+}
+
+// cleanName returns the key of the name in the archive, ok is false if it's out of the archive.
+func cleanName(name string, dir bool) (key string, ok bool) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if utils.IsEscaped(name) {
+		return "", false
+	}
+	dir = dir || strings.HasSuffix(name, "/")
+	name = path.Clean("/" + name)[1:]
+	if name == "" {
+		return "", false
+	}
+	if dir {
+		name += "/"
+	}
+	return name, true
+}
+
+// parent returns the key of the parent and the name of the key in the parent.
+func parent(key string) (string, string) {
+	i := strings.LastIndex(strings.TrimSuffix(key, "/"), "/")
+	return key[:i+1], key[i+1:]
+}
+
+// add adds the node, its missing parents are added as well. The later node replaces the former one like extracting.
+func (idx index) add(key string, n *node) {
+	if old, ok := idx[key]; ok {
+		if n.dir {
+			n.children = old.children
+		}
+		idx[key] = n
+		return
+	}
+	idx[key] = n
+	for key != "" {
+		p, name := parent(key)
+		parentNode, ok := idx[p]
+		if !ok {
+			parentNode = &node{dir: true, offset: -1}
+			idx[p] = parentNode
+		}
+		parentNode.children = append(parentNode.children, name)
+		if ok {
+			return
+		}
+		key = p
+	}
+}
+
+func (idx index) sort() {
+	for _, n := range idx {
+		sort.Strings(n.children)
+	}
+}
+
+// loadZip reads the central directory of the zip by range reads.
+func loadZip(ctx context.Context, acc interfaces.Accessor, p string, size uint64) (index, error) {
+	r, err := zip.NewReader(&readerAt{ctx: ctx, acc: acc, path: p, size: int64(size)}, int64(size))
+	if err != nil {
+		return nil, err
+	}
+	idx := newIndex()
+	for _, f := range r.File {
+		mode := f.Mode()
+		if !mode.IsDir() && !mode.IsRegular() {
+			continue
+		}
+		key, ok := cleanName(f.Name, mode.IsDir())
+		if !ok {
+			continue
+		}
+		n := &node{dir: mode.IsDir(), modified: f.Modified, offset: -1}
+		if !n.dir {
+			if n.offset, err = f.DataOffset(); err != nil {
+				return nil, err
+			}
+			n.size, n.compressed, n.method = f.UncompressedSize64, f.CompressedSize64, f.Method
+			// the encrypted data can't be read
+			if f.Flags&0x1 != 0 {
+				n.offset = -1
+			}
+		}
+		idx.add(key, n)
+	}
+	idx.sort()
+	return idx, nil
+}
+
+// counter counts the read bytes, which is the offset of the data after the header is read by the tar reader.
+type counter struct {
+	io.Reader
+	n int64
+}
+
+func (c *counter) Read(p []byte) (n int, err error) {
+	n, err = c.Reader.Read(p)
+	c.n += int64(n)
+	return
+}
+
+// openTar reads the archive from the beginning.
+func openTar(ctx context.Context, acc interfaces.Accessor, p string, gzipped bool) (io.ReadCloser, io.Reader, error) {
+	reader, err := acc.Read(ctx, p, options.ReadOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	if !gzipped {
+		return reader, reader, nil
+	}
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		_ = reader.Close()
+		return nil, nil, err
+	}
+	return reader, gz, nil
+}
+
+// isSparse returns true if the data of the file is not stored as is.
+func isSparse(header *tar.Header) bool {
+	if header.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+	for k := range header.PAXRecords {
+		if strings.HasPrefix(k, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+// loadTar scans the headers of the tar, the data of the files are skipped.
+func loadTar(ctx context.Context, acc interfaces.Accessor, p string, gzipped bool) (index, error) {
+	closer, reader, err := openTar(ctx, acc, p, gzipped)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	c := &counter{Reader: reader}
+	r := tar.NewReader(c)
+	idx := newIndex()
+	for ordinal := 0; ; ordinal++ {
+		header, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		dir := header.Typeflag == tar.TypeDir
+		if !dir && header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeGNUSparse {
+			continue
+		}
+		key, ok := cleanName(header.Name, dir)
+		if !ok {
+			continue
+		}
+		n := &node{dir: dir, modified: header.ModTime, offset: -1, ordinal: ordinal}
+		if !dir {
+			n.size = uint64(header.Size)
+			if !gzipped && !isSparse(header) {
+				n.offset = c.n
+			}
+		}
+		idx.add(key, n)
+	}
+	idx.sort()
+	return idx, nil
+}
+
+// load reads the index of the archive.
+func load(ctx context.Context, acc interfaces.Accessor, p string, format Format) (index, error) {
+	switch format {
+	case FormatZip:
+		meta, err := acc.Stat(ctx, p, options.StatOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if meta.ContentLength() == nil {
+			return nil, fmt.Errorf("the size of %s is unknown", p)
+		}
+		return loadZip(ctx, acc, p, *meta.ContentLength())
+	case FormatTar, FormatTarGz:
+		return loadTar(ctx, acc, p, format == FormatTarGz)
+	default:
+		return nil, fmt.Errorf("unknown format %d", format)
+	}
+}
@@ -0,0 +1,15 @@
+package archive
+
+import "github.com/senrok/yadal/interfaces"
+
+type Options struct {
+	// Accessor the storage the archive is stored in.
+	Accessor interfaces.Accessor
+	// Path the path of the archive in the storage, e.g. `backups/2023.tar.gz`.
+	Path string
+	// Root the root in the archive.
+	Root string
+
+	// Format the format of the archive, it's detected by the extension of the path if it's not set.
+	Format Format
+}
@@ -0,0 +1,59 @@
+package archive
+
+import (
+	"context"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/options"
+	"io"
+)
+
+const blockSize = 64 << 10
+
+// readerAt reads the archive by range reads, the last read block is cached,
+// since the zip reader reads the central directory in small pieces.
+type readerAt struct {
+	ctx  context.Context
+	acc  interfaces.Accessor
+	path string
+	size int64
+
+	offset int64
+	block  []byte
+}
+
+func (r *readerAt) ReadAt(p []byte, off int64) (n int, err error) {
+	for len(p) > 0 {
+		if off >= r.size {
+			return n, io.EOF
+		}
+		if off < r.offset || off >= r.offset+int64(len(r.block)) {
+			if err = r.fetch(off - off%blockSize); err != nil {
+				return n, err
+			}
+		}
+		copied := copy(p, r.block[off-r.offset:])
+		p = p[copied:]
+		off += int64(copied)
+		n += copied
+	}
+	return n, nil
+}
+
+func (r *readerAt) fetch(offset int64) error {
+	size := uint64(blockSize)
+	if remaining := uint64(r.size - offset); remaining < size {
+		size = remaining
+	}
+	o := uint64(offset)
+	reader, err := r.acc.Read(r.ctx, r.path, options.ReadOptions{Offset: &o, Size: &size})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	block := make([]byte, size)
+	if _, err = io.ReadFull(reader, block); err != nil {
+		return err
+	}
+	r.offset, r.block = offset, block
+	return nil
+}
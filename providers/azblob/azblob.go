@@ -0,0 +1,377 @@
+package azblob
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/senrok/yadal/constants"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/logger"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/providers"
+	"github.com/senrok/yadal/utils"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultPreSignExpire = time.Hour
+
+type Driver struct {
+	account   string
+	container string
+	endpoint  string
+	root      string
+	client    *http.Client
+	signer    Signer
+	logger.Logger
+}
+
+func (d *Driver) Metadata() interfaces.Metadata {
+	capability := interfaces.Read | interfaces.Write | interfaces.List | interfaces.Multipart
+	// the service SAS is signed by the account key.
+	if _, ok := d.signer.(*sharedKey); ok {
+		capability |= interfaces.PreSign
+	}
+	return providers.NewMetadata(interfaces.Azblob, d.root, d.container, capability)
+}
+
+func (d *Driver) Create(ctx context.Context, path string, _ options.CreateOptions) error {
+	resp, err := d.PutBlob(ctx, path, 0, nil)
+	if err != nil {
+		return errors.Wrap(errors.ErrCreateFailed, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusOK:
+		return nil
+	default:
+		return errors.ParseAzblobError(errors.ErrCreateFailed, path, resp)
+	}
+}
+
+func (d *Driver) Read(ctx context.Context, path string, args options.ReadOptions) (io.ReadCloser, error) {
+	resp, err := d.GetBlob(ctx, path, args.Offset, args.Size, args.VersionId)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrReadFailed, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent, http.StatusOK:
+		return resp.Body, nil
+	default:
+		return nil, errors.ParseAzblobError(errors.ErrReadFailed, path, resp)
+	}
+}
+
+func (d *Driver) Write(ctx context.Context, path string, args options.WriteOptions, reader io.Reader) (interfaces.WriteResult, error) {
+	resp, err := d.PutBlob(ctx, path, args.Size, reader)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrWriteFailed, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusOK:
+		result := object.WriteResult{Size: args.Size}
+		if etag := resp.Header.Get(constants.ETag); etag != "" {
+			result.ETag = &etag
+		}
+		if versionId := resp.Header.Get("x-ms-version-id"); versionId != "" {
+			result.VersionId = &versionId
+		}
+		return result, nil
+	default:
+		return nil, errors.ParseAzblobError(errors.ErrWriteFailed, path, resp)
+	}
+}
+
+// contentMD5 returns the hex encoded md5 of the base64 encoded `Content-MD5`, the etag of azure is not the md5.
+func contentMD5(encoded string) (string, bool) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) == 0 {
+		return "", false
+	}
+	return hex.EncodeToString(raw), true
+}
+
+func (d *Driver) Stat(ctx context.Context, path string, args options.StatOptions) (interfaces.ObjectMetadata, error) {
+	if path == "/" {
+		return object.Metadata{ObjectMode: interfaces.DIR}, nil
+	}
+
+	resp, err := d.HeadBlob(ctx, path, args.VersionId)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrStatFailed, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		opts := []object.MetadataOptions{
+			object.SetMode(interfaces.ObjectModeFromPath(path)),
+			object.SetMetadataFromHeader(resp.Header),
+			object.SetStorageClass(resp.Header.Get("x-ms-access-tier")),
+		}
+		if md5, ok := contentMD5(resp.Header.Get(constants.ContentMD5)); ok {
+			opts = append(opts, object.SetContentMD5(md5))
+		}
+		md, err := object.NewMetadata(opts...)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrStatFailed, err)
+		}
+		return md, nil
+
+	case http.StatusNotFound:
+		if strings.HasSuffix(path, "/") {
+			return object.Metadata{ObjectMode: interfaces.DIR}, nil
+		}
+		fallthrough // handles other cases
+	default:
+		return nil, errors.ParseAzblobError(errors.ErrStatFailed, path, resp)
+	}
+}
+
+func (d *Driver) Delete(ctx context.Context, path string, args options.DeleteOptions) error {
+	resp, err := d.DeleteBlob(ctx, path, args.VersionId)
+	if err != nil {
+		return errors.Wrap(errors.ErrDeleteFailed, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusAccepted, http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return errors.ParseAzblobError(errors.ErrDeleteFailed, path, resp)
+	}
+}
+
+func (d *Driver) List(ctx context.Context, path string, args options.ListOptions) (interfaces.ObjectStream, error) {
+	return object.NewObjectStream(NewDirStream(d, d.root, path)), nil
+}
+
+func (d *Driver) ListVersions(ctx context.Context, path string, args options.ListVersions) (interfaces.ObjectStream, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) PreSign(ctx context.Context, path string, args options.PreSignOptions) (req *http.Request, err error) {
+	var permissions string
+	switch args.Op {
+	case options.ReadOp:
+		read := options.ReadOptions{}
+		if args.ReadOptions != nil {
+			read = *args.ReadOptions
+		}
+		if req, err = d.getBlobRequest(ctx, path, read.Offset, read.Size, read.VersionId); err != nil {
+			return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+		}
+		permissions = "r"
+	case options.WriteOp:
+		if req, err = d.putBlobRequest(ctx, path, 0, nil); err != nil {
+			return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+		}
+		permissions = "cw"
+	case options.StatOp:
+		if req, err = d.headBlobRequest(ctx, path, ""); err != nil {
+			return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+		}
+		permissions = "r"
+	case options.DeleteOp:
+		if req, err = d.deleteBlobRequest(ctx, path, ""); err != nil {
+			return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+		}
+		permissions = "d"
+	case options.WriteMultipartOp:
+		if args.WriteMultipart == nil {
+			return nil, errors.Wrap(errors.ErrPreSignFailed, errors.ErrUploadIdRequired)
+		}
+		if req, err = d.putBlockRequest(ctx, path, blockId(args.UploadId, args.PartNumber), 0, nil); err != nil {
+			return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+		}
+		permissions = "w"
+	case options.CreateMultipartOp, options.CompleteMultipartOp:
+		return nil, errors.ErrUnsupportedMethod
+	default:
+		return nil, errors.Wrap(errors.ErrPreSignFailed, errors.ErrUnknownPreSignOperation)
+	}
+
+	for k, values := range args.Headers {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	if args.Op == options.ReadOp && args.Response != nil {
+		setResponseOverrides(req, args.Response)
+	}
+
+	blob, err := d.blobName(path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+	}
+	expire := args.Expire
+	if expire == 0 {
+		expire = defaultPreSignExpire
+	}
+	resource := fmt.Sprintf("/blob/%s/%s/%s", d.account, d.container, blob)
+	if err = d.signer.PreSign(req, resource, permissions, expire, time.Now()); err != nil {
+		if errors.Is(err, errors.ErrUnsupportedMethod) {
+			return nil, err
+		}
+		return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+	}
+	return req, nil
+}
+
+// setResponseOverrides sets the `rsc*` query of a GET request, they are signed into the SAS.
+func setResponseOverrides(req *http.Request, o *options.ResponseOverrides) {
+	query := req.URL.Query()
+	for k, v := range map[string]string{
+		"rsct": o.ContentType,
+		"rscd": o.ContentDisposition,
+		"rsce": o.ContentEncoding,
+		"rscl": o.ContentLanguage,
+		"rscc": o.CacheControl,
+	} {
+		if v != "" {
+			query.Set(k, v)
+		}
+	}
+	req.URL.RawQuery = query.Encode()
+}
+
+func (d *Driver) GetTags(ctx context.Context, path string, args options.GetTags) (map[string]string, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) SetTags(ctx context.Context, path string, args options.SetTags) error {
+	return errors.ErrUnsupportedMethod
+}
+
+// blockId returns the block id of the part, the ids of a blob MUST be in the same length before encoded.
+func blockId(uploadId string, partNumber uint) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s-%06d", uploadId, partNumber)))
+}
+
+// CreateMultipart returns a new upload id, nothing is sent since the blocks are staged on the blob itself.
+func (d *Driver) CreateMultipart(ctx context.Context, path string, args options.CreateMultipart) (string, error) {
+	return uuid.New().String(), nil
+}
+
+// WriteMultipart stages the part as a block, the etag of the part is its block id.
+func (d *Driver) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.Reader) (interfaces.ObjectPart, error) {
+	id := blockId(args.UploadId, args.PartNumber)
+	resp, err := d.PutBlock(ctx, path, id, args.Size, reader)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrWriteMultipartFailed, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusOK:
+		return object.ObjectPart{
+			PartNumber: args.PartNumber,
+			ETag:       id,
+		}, nil
+	default:
+		return nil, errors.ParseAzblobError(errors.ErrWriteMultipartFailed, path, resp)
+	}
+}
+
+// CompleteMultipart commits the blocks of the parts ordered by the part number.
+func (d *Driver) CompleteMultipart(ctx context.Context, path string, args options.CompleteMultipart) error {
+	parts := make([]options.ObjectPart, len(args.ObjectParts))
+	copy(parts, args.ObjectParts)
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].GetPartNumber() < parts[j].GetPartNumber()
+	})
+	ids := make([]string, 0, len(parts))
+	for _, part := range parts {
+		ids = append(ids, blockId(args.UploadId, part.GetPartNumber()))
+	}
+
+	resp, err := d.PutBlockList(ctx, path, ids)
+	if err != nil {
+		return errors.Wrap(errors.ErrCompleteMultipartFailed, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusOK:
+		return nil
+	default:
+		return errors.ParseAzblobError(errors.ErrCompleteMultipartFailed, path, resp)
+	}
+}
+
+// AbortMultipart does nothing, the uncommitted blocks are garbage collected by the service after a week.
+func (d *Driver) AbortMultipart(ctx context.Context, path string, args options.AbortMultipart) error {
+	return nil
+}
+
+// ListMultipart is not supported, the uploads are not known by the service until their blocks are committed.
+func (d *Driver) ListMultipart(ctx context.Context, path string, args options.ListMultipart) ([]interfaces.MultipartUpload, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+// NewDriver returns a driver of the container, the requests are signed with Shared Key if the account key is set,
+// or authorized by the SAS token, otherwise they are anonymous.
+func NewDriver(ctx context.Context, opt Options) (interfaces.Accessor, error) {
+	client := opt.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	endpoint := opt.Endpoint
+	if endpoint == "" {
+		if opt.AccountName == "" {
+			return nil, fmt.Errorf("either endpoint or account name is required")
+		}
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", opt.AccountName)
+	}
+	if !strings.HasPrefix(endpoint, "http") {
+		endpoint = "https://" + endpoint
+	}
+	d := &Driver{
+		account:   opt.AccountName,
+		container: opt.Container,
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+		root:      utils.NormalizeRoot(opt.Root),
+		client:    client,
+		signer:    anonymous{},
+	}
+	if d.account == "" {
+		d.account = accountFromEndpoint(d.endpoint)
+	}
+
+	var err error
+	switch {
+	case opt.AccountKey != "":
+		d.signer, err = NewSharedKeySigner(d.account, opt.AccountKey)
+	case opt.SASToken != "":
+		d.signer, err = NewSASSigner(opt.SASToken)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// accountFromEndpoint returns the account of `https://{account}.blob.core.windows.net`
+// or the path style `http://127.0.0.1:10000/{account}`.
+func accountFromEndpoint(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	if p := strings.Trim(u.Path, "/"); p != "" {
+		return p
+	}
+	return strings.Split(u.Hostname(), ".")[0]
+}
@@ -0,0 +1,187 @@
+package azblob
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/options"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func setupDriver(t *testing.T, f *fakeServer, root string) interfaces.Accessor {
+	d, err := NewDriver(context.Background(), Options{
+		Container:  f.container,
+		Endpoint:   f.endpoint(),
+		Root:       root,
+		AccountKey: fakeKey,
+	})
+	assert.Nil(t, err)
+	return d
+}
+
+func TestDriver(t *testing.T) {
+	f := newFakeServer(t)
+	d := setupDriver(t, f, "/root/")
+	ctx := context.Background()
+	assert.Equal(t, interfaces.Azblob, d.Metadata().Provider())
+	assert.Equal(t, fakeAccount, d.(*Driver).account)
+	assert.True(t, d.Metadata().Capability().Has(interfaces.Read, interfaces.Write, interfaces.List, interfaces.PreSign, interfaces.Multipart))
+
+	content := []byte("Hello,World!")
+	result, err := d.Write(ctx, "dir/a b.txt", options.WriteOptions{Size: uint64(len(content))}, bytes.NewReader(content))
+	assert.Nil(t, err)
+	assert.Equal(t, "\"0x1\"", *result.GetETag())
+	assert.Contains(t, f.blobs, "root/dir/a b.txt")
+
+	meta, err := d.Stat(ctx, "dir/a b.txt", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.FILE, meta.Mode())
+	assert.Equal(t, uint64(len(content)), *meta.ContentLength())
+	sum := md5.Sum(content)
+	assert.Equal(t, hex.EncodeToString(sum[:]), *meta.ContentMD5())
+	assert.Equal(t, "Hot", *meta.StorageClass())
+
+	offset, size := uint64(6), uint64(5)
+	reader, err := d.Read(ctx, "dir/a b.txt", options.ReadOptions{Offset: &offset, Size: &size})
+	assert.Nil(t, err)
+	b, _ := io.ReadAll(reader)
+	assert.Equal(t, "World", string(b))
+
+	meta, err = d.Stat(ctx, "dir/", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.DIR, meta.Mode())
+
+	_, err = d.Stat(ctx, "not-exist", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+	_, err = d.Read(ctx, "not-exist", options.ReadOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNoSuchKey))
+
+	assert.Nil(t, d.Delete(ctx, "dir/a b.txt", options.DeleteOptions{}))
+	assert.Nil(t, d.Delete(ctx, "dir/a b.txt", options.DeleteOptions{}))
+	assert.NotContains(t, f.blobs, "root/dir/a b.txt")
+
+	// a wrong key is rejected
+	wrong, err := NewDriver(ctx, Options{Container: f.container, Endpoint: f.endpoint(), AccountKey: "d3Jvbmc="})
+	assert.Nil(t, err)
+	_, err = wrong.Stat(ctx, "a", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrPermissionDenied))
+}
+
+func TestList(t *testing.T) {
+	f := newFakeServer(t)
+	f.pageSize = 2
+	d := setupDriver(t, f, "/")
+	ctx := context.Background()
+
+	assert.Nil(t, d.Create(ctx, "dir/", options.CreateOptions{Mode: int8(interfaces.DIR)}))
+	for _, path := range []string{"dir/a", "dir/b", "dir/c", "dir/sub/d"} {
+		_, err := d.Write(ctx, path, options.WriteOptions{Size: 1}, bytes.NewReader([]byte("x")))
+		assert.Nil(t, err)
+	}
+
+	// pages are fetched with the marker of the previous one
+	stream := NewDirStream(d.(*Driver), "/", "dir/")
+	entries := map[string]interfaces.ObjectMode{}
+	for {
+		page, err := stream.NextPage(ctx)
+		assert.Nil(t, err)
+		if page == nil {
+			break
+		}
+		for _, entry := range page {
+			entries[entry.Path()] = entry.Metadata().Mode()
+		}
+	}
+	assert.Equal(t, map[string]interfaces.ObjectMode{
+		"dir/a":    interfaces.FILE,
+		"dir/b":    interfaces.FILE,
+		"dir/c":    interfaces.FILE,
+		"dir/sub/": interfaces.DIR,
+	}, entries)
+}
+
+func TestMultipart(t *testing.T) {
+	f := newFakeServer(t)
+	d := setupDriver(t, f, "/")
+	ctx := context.Background()
+
+	uploadId, err := d.CreateMultipart(ctx, "multipart", options.CreateMultipart{})
+	assert.Nil(t, err)
+
+	contents := [][]byte{[]byte("part1-"), []byte("part2-"), []byte("part3")}
+	var parts []options.ObjectPart
+	// parts are committed by the part number, whatever the order they are written.
+	for _, i := range []int{2, 0, 1} {
+		part, err := d.WriteMultipart(ctx, "multipart", options.WriteMultipart{
+			UploadId:   uploadId,
+			PartNumber: uint(i + 1),
+			Size:       uint64(len(contents[i])),
+		}, bytes.NewReader(contents[i]))
+		assert.Nil(t, err)
+		parts = append(parts, part)
+	}
+	assert.NotContains(t, f.blobs, "multipart")
+	assert.Nil(t, d.CompleteMultipart(ctx, "multipart", options.CompleteMultipart{UploadId: uploadId, ObjectParts: parts}))
+	assert.Equal(t, bytes.Join(contents, nil), f.blobs["multipart"].data)
+
+	_, err = d.ListMultipart(ctx, "/", options.ListMultipart{})
+	assert.Equal(t, errors.ErrUnsupportedMethod, err)
+}
+
+func TestPreSign(t *testing.T) {
+	f := newFakeServer(t)
+	d := setupDriver(t, f, "/root/")
+	ctx := context.Background()
+
+	content := []byte("Hello,World!")
+	req, err := d.PreSign(ctx, "a b.txt", options.PreSignOptions{Op: options.WriteOp})
+	assert.Nil(t, err)
+	assert.Equal(t, http.MethodPut, req.Method)
+	assert.Equal(t, "cw", req.URL.Query().Get("sp"))
+	assert.Equal(t, "BlockBlob", req.Header.Get("x-ms-blob-type"))
+	req.Body = io.NopCloser(bytes.NewReader(content))
+	req.ContentLength = int64(len(content))
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, content, f.blobs["root/a b.txt"].data)
+
+	req, err = d.PreSign(ctx, "a b.txt", options.PreSignOptions{
+		Op:       options.ReadOp,
+		Response: &options.ResponseOverrides{ContentDisposition: "attachment; filename=\"a.txt\""},
+	})
+	assert.Nil(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	b, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, content, b)
+	assert.Equal(t, "attachment; filename=\"a.txt\"", resp.Header.Get("Content-Disposition"))
+
+	// the signed permissions can't be escalated
+	query := req.URL.Query()
+	query.Set("sp", "rwd")
+	req.URL.RawQuery = query.Encode()
+	req.Method = http.MethodDelete
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// the SAS token authorizes the requests but can't sign urls
+	req, err = d.PreSign(ctx, "a b.txt", options.PreSignOptions{Op: options.ReadOp})
+	assert.Nil(t, err)
+	sasDriver, err := NewDriver(ctx, Options{Container: f.container, Endpoint: f.endpoint(), SASToken: "?" + req.URL.RawQuery})
+	assert.Nil(t, err)
+	assert.False(t, sasDriver.Metadata().Capability().Has(interfaces.PreSign))
+	reader, err := sasDriver.Read(ctx, "root/a b.txt", options.ReadOptions{})
+	assert.Nil(t, err)
+	b, _ = io.ReadAll(reader)
+	assert.Equal(t, content, b)
+	_, err = sasDriver.PreSign(ctx, "a b.txt", options.PreSignOptions{Op: options.ReadOp})
+	assert.Equal(t, errors.ErrUnsupportedMethod, err)
+}
@@ -0,0 +1,109 @@
+package azblob
+
+import (
+	"context"
+	"encoding/xml"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/utils"
+	"net/http"
+	"strings"
+)
+
+type DirStream struct {
+	*Driver
+	root   string
+	path   string
+	marker string
+
+	done bool
+}
+
+func (d *DirStream) NextPage(ctx context.Context) ([]interfaces.Entry, error) {
+	// a page could be empty if it only contains the dir itself, an empty page ends the stream.
+	for !d.done {
+		entries, err := d.nextPage(ctx)
+		if err != nil || len(entries) > 0 {
+			return entries, err
+		}
+	}
+	return nil, nil
+}
+
+func (d *DirStream) nextPage(ctx context.Context) ([]interfaces.Entry, error) {
+	resp, err := d.ListBlobs(ctx, d.path, d.marker)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrListFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.ParseAzblobError(errors.ErrListFailed, d.path, resp)
+	}
+	output := EnumerationResults{}
+	if err = xml.NewDecoder(resp.Body).Decode(&output); err != nil {
+		return nil, errors.Wrap(errors.ErrListFailed, err)
+	}
+	d.marker = output.NextMarker
+	d.done = output.NextMarker == ""
+
+	entries := make([]interfaces.Entry, 0, len(output.Blobs.BlobPrefix)+len(output.Blobs.Blob))
+	for _, prefix := range output.Blobs.BlobPrefix {
+		path, err := utils.BuildRealPath(d.root, prefix.Name)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries,
+			object.NewEntry(
+				d.Driver,
+				path,
+				object.Metadata{
+					ObjectMode: interfaces.DIR,
+				},
+				false),
+		)
+	}
+
+	for _, blob := range output.Blobs.Blob {
+		// the dir itself is listed if it's created as a blob ends-with `/`.
+		if strings.HasSuffix(blob.Name, "/") {
+			continue
+		}
+		props := blob.Properties
+		opts := []object.MetadataOptions{
+			object.SetMode(interfaces.FILE),
+			object.SetMetadata(props.ContentLength, props.lastModified(), props.ETag),
+			object.SetStorageClass(props.AccessTier),
+		}
+		if md5, ok := contentMD5(props.ContentMD5); ok {
+			opts = append(opts, object.SetContentMD5(md5))
+		}
+		meta, err := object.NewMetadata(opts...)
+		if err != nil {
+			return nil, err
+		}
+		path, err := utils.BuildRealPath(d.root, blob.Name)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries,
+			object.NewEntry(
+				d.Driver,
+				path,
+				meta,
+				true),
+		)
+	}
+	return entries, nil
+}
+
+func NewDirStream(d *Driver, root, path string) interfaces.ObjectPageStream {
+	return &DirStream{
+		Driver: d,
+		root:   root,
+		path:   path,
+		marker: "",
+		done:   false,
+	}
+}
@@ -0,0 +1,187 @@
+package azblob
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/utils"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+func (d *Driver) blobName(path string) (string, error) {
+	return utils.BuildAbsPath(d.root, path)
+}
+
+func (d *Driver) buildUrl(path string, query url.Values) (string, error) {
+	p, err := d.blobName(path)
+	if err != nil {
+		return "", err
+	}
+	u := fmt.Sprintf("%s/%s/%s", d.endpoint, d.container, utils.EncodePath(p))
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u, nil
+}
+
+// withVersionId selects the version of the blob, the current one is selected if versionId is empty.
+func withVersionId(query url.Values, versionId string) url.Values {
+	if versionId != "" {
+		query.Set("versionid", versionId)
+	}
+	return query
+}
+
+func (d *Driver) getBlobRequest(ctx context.Context, path string, offset, size *uint64, versionId string) (*http.Request, error) {
+	u, err := d.buildUrl(path, withVersionId(url.Values{}, versionId))
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset != nil || size != nil {
+		req.Header.Set("x-ms-range", options.NewBytesRange(offset, size).String())
+	}
+	return req, nil
+}
+
+func (d *Driver) GetBlob(ctx context.Context, path string, offset, size *uint64, versionId string) (*http.Response, error) {
+	req, err := d.getBlobRequest(ctx, path, offset, size, versionId)
+	if err != nil {
+		return nil, err
+	}
+	return d.do(req)
+}
+
+// putBlobRequest creates a block blob, the body is nil if the request is presigned.
+func (d *Driver) putBlobRequest(ctx context.Context, path string, size uint64, body io.Reader) (*http.Request, error) {
+	u, err := d.buildUrl(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(size)
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	return req, nil
+}
+
+func (d *Driver) PutBlob(ctx context.Context, path string, size uint64, body io.Reader) (*http.Response, error) {
+	if size == 0 {
+		body = http.NoBody
+	}
+	req, err := d.putBlobRequest(ctx, path, size, body)
+	if err != nil {
+		return nil, err
+	}
+	return d.do(req)
+}
+
+func (d *Driver) headBlobRequest(ctx context.Context, path string, versionId string) (*http.Request, error) {
+	u, err := d.buildUrl(path, withVersionId(url.Values{}, versionId))
+	if err != nil {
+		return nil, err
+	}
+	return http.NewRequestWithContext(ctx, http.MethodHead, u, nil)
+}
+
+func (d *Driver) HeadBlob(ctx context.Context, path string, versionId string) (*http.Response, error) {
+	req, err := d.headBlobRequest(ctx, path, versionId)
+	if err != nil {
+		return nil, err
+	}
+	return d.do(req)
+}
+
+func (d *Driver) deleteBlobRequest(ctx context.Context, path string, versionId string) (*http.Request, error) {
+	u, err := d.buildUrl(path, withVersionId(url.Values{}, versionId))
+	if err != nil {
+		return nil, err
+	}
+	return http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
+}
+
+func (d *Driver) DeleteBlob(ctx context.Context, path string, versionId string) (*http.Response, error) {
+	req, err := d.deleteBlobRequest(ctx, path, versionId)
+	if err != nil {
+		return nil, err
+	}
+	return d.do(req)
+}
+
+// ListBlobs lists the blobs and prefixes of the dir, see https://learn.microsoft.com/en-us/rest/api/storageservices/list-blobs
+func (d *Driver) ListBlobs(ctx context.Context, path string, marker string) (*http.Response, error) {
+	p, err := d.blobName(path)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	query.Set("restype", "container")
+	query.Set("comp", "list")
+	query.Set("prefix", p)
+	query.Set("delimiter", "/")
+	if marker != "" {
+		query.Set("marker", marker)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s?%s", d.endpoint, d.container, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.do(req)
+}
+
+func (d *Driver) putBlockRequest(ctx context.Context, path, blockId string, size uint64, body io.Reader) (*http.Request, error) {
+	u, err := d.buildUrl(path, url.Values{"comp": {"block"}, "blockid": {blockId}})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(size)
+	return req, nil
+}
+
+// PutBlock stages the block of the blob, see https://learn.microsoft.com/en-us/rest/api/storageservices/put-block
+func (d *Driver) PutBlock(ctx context.Context, path, blockId string, size uint64, body io.Reader) (*http.Response, error) {
+	req, err := d.putBlockRequest(ctx, path, blockId, size, body)
+	if err != nil {
+		return nil, err
+	}
+	return d.do(req)
+}
+
+// PutBlockList commits the staged blocks in order as the blob, see https://learn.microsoft.com/en-us/rest/api/storageservices/put-block-list
+func (d *Driver) PutBlockList(ctx context.Context, path string, blockIds []string) (*http.Response, error) {
+	u, err := d.buildUrl(path, url.Values{"comp": {"blocklist"}})
+	if err != nil {
+		return nil, err
+	}
+	b, err := xml.Marshal(BlockList{Latest: blockIds})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+	return d.do(req)
+}
+
+// do authorizes and sends the request.
+func (d *Driver) do(req *http.Request) (*http.Response, error) {
+	if err := d.signer.Sign(req); err != nil {
+		return nil, err
+	}
+	return d.client.Do(req)
+}
@@ -0,0 +1,249 @@
+package azblob
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// the well-known account of Azurite
+const (
+	fakeAccount = "devstoreaccount1"
+	fakeKey     = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+)
+
+// fakeServer a minimal in-memory Azurite serving block blobs of a container with the path style urls,
+// the requests are authorized by Shared Key or service SAS.
+type fakeServer struct {
+	*httptest.Server
+	container string
+	pageSize  int
+	signer    *sharedKey
+
+	mu     sync.Mutex
+	blobs  map[string]fakeBlob
+	blocks map[string]map[string][]byte
+	etag   int
+}
+
+type fakeBlob struct {
+	data    []byte
+	etag    string
+	updated time.Time
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	signer, err := NewSharedKeySigner(fakeAccount, fakeKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeServer{
+		container: "container",
+		pageSize:  5000,
+		signer:    signer.(*sharedKey),
+		blobs:     map[string]fakeBlob{},
+		blocks:    map[string]map[string][]byte{},
+	}
+	f.Server = httptest.NewServer(f)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeServer) endpoint() string {
+	return f.URL + "/" + fakeAccount
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("x-ms-error-code", code)
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?><Error><Code>%s</Code><Message>%s</Message></Error>`, code, code)
+}
+
+// authorized verifies the Shared Key or the service SAS of the request.
+func (f *fakeServer) authorized(r *http.Request, blob string) bool {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		return auth == "SharedKey "+fakeAccount+":"+f.signer.hmac(f.signer.StringToSign(r))
+	}
+	query := r.URL.Query()
+	if query.Get("sig") == "" {
+		return false
+	}
+	expiry, err := time.Parse("2006-01-02T15:04:05Z", query.Get("se"))
+	if err != nil || time.Now().After(expiry) {
+		return false
+	}
+	permissions := query.Get("sp")
+	required := map[string]string{http.MethodGet: "r", http.MethodHead: "r", http.MethodPut: "w", http.MethodDelete: "d"}[r.Method]
+	if !strings.Contains(permissions, required) {
+		return false
+	}
+	stringToSign := strings.Join([]string{
+		permissions, "", query.Get("se"),
+		fmt.Sprintf("/blob/%s/%s/%s", fakeAccount, f.container, blob),
+		"", "", "", query.Get("sv"), query.Get("sr"), "",
+		query.Get("rscc"), query.Get("rscd"), query.Get("rsce"), query.Get("rscl"), query.Get("rsct"),
+	}, "\n")
+	return query.Get("sig") == f.signer.hmac(stringToSign)
+}
+
+// put stores the blob, the caller MUST hold the lock.
+func (f *fakeServer) put(w http.ResponseWriter, name string, data []byte) {
+	f.etag++
+	blob := fakeBlob{data: data, etag: fmt.Sprintf("\"0x%d\"", f.etag), updated: time.Now().UTC().Truncate(time.Second)}
+	f.blobs[name] = blob
+	delete(f.blocks, name)
+	sum := md5.Sum(data)
+	w.Header().Set("ETag", blob.etag)
+	w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	prefix := "/" + fakeAccount + "/" + f.container
+	path := r.URL.EscapedPath()
+	if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+		writeError(w, http.StatusNotFound, "ContainerNotFound")
+		return
+	}
+	name, _ := url.PathUnescape(strings.TrimPrefix(strings.TrimPrefix(path, prefix), "/"))
+	if !f.authorized(r, name) {
+		writeError(w, http.StatusForbidden, "AuthenticationFailed")
+		return
+	}
+	query := r.URL.Query()
+	if name == "" {
+		f.serveList(w, r)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPut && query.Get("comp") == "block":
+		data, _ := io.ReadAll(r.Body)
+		if f.blocks[name] == nil {
+			f.blocks[name] = map[string][]byte{}
+		}
+		f.blocks[name][query.Get("blockid")] = data
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && query.Get("comp") == "blocklist":
+		list := BlockList{}
+		if err := xml.NewDecoder(r.Body).Decode(&list); err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidXmlDocument")
+			return
+		}
+		var data []byte
+		for _, id := range list.Latest {
+			block, ok := f.blocks[name][id]
+			if !ok {
+				writeError(w, http.StatusBadRequest, "InvalidBlockList")
+				return
+			}
+			data = append(data, block...)
+		}
+		f.put(w, name, data)
+	case r.Method == http.MethodPut:
+		if r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+			writeError(w, http.StatusBadRequest, "MissingRequiredHeader")
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		f.put(w, name, data)
+	default:
+		blob, ok := f.blobs[name]
+		if !ok {
+			writeError(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		switch r.Method {
+		case http.MethodDelete:
+			delete(f.blobs, name)
+			w.WriteHeader(http.StatusAccepted)
+		case http.MethodHead:
+			sum := md5.Sum(blob.data)
+			w.Header().Set("Content-Length", strconv.Itoa(len(blob.data)))
+			w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+			w.Header().Set("ETag", blob.etag)
+			w.Header().Set("Last-Modified", blob.updated.Format(http.TimeFormat))
+			w.Header().Set("x-ms-access-tier", "Hot")
+		case http.MethodGet:
+			if v := query.Get("rscd"); v != "" {
+				w.Header().Set("Content-Disposition", v)
+			}
+			var start, end int
+			if _, err := fmt.Sscanf(r.Header.Get("x-ms-range"), "bytes=%d-%d", &start, &end); err == nil {
+				w.WriteHeader(http.StatusPartialContent)
+				_, _ = w.Write(blob.data[start : end+1])
+				return
+			}
+			_, _ = w.Write(blob.data)
+		}
+	}
+}
+
+func (f *fakeServer) serveList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("restype") != "container" || query.Get("comp") != "list" {
+		writeError(w, http.StatusBadRequest, "InvalidQueryParameterValue")
+		return
+	}
+	prefix := query.Get("prefix")
+	var names []string
+	prefixes := map[string]bool{}
+	for name := range f.blobs {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if idx := strings.Index(name[len(prefix):], "/"); idx >= 0 {
+			p := name[:len(prefix)+idx+1]
+			if !prefixes[p] {
+				prefixes[p] = true
+				names = append(names, p)
+			}
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	start := 0
+	if marker := query.Get("marker"); marker != "" {
+		start = sort.SearchStrings(names, marker)
+	}
+	end := start + f.pageSize
+	output := EnumerationResults{Prefix: prefix}
+	if end < len(names) {
+		output.NextMarker = names[end]
+	} else {
+		end = len(names)
+	}
+	for _, name := range names[start:end] {
+		if prefixes[name] {
+			output.Blobs.BlobPrefix = append(output.Blobs.BlobPrefix, BlobPrefix{Name: name})
+			continue
+		}
+		blob := f.blobs[name]
+		sum := md5.Sum(blob.data)
+		output.Blobs.Blob = append(output.Blobs.Blob, Blob{Name: name, Properties: BlobProperties{
+			LastModified:  blob.updated.Format(http.TimeFormat),
+			ETag:          blob.etag,
+			ContentLength: uint64(len(blob.data)),
+			ContentMD5:    base64.StdEncoding.EncodeToString(sum[:]),
+			AccessTier:    "Hot",
+		}})
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(output)
+}
@@ -0,0 +1,21 @@
+package azblob
+
+import "net/http"
+
+type Options struct {
+	Container string
+	// Endpoint the blob endpoint of the account, e.g. `https://account.blob.core.windows.net`
+	// or `http://127.0.0.1:10000/devstoreaccount1` of Azurite.
+	Endpoint string
+	Root     string
+
+	AccountName string
+	// AccountKey the base64 encoded key, the requests are signed with Shared Key if it's set.
+	AccountKey string
+	// SASToken the shared access signature appended to the requests if AccountKey is not set,
+	// with or without the leading `?`.
+	SASToken string
+
+	// Client the http client, defaults to http.DefaultClient.
+	Client *http.Client
+}
@@ -0,0 +1,182 @@
+package azblob
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/senrok/yadal/errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// storageVersion the version of the REST API and the signed version of SAS
+const storageVersion = "2020-10-02"
+
+type Signer interface {
+	// Sign authorizes the request.
+	Sign(r *http.Request) error
+	// PreSign signs the request of the blob resource into its query with a service SAS of the permissions,
+	// the request is valid within expire. The resource is `/blob/{account}/{container}/{blob}`.
+	PreSign(r *http.Request, resource, permissions string, expire time.Duration, now time.Time) error
+}
+
+// sharedKey signs the requests with the key of the account,
+// see https://learn.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+type sharedKey struct {
+	account string
+	key     []byte
+}
+
+// NewSharedKeySigner returns the signer of the base64 encoded account key.
+func NewSharedKeySigner(account, key string) (Signer, error) {
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, err
+	}
+	return &sharedKey{account: account, key: decoded}, nil
+}
+
+func (s *sharedKey) hmac(stringToSign string) string {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (s *sharedKey) Sign(r *http.Request) error {
+	r.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	r.Header.Set("x-ms-version", storageVersion)
+	r.Header.Set("Authorization", "SharedKey "+s.account+":"+s.hmac(s.StringToSign(r)))
+	return nil
+}
+
+// StringToSign returns the string to sign of Shared Key.
+func (s *sharedKey) StringToSign(r *http.Request) string {
+	contentLength := ""
+	if r.ContentLength > 0 {
+		contentLength = strconv.FormatInt(r.ContentLength, 10)
+	}
+	return strings.Join([]string{
+		r.Method,
+		r.Header.Get("Content-Encoding"),
+		r.Header.Get("Content-Language"),
+		contentLength,
+		r.Header.Get("Content-MD5"),
+		r.Header.Get("Content-Type"),
+		"", // Date, x-ms-date is used instead
+		r.Header.Get("If-Modified-Since"),
+		r.Header.Get("If-Match"),
+		r.Header.Get("If-None-Match"),
+		r.Header.Get("If-Unmodified-Since"),
+		r.Header.Get("Range"),
+		canonicalizedHeaders(r) + s.canonicalizedResource(r),
+	}, "\n")
+}
+
+// canonicalizedHeaders returns the sorted `x-ms-` headers, each line ends with `\n`.
+func canonicalizedHeaders(r *http.Request) string {
+	var names []string
+	for name := range r.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			names = append(names, lower)
+		}
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + strings.TrimSpace(r.Header.Get(name)) + "\n")
+	}
+	return b.String()
+}
+
+// canonicalizedResource returns the account, the encoded path and the sorted query of the request.
+func (s *sharedKey) canonicalizedResource(r *http.Request) string {
+	var b strings.Builder
+	b.WriteString("/" + s.account + r.URL.EscapedPath())
+	query := r.URL.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		b.WriteString("\n" + strings.ToLower(name) + ":" + strings.Join(values, ","))
+	}
+	return b.String()
+}
+
+// PreSign signs the request with a service SAS,
+// see https://learn.microsoft.com/en-us/rest/api/storageservices/create-service-sas
+func (s *sharedKey) PreSign(r *http.Request, resource, permissions string, expire time.Duration, now time.Time) error {
+	query := r.URL.Query()
+	expiry := now.UTC().Add(expire).Format("2006-01-02T15:04:05Z")
+	stringToSign := strings.Join([]string{
+		permissions,
+		"", // signed start
+		expiry,
+		resource,
+		"", // signed identifier
+		"", // signed ip
+		"", // signed protocol
+		storageVersion,
+		"b", // signed resource
+		"",  // snapshot time
+		query.Get("rscc"),
+		query.Get("rscd"),
+		query.Get("rsce"),
+		query.Get("rscl"),
+		query.Get("rsct"),
+	}, "\n")
+	query.Set("sv", storageVersion)
+	query.Set("sr", "b")
+	query.Set("sp", permissions)
+	query.Set("se", expiry)
+	query.Set("sig", s.hmac(stringToSign))
+	r.URL.RawQuery = query.Encode()
+	return nil
+}
+
+// sasToken authorizes the requests with the shared access signature.
+type sasToken struct {
+	query url.Values
+}
+
+// NewSASSigner returns the signer appending the SAS token to the requests, the token can't sign urls.
+func NewSASSigner(token string) (Signer, error) {
+	query, err := url.ParseQuery(strings.TrimPrefix(token, "?"))
+	if err != nil {
+		return nil, err
+	}
+	return &sasToken{query: query}, nil
+}
+
+func (s *sasToken) Sign(r *http.Request) error {
+	query := r.URL.Query()
+	for k, values := range s.query {
+		query[k] = values
+	}
+	r.URL.RawQuery = query.Encode()
+	r.Header.Set("x-ms-version", storageVersion)
+	return nil
+}
+
+func (s *sasToken) PreSign(*http.Request, string, string, time.Duration, time.Time) error {
+	return errors.ErrUnsupportedMethod
+}
+
+// anonymous sends the requests as is, e.g. the containers of public access.
+type anonymous struct{}
+
+func (anonymous) Sign(r *http.Request) error {
+	r.Header.Set("x-ms-version", storageVersion)
+	return nil
+}
+
+func (anonymous) PreSign(*http.Request, string, string, time.Duration, time.Time) error {
+	return errors.ErrUnsupportedMethod
+}
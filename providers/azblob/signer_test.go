@@ -0,0 +1,27 @@
+package azblob
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStringToSign(t *testing.T) {
+	signer, err := NewSharedKeySigner(fakeAccount, fakeKey)
+	assert.Nil(t, err)
+	req, err := http.NewRequest(http.MethodPut, "http://127.0.0.1:10000/devstoreaccount1/container/a%20b.txt?comp=block&blockid=YQ%3D%3D", strings.NewReader("hello"))
+	assert.Nil(t, err)
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("X-Ms-Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+	req.Header.Set("x-ms-version", storageVersion)
+
+	assert.Equal(t, "PUT\n\n\n5\n\ntext/plain\n\n\n\n\n\n\n"+
+		"x-ms-date:Mon, 02 Jan 2006 15:04:05 GMT\nx-ms-version:2020-10-02\n"+
+		"/devstoreaccount1/devstoreaccount1/container/a%20b.txt\nblockid:YQ==\ncomp:block", signer.(*sharedKey).StringToSign(req))
+
+	// the empty content length is signed as empty
+	req, err = http.NewRequest(http.MethodGet, "http://127.0.0.1:10000/devstoreaccount1/container?restype=container&comp=list", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "GET\n\n\n\n\n\n\n\n\n\n\n\n/devstoreaccount1/devstoreaccount1/container\ncomp:list\nrestype:container", signer.(*sharedKey).StringToSign(req))
+}
@@ -0,0 +1,49 @@
+package azblob
+
+import (
+	"encoding/xml"
+	"github.com/senrok/yadal/utils"
+	"time"
+)
+
+// EnumerationResults the response of List Blobs
+type EnumerationResults struct {
+	XMLName    xml.Name `xml:"EnumerationResults"`
+	Prefix     string   `xml:"Prefix"`
+	Blobs      Blobs    `xml:"Blobs"`
+	NextMarker string   `xml:"NextMarker"`
+}
+
+type Blobs struct {
+	BlobPrefix []BlobPrefix `xml:"BlobPrefix"`
+	Blob       []Blob       `xml:"Blob"`
+}
+
+type BlobPrefix struct {
+	Name string `xml:"Name"`
+}
+
+type Blob struct {
+	Name       string         `xml:"Name"`
+	VersionId  string         `xml:"VersionId"`
+	Properties BlobProperties `xml:"Properties"`
+}
+
+type BlobProperties struct {
+	LastModified  string `xml:"Last-Modified"`
+	ETag          string `xml:"Etag"`
+	ContentLength uint64 `xml:"Content-Length"`
+	ContentMD5    string `xml:"Content-MD5"`
+	AccessTier    string `xml:"AccessTier"`
+}
+
+func (p BlobProperties) lastModified() time.Time {
+	lm, _ := utils.ParseRFC7231Time(p.LastModified)
+	return lm
+}
+
+// BlockList the body of Put Block List
+type BlockList struct {
+	XMLName xml.Name `xml:"BlockList"`
+	Latest  []string `xml:"Latest"`
+}
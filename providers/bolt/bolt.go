@@ -0,0 +1,547 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/logger"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/providers"
+	"github.com/senrok/yadal/utils"
+	"go.etcd.io/bbolt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultChunkSize = 1 << 20
+	defaultPageSize  = 1000
+	defaultTimeout   = 5 * time.Second
+)
+
+var errObjectChanged = fmt.Errorf("%w: the object is changed while reading", errors.ErrInterrupted)
+
+type Driver struct {
+	db        *bbolt.DB
+	root      string
+	chunkSize uint64
+	pageSize  int
+	logger.Logger
+}
+
+func (d *Driver) Metadata() interfaces.Metadata {
+	return providers.NewMetadata(interfaces.Bolt, d.root, d.db.Path(), interfaces.Read|interfaces.Write|interfaces.List|interfaces.Multipart)
+}
+
+// key returns the key of the object, e.g. `path/to/root/dir/`.
+func (d *Driver) key(path string) ([]byte, error) {
+	p, err := utils.BuildAbsPath(d.root, path)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(p), nil
+}
+
+// notFound returns the error of the missing object or upload.
+func notFound(kind error, path string) error {
+	return errors.ParseFsError(kind, os.ErrNotExist, path)
+}
+
+// replace puts the record of the object, the segments of the replaced one are deleted.
+func replace(tx *bbolt.Tx, key []byte, r record) error {
+	objects := tx.Bucket(objectsBucket)
+	old := record{}
+	found, err := get(objects, key, &old)
+	if err != nil {
+		return err
+	}
+	if found {
+		if err = deleteSegments(tx, old.Segments...); err != nil {
+			return err
+		}
+	}
+	return put(objects, key, r)
+}
+
+func (d *Driver) Create(ctx context.Context, path string, args options.CreateOptions) error {
+	if interfaces.ObjectMode(args.Mode) != interfaces.DIR {
+		if _, err := d.write(ctx, path, bytes.NewReader(nil)); err != nil {
+			return errors.ParseFsError(errors.ErrCreateFailed, err, path)
+		}
+		return nil
+	}
+	key, err := d.key(path)
+	if err != nil {
+		return errors.ParseFsError(errors.ErrCreateFailed, err, path)
+	}
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		return put(tx.Bucket(objectsBucket), key, record{Dir: true, Modified: time.Now()})
+	})
+	if err != nil {
+		return errors.ParseFsError(errors.ErrCreateFailed, err, path)
+	}
+	return nil
+}
+
+func (d *Driver) Read(ctx context.Context, path string, args options.ReadOptions) (io.ReadCloser, error) {
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	key, err := d.key(path)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrReadFailed, err, path)
+	}
+	r := record{}
+	var found bool
+	err = d.db.View(func(tx *bbolt.Tx) (err error) {
+		found, err = get(tx.Bucket(objectsBucket), key, &r)
+		return
+	})
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrReadFailed, err, path)
+	}
+	if !found || r.Dir {
+		return nil, notFound(errors.ErrReadFailed, path)
+	}
+	var offset uint64
+	if args.Offset != nil {
+		offset = *args.Offset
+	}
+	size := uint64(0)
+	if offset < r.Size {
+		size = r.Size - offset
+	}
+	if args.Size != nil && *args.Size < size {
+		size = *args.Size
+	}
+	return newReader(d.db, r.Segments, offset, size), nil
+}
+
+// write stores the reader as a new segment, and replaces the object atomically once it's stored.
+func (d *Driver) write(ctx context.Context, path string, reader io.Reader) (object.WriteResult, error) {
+	key, err := d.key(path)
+	if err != nil {
+		return object.WriteResult{}, err
+	}
+	s, sum, err := d.writeSegment(ctx, reader)
+	if err != nil {
+		return object.WriteResult{}, err
+	}
+	etag := fmt.Sprintf("\"%s\"", hex.EncodeToString(sum))
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		return replace(tx, key, record{Size: s.Size, Modified: time.Now(), ETag: etag, Segments: []segment{s}})
+	})
+	if err != nil {
+		_ = d.db.Update(func(tx *bbolt.Tx) error {
+			return deleteSegments(tx, s)
+		})
+		return object.WriteResult{}, err
+	}
+	return object.WriteResult{Size: s.Size, ETag: &etag}, nil
+}
+
+func (d *Driver) Write(ctx context.Context, path string, args options.WriteOptions, reader io.Reader) (interfaces.WriteResult, error) {
+	result, err := d.write(ctx, path, reader)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrWriteFailed, err, path)
+	}
+	return result, nil
+}
+
+// Stat returns the metadata of the object, the dirs exist if either they are created or they have children.
+func (d *Driver) Stat(ctx context.Context, path string, args options.StatOptions) (interfaces.ObjectMetadata, error) {
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	if path == "/" {
+		return object.Metadata{ObjectMode: interfaces.DIR}, nil
+	}
+	key, err := d.key(path)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrStatFailed, err, path)
+	}
+	r := record{}
+	var found bool
+	err = d.db.View(func(tx *bbolt.Tx) (err error) {
+		objects := tx.Bucket(objectsBucket)
+		if found, err = get(objects, key, &r); found || err != nil || !strings.HasSuffix(path, "/") {
+			return
+		}
+		k, _ := objects.Cursor().Seek(key)
+		found = k != nil && bytes.HasPrefix(k, key)
+		r.Dir = true
+		return
+	})
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrStatFailed, err, path)
+	}
+	if !found {
+		return nil, notFound(errors.ErrStatFailed, path)
+	}
+	return metadata(r)
+}
+
+func metadata(r record) (interfaces.ObjectMetadata, error) {
+	if r.Dir {
+		return object.NewMetadata(object.SetMode(interfaces.DIR))
+	}
+	return object.NewMetadata(
+		object.SetMode(interfaces.FILE),
+		object.SetMetadata(r.Size, r.Modified, r.ETag),
+	)
+}
+
+// Delete removes the object, or the dir with all of its children.
+func (d *Driver) Delete(ctx context.Context, path string, args options.DeleteOptions) error {
+	if args.VersionId != "" {
+		return errors.ErrUnsupportedMethod
+	}
+	key, err := d.key(path)
+	if err != nil {
+		return errors.ParseFsError(errors.ErrDeleteFailed, err, path)
+	}
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		objects := tx.Bucket(objectsBucket)
+		if !strings.HasSuffix(path, "/") {
+			return deleteObject(tx, objects, key)
+		}
+		// the cursor is reset after the deletion
+		for k, _ := objects.Cursor().Seek(key); k != nil && bytes.HasPrefix(k, key); k, _ = objects.Cursor().Seek(key) {
+			if err := deleteObject(tx, objects, k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.ParseFsError(errors.ErrDeleteFailed, err, path)
+	}
+	return nil
+}
+
+func deleteObject(tx *bbolt.Tx, objects *bbolt.Bucket, key []byte) error {
+	r := record{}
+	found, err := get(objects, key, &r)
+	if err != nil || !found {
+		return err
+	}
+	if err = deleteSegments(tx, r.Segments...); err != nil {
+		return err
+	}
+	return objects.Delete(key)
+}
+
+func (d *Driver) List(ctx context.Context, path string, args options.ListOptions) (interfaces.ObjectStream, error) {
+	return object.NewObjectStream(NewDirStream(d, d.root, path)), nil
+}
+
+func (d *Driver) ListVersions(ctx context.Context, path string, args options.ListVersions) (interfaces.ObjectStream, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) PreSign(ctx context.Context, path string, args options.PreSignOptions) (*http.Request, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) GetTags(ctx context.Context, path string, args options.GetTags) (map[string]string, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) SetTags(ctx context.Context, path string, args options.SetTags) error {
+	return errors.ErrUnsupportedMethod
+}
+
+// CreateMultipart starts an upload, the parts are staged until the upload is completed or aborted.
+func (d *Driver) CreateMultipart(ctx context.Context, path string, args options.CreateMultipart) (string, error) {
+	key, err := d.key(path)
+	if err != nil {
+		return "", errors.ParseFsError(errors.ErrCreateMultipartFailed, err, path)
+	}
+	uploadId := uuid.New().String()
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		return put(tx.Bucket(uploadsBucket), []byte(uploadId), upload{Key: string(key), Initiated: time.Now()})
+	})
+	if err != nil {
+		return "", errors.ParseFsError(errors.ErrCreateMultipartFailed, err, path)
+	}
+	return uploadId, nil
+}
+
+// getUpload returns the upload of the key, found is false if it doesn't exist or it's not of the key.
+func getUpload(tx *bbolt.Tx, uploadId string, key []byte) (found bool, err error) {
+	u := upload{}
+	if found, err = get(tx.Bucket(uploadsBucket), []byte(uploadId), &u); err != nil || !found {
+		return
+	}
+	return u.Key == string(key), nil
+}
+
+// WriteMultipart stages the part, the part of the same number is replaced.
+func (d *Driver) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.Reader) (interfaces.ObjectPart, error) {
+	if args.UploadId == "" {
+		return nil, errors.ErrUploadIdRequired
+	}
+	key, err := d.key(path)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrWriteMultipartFailed, err, path)
+	}
+	var found bool
+	err = d.db.View(func(tx *bbolt.Tx) (err error) {
+		found, err = getUpload(tx, args.UploadId, key)
+		return
+	})
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrWriteMultipartFailed, err, path)
+	}
+	if !found {
+		return nil, notFound(errors.ErrWriteMultipartFailed, path)
+	}
+
+	s, sum, err := d.writeSegment(ctx, reader)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrWriteMultipartFailed, err, path)
+	}
+	p := part{Segment: s, ETag: fmt.Sprintf("\"%s\"", hex.EncodeToString(sum))}
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		// the upload may be completed or aborted meanwhile
+		if found, err = getUpload(tx, args.UploadId, key); err != nil || !found {
+			return err
+		}
+		parts, err := tx.Bucket(partsBucket).CreateBucketIfNotExists([]byte(args.UploadId))
+		if err != nil {
+			return err
+		}
+		partKey := uint64Key(uint64(args.PartNumber))
+		old := part{}
+		if replaced, err := get(parts, partKey, &old); err != nil {
+			return err
+		} else if replaced {
+			if err = deleteSegments(tx, old.Segment); err != nil {
+				return err
+			}
+		}
+		return put(parts, partKey, p)
+	})
+	if err != nil || !found {
+		_ = d.db.Update(func(tx *bbolt.Tx) error {
+			return deleteSegments(tx, s)
+		})
+	}
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrWriteMultipartFailed, err, path)
+	}
+	if !found {
+		return nil, notFound(errors.ErrWriteMultipartFailed, path)
+	}
+	return object.ObjectPart{PartNumber: args.PartNumber, ETag: p.ETag}, nil
+}
+
+// deleteUpload deletes the upload with its parts, the segments of the parts are deleted except the kept ones.
+func deleteUpload(tx *bbolt.Tx, uploadId string, keep map[string]bool) error {
+	if parts := tx.Bucket(partsBucket).Bucket([]byte(uploadId)); parts != nil {
+		err := parts.ForEach(func(k, v []byte) error {
+			p := part{}
+			if _, err := get(parts, k, &p); err != nil {
+				return err
+			}
+			if keep[p.Segment.Id] {
+				return nil
+			}
+			return deleteSegments(tx, p.Segment)
+		})
+		if err != nil {
+			return err
+		}
+		if err = tx.Bucket(partsBucket).DeleteBucket([]byte(uploadId)); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(uploadsBucket).Delete([]byte(uploadId))
+}
+
+// CompleteMultipart joins the segments of the parts in order as the object in a single transaction,
+// the etag is the md5 of the md5s of the parts followed by the number of the parts, like S3.
+func (d *Driver) CompleteMultipart(ctx context.Context, path string, args options.CompleteMultipart) error {
+	if args.UploadId == "" {
+		return errors.ErrUploadIdRequired
+	}
+	if len(args.ObjectParts) == 0 {
+		return errors.ParseFsError(errors.ErrCompleteMultipartFailed, errors.ErrInvalidPart, path)
+	}
+	key, err := d.key(path)
+	if err != nil {
+		return errors.ParseFsError(errors.ErrCompleteMultipartFailed, err, path)
+	}
+	var found bool
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		if found, err = getUpload(tx, args.UploadId, key); err != nil || !found {
+			return err
+		}
+		parts := tx.Bucket(partsBucket).Bucket([]byte(args.UploadId))
+		r := record{Modified: time.Now()}
+		keep := map[string]bool{}
+		sums := md5.New()
+		var last uint
+		for _, objectPart := range args.ObjectParts {
+			if objectPart.GetPartNumber() <= last {
+				return errors.ErrInvalidPart
+			}
+			last = objectPart.GetPartNumber()
+			p := part{}
+			if ok, err := get(parts, uint64Key(uint64(last)), &p); err != nil {
+				return err
+			} else if !ok || p.ETag != objectPart.GetETag() {
+				return errors.ErrInvalidPart
+			}
+			sum, err := hex.DecodeString(strings.Trim(p.ETag, "\""))
+			if err != nil {
+				return err
+			}
+			sums.Write(sum)
+			r.Size += p.Segment.Size
+			r.Segments = append(r.Segments, p.Segment)
+			keep[p.Segment.Id] = true
+		}
+		r.ETag = fmt.Sprintf("\"%s-%d\"", hex.EncodeToString(sums.Sum(nil)), len(args.ObjectParts))
+		if err := replace(tx, key, r); err != nil {
+			return err
+		}
+		return deleteUpload(tx, args.UploadId, keep)
+	})
+	if err != nil {
+		return errors.ParseFsError(errors.ErrCompleteMultipartFailed, err, path)
+	}
+	if !found {
+		return notFound(errors.ErrCompleteMultipartFailed, path)
+	}
+	return nil
+}
+
+func (d *Driver) AbortMultipart(ctx context.Context, path string, args options.AbortMultipart) error {
+	if args.UploadId == "" {
+		return errors.ErrUploadIdRequired
+	}
+	key, err := d.key(path)
+	if err != nil {
+		return errors.ParseFsError(errors.ErrAbortMultipartFailed, err, path)
+	}
+	var found bool
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		if found, err = getUpload(tx, args.UploadId, key); err != nil || !found {
+			return err
+		}
+		return deleteUpload(tx, args.UploadId, nil)
+	})
+	if err != nil {
+		return errors.ParseFsError(errors.ErrAbortMultipartFailed, err, path)
+	}
+	if !found {
+		return notFound(errors.ErrAbortMultipartFailed, path)
+	}
+	return nil
+}
+
+// ListMultipart returns the in-progress uploads of the objects under the path.
+func (d *Driver) ListMultipart(ctx context.Context, path string, args options.ListMultipart) ([]interfaces.MultipartUpload, error) {
+	prefix, err := d.key(path)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrListMultipartFailed, err, path)
+	}
+	var uploads []interfaces.MultipartUpload
+	err = d.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(uploadsBucket).ForEach(func(k, v []byte) error {
+			u := upload{}
+			if _, err := get(tx.Bucket(uploadsBucket), k, &u); err != nil {
+				return err
+			}
+			if !strings.HasPrefix(u.Key, string(prefix)) || !args.Includes(u.Initiated) {
+				return nil
+			}
+			p, err := utils.BuildRealPath(d.root, "/"+u.Key)
+			if err != nil {
+				return err
+			}
+			m := object.MultipartUpload{
+				Path:      p,
+				UploadId:  string(k),
+				Initiated: u.Initiated,
+			}
+			if args.WithSize {
+				var size uint64
+				if parts := tx.Bucket(partsBucket).Bucket(k); parts != nil {
+					err = parts.ForEach(func(k, v []byte) error {
+						p := part{}
+						if _, err := get(parts, k, &p); err != nil {
+							return err
+						}
+						size += p.Segment.Size
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+				}
+				m.Size = &size
+			}
+			uploads = append(uploads, m)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrListMultipartFailed, err, path)
+	}
+	return uploads, nil
+}
+
+// NewDriver returns a driver storing the objects in a single bbolt database file,
+// the objects are stored in chunks, and they are replaced atomically once they are written.
+func NewDriver(ctx context.Context, opt Options) (interfaces.Accessor, error) {
+	db := opt.DB
+	if db == nil {
+		if opt.Path == "" {
+			return nil, fmt.Errorf("path is required")
+		}
+		timeout := opt.Timeout
+		if timeout == 0 {
+			timeout = defaultTimeout
+		}
+		var err error
+		if db, err = bbolt.Open(opt.Path, 0600, &bbolt.Options{Timeout: timeout}); err != nil {
+			return nil, err
+		}
+	}
+	err := db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{objectsBucket, chunksBucket, uploadsBucket, partsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	chunkSize := opt.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = defaultChunkSize
+	}
+	pageSize := opt.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	return &Driver{
+		db:        db,
+		root:      utils.NormalizeRoot(opt.Root),
+		chunkSize: chunkSize,
+		pageSize:  pageSize,
+	}, nil
+}
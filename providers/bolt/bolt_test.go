@@ -0,0 +1,266 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/options"
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/bbolt"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func setupDriver(t *testing.T, opt Options) *Driver {
+	if opt.Path == "" {
+		opt.Path = filepath.Join(t.TempDir(), "yadal.db")
+	}
+	d, err := NewDriver(context.Background(), opt)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = d.(*Driver).db.Close()
+	})
+	return d.(*Driver)
+}
+
+func TestDriver(t *testing.T) {
+	// the objects are stored in the chunks of 4 bytes
+	d := setupDriver(t, Options{Root: "/root/", ChunkSize: 4})
+	ctx := context.Background()
+	assert.Equal(t, interfaces.Bolt, d.Metadata().Provider())
+	assert.True(t, d.Metadata().Capability().Has(interfaces.Read, interfaces.Write, interfaces.List, interfaces.Multipart))
+
+	content := []byte("Hello,World!")
+	result, err := d.Write(ctx, "dir/sub/a b.txt", options.WriteOptions{Size: uint64(len(content))}, bytes.NewReader(content))
+	assert.Nil(t, err)
+	assert.Equal(t, "\"98f97a791ef1457579a5b7e88a495063\"", *result.GetETag())
+
+	meta, err := d.Stat(ctx, "dir/sub/a b.txt", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.FILE, meta.Mode())
+	assert.Equal(t, uint64(len(content)), *meta.ContentLength())
+	assert.Equal(t, *result.GetETag(), *meta.ETag())
+	assert.Equal(t, "98f97a791ef1457579a5b7e88a495063", *meta.ContentMD5())
+
+	for _, c := range []struct {
+		offset, size uint64
+		expected     string
+	}{
+		{0, 100, "Hello,World!"},
+		{3, 6, "lo,Wor"},
+		{4, 4, "o,Wo"},
+		{11, 5, "!"},
+		{12, 5, ""},
+	} {
+		reader, err := d.Read(ctx, "dir/sub/a b.txt", options.ReadOptions{Offset: &c.offset, Size: &c.size})
+		assert.Nil(t, err)
+		b, err := io.ReadAll(reader)
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, string(b))
+	}
+
+	// the dirs exist if they have children
+	meta, err = d.Stat(ctx, "dir/", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.DIR, meta.Mode())
+
+	assert.Nil(t, d.Create(ctx, "empty/", options.CreateOptions{Mode: int8(interfaces.DIR)}))
+	assert.Nil(t, d.Create(ctx, "empty/", options.CreateOptions{Mode: int8(interfaces.DIR)}))
+	meta, err = d.Stat(ctx, "empty/", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.DIR, meta.Mode())
+	assert.Nil(t, d.Create(ctx, "file", options.CreateOptions{Mode: int8(interfaces.FILE)}))
+	meta, err = d.Stat(ctx, "file", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), *meta.ContentLength())
+
+	_, err = d.Stat(ctx, "not-exist", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+	_, err = d.Stat(ctx, "not-exist/", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+	_, err = d.Read(ctx, "not-exist", options.ReadOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+	// the overwritten chunks are deleted
+	_, err = d.Write(ctx, "dir/sub/a b.txt", options.WriteOptions{Size: 1}, bytes.NewReader([]byte("x")))
+	assert.Nil(t, err)
+	reader, err := d.Read(ctx, "dir/sub/a b.txt", options.ReadOptions{})
+	assert.Nil(t, err)
+	b, _ := io.ReadAll(reader)
+	assert.Equal(t, "x", string(b))
+	assert.Equal(t, 2, d.countSegments(t))
+
+	// the children are deleted with the dir
+	assert.Nil(t, d.Delete(ctx, "dir/", options.DeleteOptions{}))
+	assert.Nil(t, d.Delete(ctx, "dir/", options.DeleteOptions{}))
+	_, err = d.Stat(ctx, "dir/sub/a b.txt", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+	assert.Equal(t, 1, d.countSegments(t))
+}
+
+// countSegments returns the number of the stored segments.
+func (d *Driver) countSegments(t *testing.T) (n int) {
+	assert.Nil(t, d.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(chunksBucket).ForEach(func(k, v []byte) error {
+			n++
+			return nil
+		})
+	}))
+	return
+}
+
+func TestChangedWhileReading(t *testing.T) {
+	d := setupDriver(t, Options{ChunkSize: 2})
+	ctx := context.Background()
+
+	_, err := d.Write(ctx, "file", options.WriteOptions{}, bytes.NewReader([]byte("abcdef")))
+	assert.Nil(t, err)
+	reader, err := d.Read(ctx, "file", options.ReadOptions{})
+	assert.Nil(t, err)
+	b := make([]byte, 2)
+	_, err = io.ReadFull(reader, b)
+	assert.Nil(t, err)
+	assert.Equal(t, "ab", string(b))
+
+	_, err = d.Write(ctx, "file", options.WriteOptions{}, bytes.NewReader([]byte("ghijkl")))
+	assert.Nil(t, err)
+	_, err = io.ReadFull(reader, b)
+	assert.True(t, errors.Is(err, errors.ErrInterrupted))
+}
+
+func TestList(t *testing.T) {
+	d := setupDriver(t, Options{Root: "/root/", PageSize: 2})
+	ctx := context.Background()
+
+	for _, path := range []string{"dir/a", "dir/b c", "dir/sub/d", "dir/sub/e/f", "dir0", "dir!"} {
+		_, err := d.Write(ctx, path, options.WriteOptions{Size: 1}, bytes.NewReader([]byte("x")))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, d.Create(ctx, "dir/", options.CreateOptions{Mode: int8(interfaces.DIR)}))
+	assert.Nil(t, d.Create(ctx, "dir/empty/", options.CreateOptions{Mode: int8(interfaces.DIR)}))
+
+	for path, expected := range map[string][][]string{
+		"dir/": {
+			{"dir/a", "dir/b c"},
+			{"dir/empty/", "dir/sub/"},
+		},
+		"/": {
+			{"dir!", "dir/"},
+			{"dir0"},
+		},
+		"dir/sub/": {
+			{"dir/sub/d", "dir/sub/e/"},
+		},
+		"not-exist/": {},
+	} {
+		stream := NewDirStream(d, d.root, path)
+		var pages [][]string
+		for {
+			entries, err := stream.NextPage(ctx)
+			assert.Nil(t, err)
+			if entries == nil {
+				break
+			}
+			var page []string
+			for _, entry := range entries {
+				page = append(page, entry.Path())
+				assert.Equal(t, entry.Path()[len(entry.Path())-1] != '/', entry.Metadata().Mode() == interfaces.FILE)
+			}
+			pages = append(pages, page)
+		}
+		assert.Equal(t, len(expected), len(pages), path)
+		for i := range expected {
+			assert.Equal(t, expected[i], pages[i], path)
+		}
+	}
+}
+
+func TestMultipart(t *testing.T) {
+	d := setupDriver(t, Options{Root: "/root/", ChunkSize: 3})
+	ctx := context.Background()
+
+	uploadId, err := d.CreateMultipart(ctx, "dir/file", options.CreateMultipart{})
+	assert.Nil(t, err)
+	var parts []options.ObjectPart
+	for i, content := range []string{"Hello,", "xxx", "World!"} {
+		part, err := d.WriteMultipart(ctx, "dir/file", options.WriteMultipart{UploadId: uploadId, PartNumber: uint(i + 1)}, bytes.NewReader([]byte(content)))
+		assert.Nil(t, err)
+		parts = append(parts, part)
+	}
+	// the part is replaced
+	part, err := d.WriteMultipart(ctx, "dir/file", options.WriteMultipart{UploadId: uploadId, PartNumber: 2}, bytes.NewReader([]byte(" ")))
+	assert.Nil(t, err)
+	parts[1] = part
+
+	uploads, err := d.ListMultipart(ctx, "dir/", options.ListMultipart{WithSize: true})
+	assert.Nil(t, err)
+	assert.Len(t, uploads, 1)
+	assert.Equal(t, "dir/file", uploads[0].GetPath())
+	assert.Equal(t, uploadId, uploads[0].GetUploadId())
+	assert.Equal(t, uint64(13), *uploads[0].GetSize())
+
+	// the parts are in order and their etags match
+	for _, invalid := range [][]options.ObjectPart{
+		{parts[1], parts[0]},
+		{object.ObjectPart{PartNumber: 1, ETag: "\"wrong\""}},
+		{object.ObjectPart{PartNumber: 4, ETag: parts[0].GetETag()}},
+	} {
+		err = d.CompleteMultipart(ctx, "dir/file", options.CompleteMultipart{UploadId: uploadId, ObjectParts: invalid})
+		assert.True(t, errors.Is(err, errors.ErrInvalidPart))
+	}
+	_, err = d.Stat(ctx, "dir/file", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+	assert.Nil(t, d.CompleteMultipart(ctx, "dir/file", options.CompleteMultipart{UploadId: uploadId, ObjectParts: parts}))
+	reader, err := d.Read(ctx, "dir/file", options.ReadOptions{})
+	assert.Nil(t, err)
+	b, _ := io.ReadAll(reader)
+	assert.Equal(t, "Hello, World!", string(b))
+
+	offset, size := uint64(5), uint64(4)
+	reader, err = d.Read(ctx, "dir/file", options.ReadOptions{Offset: &offset, Size: &size})
+	assert.Nil(t, err)
+	b, _ = io.ReadAll(reader)
+	assert.Equal(t, ", Wo", string(b))
+
+	meta, err := d.Stat(ctx, "dir/file", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "\"2a286cf9913e025610258c495c00c524-3\"", *meta.ETag())
+
+	uploads, err = d.ListMultipart(ctx, "dir/", options.ListMultipart{})
+	assert.Nil(t, err)
+	assert.Len(t, uploads, 0)
+	err = d.CompleteMultipart(ctx, "dir/file", options.CompleteMultipart{UploadId: uploadId, ObjectParts: parts})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+	assert.Equal(t, 3, d.countSegments(t))
+
+	// the parts are deleted with the aborted upload
+	uploadId, err = d.CreateMultipart(ctx, "dir/file", options.CreateMultipart{})
+	assert.Nil(t, err)
+	_, err = d.WriteMultipart(ctx, "dir/file", options.WriteMultipart{UploadId: uploadId, PartNumber: 1}, bytes.NewReader([]byte("x")))
+	assert.Nil(t, err)
+	assert.Nil(t, d.AbortMultipart(ctx, "dir/file", options.AbortMultipart{UploadId: uploadId}))
+	assert.Equal(t, 3, d.countSegments(t))
+	err = d.AbortMultipart(ctx, "dir/file", options.AbortMultipart{UploadId: uploadId})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+	_, err = d.WriteMultipart(ctx, "dir/file", options.WriteMultipart{UploadId: uploadId, PartNumber: 1}, bytes.NewReader([]byte("x")))
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "yadal.db")
+	d := setupDriver(t, Options{Path: path})
+	ctx := context.Background()
+	_, err := d.Write(ctx, "file", options.WriteOptions{}, bytes.NewReader([]byte("persisted")))
+	assert.Nil(t, err)
+	assert.Nil(t, d.db.Close())
+
+	d = setupDriver(t, Options{Path: path})
+	reader, err := d.Read(ctx, "file", options.ReadOptions{})
+	assert.Nil(t, err)
+	b, _ := io.ReadAll(reader)
+	assert.Equal(t, "persisted", string(b))
+}
@@ -0,0 +1,93 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/utils"
+	"go.etcd.io/bbolt"
+)
+
+// DirStream lists the immediate children of the dir by seeking the keys with its prefix,
+// the entries are returned in pages of the page size.
+type DirStream struct {
+	*Driver
+	root string
+	path string
+
+	// marker the key to seek the next page from
+	marker []byte
+	done   bool
+}
+
+func (d *DirStream) NextPage(ctx context.Context) ([]interfaces.Entry, error) {
+	if d.done {
+		return nil, nil
+	}
+	path := d.path
+	if path == "/" {
+		path = ""
+	}
+	prefix, err := d.key(path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrListFailed, err)
+	}
+	if d.marker == nil {
+		d.marker = prefix
+	}
+
+	var entries []interfaces.Entry
+	err = d.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(objectsBucket).Cursor()
+		for k, v := c.Seek(d.marker); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Seek(d.marker) {
+			if len(entries) == d.pageSize {
+				return nil
+			}
+			name := k[len(prefix):]
+			// the marker of the dir itself
+			if len(name) == 0 {
+				d.marker = append(append([]byte(nil), k...), 0)
+				continue
+			}
+			var meta interfaces.ObjectMetadata
+			if i := bytes.IndexByte(name, '/'); i >= 0 {
+				// skips the subtree, the keys of it are followed by `<dir>0` since '0' is next to '/'
+				k = k[:len(prefix)+i+1]
+				d.marker = append(append([]byte(nil), k[:len(k)-1]...), '0')
+				meta = object.Metadata{ObjectMode: interfaces.DIR}
+			} else {
+				d.marker = append(append([]byte(nil), k...), 0)
+				r := record{}
+				if err := json.Unmarshal(v, &r); err != nil {
+					return err
+				}
+				if meta, err = metadata(r); err != nil {
+					return err
+				}
+			}
+			p, err := utils.BuildRealPath(d.root, "/"+string(k))
+			if err != nil {
+				return err
+			}
+			entries = append(entries, object.NewEntry(d.Driver, p, meta, meta.Mode() == interfaces.FILE))
+		}
+		d.done = true
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrListFailed, err)
+	}
+	return entries, nil
+}
+
+func NewDirStream(d *Driver, root, path string) interfaces.ObjectPageStream {
+	return &DirStream{
+		Driver: d,
+		root:   root,
+		path:   path,
+		done:   false,
+	}
+}
@@ -0,0 +1,23 @@
+package bolt
+
+import (
+	"go.etcd.io/bbolt"
+	"time"
+)
+
+type Options struct {
+	// Path the database file, it's created if it doesn't exist.
+	Path string
+	Root string
+
+	// ChunkSize the max size of the chunks the objects are stored in, defaults to 1MiB.
+	// It only applies to the objects written afterwards.
+	ChunkSize uint64
+	// PageSize the max number of the entries of a listing page, defaults to 1000.
+	PageSize int
+	// Timeout the timeout of waiting for the file lock held by the other processes, defaults to 5s.
+	Timeout time.Duration
+
+	// DB the opened database shared with the others, Path is ignored if it's set.
+	DB *bbolt.DB
+}
@@ -0,0 +1,205 @@
+package bolt
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/google/uuid"
+	"go.etcd.io/bbolt"
+	"io"
+	"time"
+)
+
+// The layout of the database:
+//
+//	objects/<key>                      the record of the object or the dir marker, the keys of dirs end with `/`
+//	chunks/<segment id>/<index>        the chunks of a segment
+//	uploads/<upload id>                the in-progress multipart upload
+//	parts/<upload id>/<part number>    the uploaded parts of the upload
+var (
+	objectsBucket = []byte("objects")
+	chunksBucket  = []byte("chunks")
+	uploadsBucket = []byte("uploads")
+	partsBucket   = []byte("parts")
+)
+
+// segment a sequence of chunks, an object is made of one segment if it's written at once,
+// or of the segments of its parts if it's completed from a multipart upload.
+type segment struct {
+	Id        string `json:"id"`
+	Size      uint64 `json:"size"`
+	ChunkSize uint64 `json:"chunkSize"`
+}
+
+type record struct {
+	Dir      bool      `json:"dir,omitempty"`
+	Size     uint64    `json:"size"`
+	Modified time.Time `json:"modified"`
+	ETag     string    `json:"etag,omitempty"`
+	Segments []segment `json:"segments,omitempty"`
+}
+
+type upload struct {
+	Key       string    `json:"key"`
+	Initiated time.Time `json:"initiated"`
+}
+
+type part struct {
+	Segment segment `json:"segment"`
+	ETag    string  `json:"etag"`
+}
+
+// uint64Key encodes the index of chunks and the number of parts in big endian, so that they are sorted.
+func uint64Key(i uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, i)
+	return b
+}
+
+// get decodes the value of the key, found is false if the key doesn't exist.
+func get(b *bbolt.Bucket, key []byte, v interface{}) (found bool, err error) {
+	if b == nil {
+		return false, nil
+	}
+	value := b.Get(key)
+	if value == nil {
+		return false, nil
+	}
+	return true, json.Unmarshal(value, v)
+}
+
+func put(b *bbolt.Bucket, key []byte, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, value)
+}
+
+// deleteSegments deletes the chunks of the segments.
+func deleteSegments(tx *bbolt.Tx, segments ...segment) error {
+	chunks := tx.Bucket(chunksBucket)
+	for _, s := range segments {
+		if err := chunks.DeleteBucket([]byte(s.Id)); err != nil && err != bbolt.ErrBucketNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSegment stores the reader in chunks, each chunk is committed in its own transaction,
+// so that the large objects are not held in memory. The segment is invisible until it's referred by a record,
+// and it's deleted if the write fails.
+func (d *Driver) writeSegment(ctx context.Context, reader io.Reader) (segment, []byte, error) {
+	s := segment{Id: uuid.New().String(), ChunkSize: d.chunkSize}
+	hash := md5.New()
+	buf := make([]byte, d.chunkSize)
+	fail := func(err error) (segment, []byte, error) {
+		_ = d.db.Update(func(tx *bbolt.Tx) error {
+			return deleteSegments(tx, s)
+		})
+		return segment{}, nil, err
+	}
+	for index := uint64(0); ; index++ {
+		if err := ctx.Err(); err != nil {
+			return fail(err)
+		}
+		n, err := io.ReadFull(reader, buf)
+		last := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !last {
+			return fail(err)
+		}
+		err = d.db.Update(func(tx *bbolt.Tx) error {
+			chunks, err := tx.Bucket(chunksBucket).CreateBucketIfNotExists([]byte(s.Id))
+			if err != nil || n == 0 {
+				return err
+			}
+			return chunks.Put(uint64Key(index), buf[:n])
+		})
+		if err != nil {
+			return fail(err)
+		}
+		hash.Write(buf[:n])
+		s.Size += uint64(n)
+		if last {
+			return s, hash.Sum(nil), nil
+		}
+	}
+}
+
+// reader reads the chunks of the segments one by one, each chunk is read in its own transaction,
+// so that the writers are not blocked by the slow readers.
+type reader struct {
+	db       *bbolt.DB
+	segments []segment
+	// seg the current segment, chunk the next chunk of it to read
+	seg   int
+	chunk uint64
+	// skip the bytes skipped in the next chunk
+	skip      uint64
+	remaining uint64
+	buf       []byte
+}
+
+// newReader seeks the offset of the segments.
+func newReader(db *bbolt.DB, segments []segment, offset, size uint64) *reader {
+	r := &reader{db: db, segments: segments, remaining: size}
+	for r.seg < len(segments) && offset >= segments[r.seg].Size {
+		offset -= segments[r.seg].Size
+		r.seg++
+	}
+	if r.seg < len(segments) {
+		r.chunk = offset / segments[r.seg].ChunkSize
+		r.skip = offset % segments[r.seg].ChunkSize
+	}
+	return r
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+	if len(r.buf) == 0 {
+		if err := r.fetch(); err != nil {
+			return 0, err
+		}
+	}
+	if uint64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.remaining -= uint64(n)
+	return n, nil
+}
+
+// fetch reads the next chunk, it fails if the object is overwritten or deleted while reading.
+func (r *reader) fetch() error {
+	for r.seg < len(r.segments) && r.chunk*r.segments[r.seg].ChunkSize >= r.segments[r.seg].Size {
+		r.seg++
+		r.chunk = 0
+	}
+	if r.seg == len(r.segments) {
+		return io.ErrUnexpectedEOF
+	}
+	return r.db.View(func(tx *bbolt.Tx) error {
+		chunks := tx.Bucket(chunksBucket).Bucket([]byte(r.segments[r.seg].Id))
+		if chunks == nil {
+			return errObjectChanged
+		}
+		value := chunks.Get(uint64Key(r.chunk))
+		if value == nil || uint64(len(value)) < r.skip {
+			return errObjectChanged
+		}
+		// the value is only valid in the transaction
+		r.buf = append([]byte(nil), value[r.skip:]...)
+		r.skip = 0
+		r.chunk++
+		return nil
+	})
+}
+
+func (r *reader) Close() error {
+	return nil
+}
@@ -0,0 +1,63 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// tempFilePrefix the prefix of temp files which are renamed to objects once they are written.
+const tempFilePrefix = ".yadal-tmp-"
+
+// writeAtomic writes into a temp file in the same dir of p, then renames it to p,
+// so readers never observe a partial file.
+//
+// The temp file and its parent dir are synced before and after renaming if Options.Sync is set.
+func (d Driver) writeAtomic(p string, write func(file *os.File) error) error {
+	file, err := os.CreateTemp(filepath.Dir(p), tempFilePrefix+"*")
+	if err != nil {
+		return err
+	}
+	tmp := file.Name()
+	if err = d.writeTemp(file, write); err != nil {
+		_ = file.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err = file.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err = os.Rename(tmp, p); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if d.sync {
+		return syncDir(filepath.Dir(p))
+	}
+	return nil
+}
+
+func (d Driver) writeTemp(file *os.File, write func(file *os.File) error) error {
+	if err := file.Chmod(0644); err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		return err
+	}
+	if d.sync {
+		return file.Sync()
+	}
+	return nil
+}
+
+// syncDir persists the dir entries, e.g. a renamed file.
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return f.Sync()
+}
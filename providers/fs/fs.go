@@ -9,14 +9,16 @@ import (
 	"github.com/senrok/yadal/providers"
 	"github.com/senrok/yadal/utils"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
 type Driver struct {
-	root string
+	root            string
+	sync            bool
+	noSymlinkEscape bool
+	preSigner       preSigner
 }
 
 func (d Driver) fsMetadata(absPath string) (os.FileInfo, error) {
@@ -28,16 +30,20 @@ func (d Driver) fsMetadata(absPath string) (os.FileInfo, error) {
 }
 
 func (d Driver) Metadata() interfaces.Metadata {
+	capability := interfaces.Read | interfaces.Write | interfaces.List | interfaces.Multipart
+	if d.preSigner.enabled() {
+		capability |= interfaces.PreSign
+	}
 	return providers.NewMetadata(
 		interfaces.Fs,
 		d.root,
 		"",
-		interfaces.Read|interfaces.Write|interfaces.List,
+		capability,
 	)
 }
 
 func (d Driver) Create(ctx context.Context, path string, args options.CreateOptions) error {
-	p, err := utils.BuildAbsPath(d.root, path)
+	p, err := d.absPath(path)
 	if err != nil {
 		return errors.ParseFsError(errors.ErrCreateFailed, err, path)
 	}
@@ -64,7 +70,10 @@ func (d Driver) Create(ctx context.Context, path string, args options.CreateOpti
 }
 
 func (d Driver) Read(ctx context.Context, path string, args options.ReadOptions) (io.ReadCloser, error) {
-	p, err := utils.BuildAbsPath(d.root, path)
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	p, err := d.absPath(path)
 	if err != nil {
 		return nil, errors.ParseFsError(errors.ErrReadFailed, err, path)
 	}
@@ -86,29 +95,30 @@ func (d Driver) Read(ctx context.Context, path string, args options.ReadOptions)
 	return file, nil
 }
 
-func (d Driver) Write(ctx context.Context, path string, args options.WriteOptions, reader io.ReadSeeker) (uint64, error) {
-	p, err := utils.BuildAbsPath(d.root, path)
-	if err != nil {
-		return 0, errors.ParseFsError(errors.ErrWriteFailed, err, path)
-	}
-	parent := filepath.Dir(p)
-	err = os.MkdirAll(parent, 0755)
+func (d Driver) Write(ctx context.Context, path string, args options.WriteOptions, reader io.Reader) (interfaces.WriteResult, error) {
+	size, err := d.write(path, reader)
 	if err != nil {
-		return 0, errors.ParseFsError(errors.ErrWriteFailed, err, path)
+		return nil, err
 	}
-	var file *os.File
-	file, err = os.OpenFile(p, os.O_RDONLY|os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
-	defer func() {
-		_ = file.Close()
-	}()
+	return object.WriteResult{Size: size}, nil
+}
+
+// write streams the reader into the file, the reader is NOT required to be seekable.
+func (d Driver) write(path string, reader io.Reader) (uint64, error) {
+	p, err := d.absPath(path)
 	if err != nil {
 		return 0, errors.ParseFsError(errors.ErrWriteFailed, err, path)
 	}
-	bytes, err := io.ReadAll(reader)
+	parent := filepath.Dir(p)
+	err = os.MkdirAll(parent, 0755)
 	if err != nil {
 		return 0, errors.ParseFsError(errors.ErrWriteFailed, err, path)
 	}
-	written, err := file.Write(bytes)
+	var written int64
+	err = d.writeAtomic(p, func(file *os.File) (err error) {
+		written, err = io.Copy(file, reader)
+		return
+	})
 	if err != nil {
 		return 0, errors.ParseFsError(errors.ErrWriteFailed, err, path)
 	}
@@ -116,7 +126,10 @@ func (d Driver) Write(ctx context.Context, path string, args options.WriteOption
 }
 
 func (d Driver) Stat(ctx context.Context, path string, args options.StatOptions) (interfaces.ObjectMetadata, error) {
-	p, err := utils.BuildAbsPath(d.root, path)
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	p, err := d.absPath(path)
 	if err != nil {
 		return nil, errors.ParseFsError(errors.ErrStatFailed, err, p)
 	}
@@ -129,7 +142,10 @@ func (d Driver) Stat(ctx context.Context, path string, args options.StatOptions)
 }
 
 func (d Driver) Delete(ctx context.Context, path string, args options.DeleteOptions) error {
-	p, err := utils.BuildAbsPath(d.root, path)
+	if args.VersionId != "" {
+		return errors.ErrUnsupportedMethod
+	}
+	p, err := d.absPath(path)
 	if err != nil {
 		return errors.ParseFsError(errors.ErrDeleteFailed, err, path)
 	}
@@ -155,7 +171,7 @@ func (d Driver) Delete(ctx context.Context, path string, args options.DeleteOpti
 }
 
 func (d Driver) List(ctx context.Context, path string, args options.ListOptions) (interfaces.ObjectStream, error) {
-	p, err := utils.BuildAbsPath(d.root, path)
+	p, err := d.absPath(path)
 	if err != nil {
 		return nil, errors.ParseFsError(errors.ErrListFailed, err, path)
 	}
@@ -163,38 +179,68 @@ func (d Driver) List(ctx context.Context, path string, args options.ListOptions)
 	if err != nil {
 		return nil, errors.ParseFsError(errors.ErrListFailed, err, path)
 	}
+	entries := list[:0]
+	for _, e := range list {
+		if isInternal(path, e.Name()) {
+			continue
+		}
+		entries = append(entries, e)
+	}
 	return &DirStream{
 		Driver:  &d,
 		root:    d.root,
 		path:    path,
-		entries: list,
+		entries: entries,
 	}, nil
 }
 
-func (d Driver) PreSign(ctx context.Context, path string, args options.PreSignOptions) (*http.Request, error) {
+func (d Driver) GetTags(ctx context.Context, path string, args options.GetTags) (map[string]string, error) {
 	return nil, errors.ErrUnsupportedMethod
 }
 
-func (d Driver) CreateMultipart(ctx context.Context, path string, args options.CreateMultipart) (string, error) {
-	return "", errors.ErrUnsupportedMethod
+func (d Driver) SetTags(ctx context.Context, path string, args options.SetTags) error {
+	return errors.ErrUnsupportedMethod
 }
 
-func (d Driver) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.ReadSeeker) (interfaces.ObjectPart, error) {
+func (d Driver) ListVersions(ctx context.Context, path string, args options.ListVersions) (interfaces.ObjectStream, error) {
 	return nil, errors.ErrUnsupportedMethod
 }
 
-func (d Driver) CompleteMultipart(ctx context.Context, path string, args options.CompleteMultipart) error {
-	return errors.ErrUnsupportedMethod
-}
-
-func (d Driver) AbortMultipart(ctx context.Context, path string, args options.AbortMultipart) error {
-	return errors.ErrUnsupportedMethod
+// isInternal returns true if the entry is used by the driver itself, e.g. staged multipart uploads.
+func isInternal(dir string, name string) bool {
+	return (dir == "/" && name == uploadsDir) || strings.HasPrefix(name, tempFilePrefix)
 }
 
 type Options struct {
 	Root string
+	// Sync fsyncs written files and their parent dirs before returning,
+	// trades throughput for durability on crash.
+	Sync bool
+
+	// NoSymlinkEscape refuses following symlinks which point to somewhere outside the root,
+	// operations on such paths fail with errors.ErrPathEscaped.
+	NoSymlinkEscape bool
+
+	// PreSignEndpoint the base url where Handler is served, e.g. `http://127.0.0.1:8080/objects/`.
+	PreSignEndpoint string
+	// PreSignSecret the HMAC key shared with Handler.
+	//
+	// PreSign is enabled only if both PreSignEndpoint and PreSignSecret are set.
+	PreSignSecret []byte
 }
 
 func NewDriver(opt Options) interfaces.Accessor {
-	return &Driver{root: utils.NormalizeRoot(opt.Root)}
+	return newDriver(opt)
+}
+
+func newDriver(opt Options) *Driver {
+	return &Driver{
+		root:            utils.NormalizeRoot(opt.Root),
+		sync:            opt.Sync,
+		noSymlinkEscape: opt.NoSymlinkEscape,
+		preSigner: preSigner{
+			endpoint: opt.PreSignEndpoint,
+			secret:   opt.PreSignSecret,
+		},
+	}
 }
@@ -0,0 +1,218 @@
+package fs
+
+import (
+	"fmt"
+	"github.com/senrok/yadal/constants"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/utils"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Handler serves the requests pre-signed by Driver.PreSign, it mirrors s3 presigned urls for local development.
+//
+// # Behavior
+//
+//   - Requests are served only if the signature is valid and not expired.
+//   - `GET` reads the object, `Range` header is supported.
+//   - `PUT` writes the object, or a part if `uploadId` and `partNumber` are present.
+type Handler struct {
+	driver *Driver
+	prefix string
+}
+
+// NewHandler returns a Handler serves the objects under opt.Root,
+// opt MUST be the same as the one used to create the Driver.
+//
+// serve:
+//
+//	opt := fs.Options{Root: "/tmp/", PreSignEndpoint: "http://127.0.0.1:8080/objects/", PreSignSecret: []byte("secret")}
+//	h, _ := fs.NewHandler(opt)
+//	_ = http.ListenAndServe(":8080", h)
+func NewHandler(opt Options) (*Handler, error) {
+	d := newDriver(opt)
+	if !d.preSigner.enabled() {
+		return nil, fmt.Errorf("both PreSignEndpoint and PreSignSecret are required")
+	}
+	u, err := url.Parse(opt.PreSignEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	prefix := u.Path
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &Handler{driver: d, prefix: prefix}, nil
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, h.prefix) {
+		http.NotFound(w, r)
+		return
+	}
+	path := utils.NormalizePath(strings.TrimPrefix(r.URL.Path, h.prefix))
+	if path == "/" {
+		http.NotFound(w, r)
+		return
+	}
+	query := r.URL.Query()
+	if err := h.driver.preSigner.verify(r.Method, path, query); err != nil {
+		writeError(w, err)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		h.read(w, r, path)
+	case http.MethodPut:
+		if interfaces.ObjectModeFromPath(path).IsDir() {
+			http.Error(w, "can not write to a dir", http.StatusBadRequest)
+			return
+		}
+		if query.Has(UploadId) {
+			h.writePart(w, r, path)
+			return
+		}
+		h.write(w, r, path)
+	case http.MethodHead:
+		h.stat(w, r, path)
+	case http.MethodDelete:
+		if err := h.driver.Delete(r.Context(), path, options.DeleteOptions{}); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func (h Handler) stat(w http.ResponseWriter, r *http.Request, path string) {
+	meta, err := h.driver.Stat(r.Context(), path, options.StatOptions{})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if !meta.Mode().IsFile() {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set(constants.ContentLength, strconv.FormatUint(*meta.ContentLength(), 10))
+	if modified := meta.LastModified(); modified != nil {
+		w.Header().Set("last-modified", modified.UTC().Format(http.TimeFormat))
+	}
+}
+
+func (h Handler) read(w http.ResponseWriter, r *http.Request, path string) {
+	meta, err := h.driver.Stat(r.Context(), path, options.StatOptions{})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if !meta.Mode().IsFile() {
+		http.NotFound(w, r)
+		return
+	}
+	total := *meta.ContentLength()
+	offset, size, err := parseRange(r.Header.Get("range"), total)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	reader, err := h.driver.Read(r.Context(), path, options.ReadOptions{Offset: &offset, Size: &size})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	w.Header().Set(constants.ContentLength, strconv.FormatUint(size, 10))
+	if size != total {
+		w.Header().Set("content-range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+size-1, total))
+		w.WriteHeader(http.StatusPartialContent)
+	}
+	_, _ = io.Copy(w, reader)
+}
+
+func (h Handler) write(w http.ResponseWriter, r *http.Request, path string) {
+	if _, err := h.driver.write(path, r.Body); err != nil {
+		writeError(w, err)
+	}
+}
+
+func (h Handler) writePart(w http.ResponseWriter, r *http.Request, path string) {
+	query := r.URL.Query()
+	partNumber, err := strconv.ParseUint(query.Get(PartNumber), 10, 32)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	part, err := h.driver.writePart(path, options.WriteMultipart{
+		UploadId:   query.Get(UploadId),
+		PartNumber: uint(partNumber),
+	}, r.Body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set(constants.ETag, part.GetETag())
+}
+
+// parseRange parses a single `bytes=` range, returns the whole object if rng is empty.
+func parseRange(rng string, total uint64) (offset uint64, size uint64, err error) {
+	if rng == "" {
+		return 0, total, nil
+	}
+	spec := strings.TrimPrefix(rng, "bytes=")
+	start, end, ok := strings.Cut(spec, "-")
+	if !ok || spec == rng || strings.Contains(spec, ",") {
+		return 0, 0, fmt.Errorf("invalid range: %s", rng)
+	}
+	if start == "" {
+		// suffix range: the last n bytes
+		n, err := strconv.ParseUint(end, 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		if n > total {
+			n = total
+		}
+		return total - n, n, nil
+	}
+	offset, err = strconv.ParseUint(start, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	if offset >= total {
+		return 0, 0, fmt.Errorf("invalid range: %s", rng)
+	}
+	last := total - 1
+	if end != "" {
+		if last, err = strconv.ParseUint(end, 10, 64); err != nil {
+			return 0, 0, err
+		}
+		if last >= total {
+			last = total - 1
+		}
+		if last < offset {
+			return 0, 0, fmt.Errorf("invalid range: %s", rng)
+		}
+	}
+	return offset, last - offset + 1, nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, errors.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, errors.ErrSignatureMismatched), errors.Is(err, errors.ErrSignatureExpired), errors.Is(err, errors.ErrPathEscaped):
+		status = http.StatusForbidden
+	}
+	http.Error(w, err.Error(), status)
+}
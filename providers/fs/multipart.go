@@ -0,0 +1,235 @@
+package fs
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/options"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// uploadsDir the hidden dir under root which stages the parts of in-progress uploads.
+	uploadsDir = ".yadal-uploads"
+	// uploadMetaFile holds the uploadMeta of an upload.
+	uploadMetaFile = "upload.json"
+)
+
+type uploadMeta struct {
+	Path      string    `json:"path"`
+	Initiated time.Time `json:"initiated"`
+}
+
+func (d Driver) uploadPath(uploadId string) (string, error) {
+	if _, err := uuid.Parse(uploadId); err != nil {
+		return "", os.ErrNotExist
+	}
+	return d.absPath(fmt.Sprintf("%s/%s", uploadsDir, uploadId))
+}
+
+// loadUpload returns the staging dir of the upload, it fails if the upload
+// does not exist or was not created for the path.
+func (d Driver) loadUpload(path, uploadId string) (string, error) {
+	dir, err := d.uploadPath(uploadId)
+	if err != nil {
+		return "", err
+	}
+	meta, err := readUploadMeta(dir)
+	if err != nil {
+		return "", err
+	}
+	if meta.Path != path {
+		return "", os.ErrNotExist
+	}
+	return dir, nil
+}
+
+func readUploadMeta(dir string) (*uploadMeta, error) {
+	b, err := os.ReadFile(filepath.Join(dir, uploadMetaFile))
+	if err != nil {
+		return nil, err
+	}
+	meta := &uploadMeta{}
+	if err = json.Unmarshal(b, meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+func partName(partNumber uint) string {
+	return strconv.FormatUint(uint64(partNumber), 10)
+}
+
+func (d Driver) CreateMultipart(ctx context.Context, path string, args options.CreateMultipart) (string, error) {
+	uploadId := uuid.New().String()
+	dir, err := d.uploadPath(uploadId)
+	if err != nil {
+		return "", errors.ParseFsError(errors.ErrCreateMultipartFailed, err, path)
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return "", errors.ParseFsError(errors.ErrCreateMultipartFailed, err, path)
+	}
+	b, err := json.Marshal(uploadMeta{Path: path, Initiated: time.Now()})
+	if err != nil {
+		return "", errors.ParseFsError(errors.ErrCreateMultipartFailed, err, path)
+	}
+	if err = os.WriteFile(filepath.Join(dir, uploadMetaFile), b, 0644); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", errors.ParseFsError(errors.ErrCreateMultipartFailed, err, path)
+	}
+	return uploadId, nil
+}
+
+func (d Driver) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.Reader) (interfaces.ObjectPart, error) {
+	return d.writePart(path, args, reader)
+}
+
+// writePart stages the part, the reader is NOT required to be seekable.
+func (d Driver) writePart(path string, args options.WriteMultipart, reader io.Reader) (interfaces.ObjectPart, error) {
+	dir, err := d.loadUpload(path, args.UploadId)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrWriteMultipartFailed, err, path)
+	}
+	hash := md5.New()
+	err = d.writeAtomic(filepath.Join(dir, partName(args.PartNumber)), func(file *os.File) error {
+		_, err := io.Copy(io.MultiWriter(file, hash), reader)
+		return err
+	})
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrWriteMultipartFailed, err, path)
+	}
+	return object.ObjectPart{
+		PartNumber: args.PartNumber,
+		ETag:       fmt.Sprintf("\"%s\"", hex.EncodeToString(hash.Sum(nil))),
+	}, nil
+}
+
+func (d Driver) CompleteMultipart(ctx context.Context, path string, args options.CompleteMultipart) error {
+	dir, err := d.loadUpload(path, args.UploadId)
+	if err != nil {
+		return errors.ParseFsError(errors.ErrCompleteMultipartFailed, err, path)
+	}
+	p, err := d.absPath(path)
+	if err != nil {
+		return errors.ParseFsError(errors.ErrCompleteMultipartFailed, err, path)
+	}
+	if err = os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return errors.ParseFsError(errors.ErrCompleteMultipartFailed, err, path)
+	}
+	err = d.writeAtomic(p, func(file *os.File) error {
+		for _, part := range args.ObjectParts {
+			if err := appendPart(file, filepath.Join(dir, partName(part.GetPartNumber())), part.GetETag()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.ParseFsError(errors.ErrCompleteMultipartFailed, err, path)
+	}
+	if err = os.RemoveAll(dir); err != nil {
+		return errors.ParseFsError(errors.ErrCompleteMultipartFailed, err, path)
+	}
+	return nil
+}
+
+// appendPart copies the part into file, the etag is checked if it's not empty.
+func appendPart(file *os.File, part string, etag string) error {
+	src, err := os.Open(part)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+	hash := md5.New()
+	if _, err = io.Copy(io.MultiWriter(file, hash), src); err != nil {
+		return err
+	}
+	etag = strings.Trim(etag, "\"")
+	if etag != "" && etag != hex.EncodeToString(hash.Sum(nil)) {
+		return fmt.Errorf("part %s etag mismatched", filepath.Base(part))
+	}
+	return nil
+}
+
+func (d Driver) AbortMultipart(ctx context.Context, path string, args options.AbortMultipart) error {
+	dir, err := d.loadUpload(path, args.UploadId)
+	if err != nil {
+		return errors.ParseFsError(errors.ErrAbortMultipartFailed, err, path)
+	}
+	if err = os.RemoveAll(dir); err != nil {
+		return errors.ParseFsError(errors.ErrAbortMultipartFailed, err, path)
+	}
+	return nil
+}
+
+func (d Driver) ListMultipart(ctx context.Context, path string, args options.ListMultipart) ([]interfaces.MultipartUpload, error) {
+	p, err := d.absPath(uploadsDir)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrListMultipartFailed, err, path)
+	}
+	list, err := os.ReadDir(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.ParseFsError(errors.ErrListMultipartFailed, err, path)
+	}
+	var uploads []interfaces.MultipartUpload
+	for _, e := range list {
+		dir := filepath.Join(p, e.Name())
+		meta, err := readUploadMeta(dir)
+		if err != nil {
+			// skips the upload which is being created or aborted.
+			continue
+		}
+		if path != "/" && !strings.HasPrefix(meta.Path, path) || !args.Includes(meta.Initiated) {
+			continue
+		}
+		upload := object.MultipartUpload{
+			Path:      meta.Path,
+			UploadId:  e.Name(),
+			Initiated: meta.Initiated,
+		}
+		if args.WithSize {
+			size, err := partsSize(dir)
+			if err != nil {
+				return nil, errors.ParseFsError(errors.ErrListMultipartFailed, err, path)
+			}
+			upload.Size = &size
+		}
+		uploads = append(uploads, upload)
+	}
+	return uploads, nil
+}
+
+func partsSize(dir string) (uint64, error) {
+	list, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var size uint64
+	for _, e := range list {
+		if _, err := strconv.ParseUint(e.Name(), 10, 64); err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return 0, err
+		}
+		size += uint64(info.Size())
+	}
+	return size, nil
+}
@@ -0,0 +1,116 @@
+package fs
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/utils"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	XYadalExpires   = "X-Yadal-Expires"
+	XYadalSignature = "X-Yadal-Signature"
+	UploadId        = "uploadId"
+	PartNumber      = "partNumber"
+
+	defaultPreSignExpire = time.Hour
+)
+
+// preSigner signs the requests served by Handler with HMAC-SHA256.
+type preSigner struct {
+	endpoint string
+	secret   []byte
+}
+
+func (s preSigner) enabled() bool {
+	return s.endpoint != "" && len(s.secret) > 0
+}
+
+// signature signs `method\npath\nexpires\nuploadId\npartNumber`.
+func (s preSigner) signature(method, path string, query url.Values) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(strings.Join([]string{
+		method,
+		path,
+		query.Get(XYadalExpires),
+		query.Get(UploadId),
+		query.Get(PartNumber),
+	}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s preSigner) sign(method, path string, query url.Values, expire time.Duration) {
+	if expire == 0 {
+		expire = defaultPreSignExpire
+	}
+	query.Set(XYadalExpires, strconv.FormatInt(time.Now().Add(expire).Unix(), 10))
+	query.Set(XYadalSignature, s.signature(method, path, query))
+}
+
+func (s preSigner) verify(method, path string, query url.Values) error {
+	expected, err := hex.DecodeString(query.Get(XYadalSignature))
+	if err != nil {
+		return errors.ErrSignatureMismatched
+	}
+	actual, _ := hex.DecodeString(s.signature(method, path, query))
+	if !hmac.Equal(expected, actual) {
+		return errors.ErrSignatureMismatched
+	}
+	expires, err := strconv.ParseInt(query.Get(XYadalExpires), 10, 64)
+	if err != nil {
+		return errors.ErrSignatureMismatched
+	}
+	if time.Now().Unix() > expires {
+		return errors.ErrSignatureExpired
+	}
+	return nil
+}
+
+func (d Driver) PreSign(ctx context.Context, path string, args options.PreSignOptions) (*http.Request, error) {
+	if !d.preSigner.enabled() {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	query := url.Values{}
+	var method string
+	switch args.Op {
+	case options.ReadOp:
+		method = http.MethodGet
+	case options.WriteOp:
+		method = http.MethodPut
+	case options.WriteMultipartOp:
+		if args.WriteMultipart == nil {
+			return nil, errors.Wrap(errors.ErrPreSignFailed, errors.ErrUploadIdRequired)
+		}
+		method = http.MethodPut
+		query.Set(UploadId, args.UploadId)
+		query.Set(PartNumber, strconv.FormatUint(uint64(args.PartNumber), 10))
+	case options.StatOp:
+		method = http.MethodHead
+	case options.DeleteOp:
+		method = http.MethodDelete
+	default:
+		return nil, errors.Wrap(errors.ErrPreSignFailed, errors.ErrUnknownPreSignOperation)
+	}
+	d.preSigner.sign(method, path, query, args.Expire)
+
+	u := fmt.Sprintf("%s/%s?%s", strings.TrimSuffix(d.preSigner.endpoint, "/"), utils.EncodePath(path), query.Encode())
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+	}
+	if args.Op == options.ReadOp && args.ReadOptions != nil {
+		if (args.Offset != nil && *args.Offset != 0) || args.ReadOptions.Size != nil {
+			req.Header.Set("range", options.NewBytesRange(args.Offset, args.ReadOptions.Size).String())
+		}
+	}
+	return req, nil
+}
@@ -0,0 +1,142 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/options"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func setupPreSign(t *testing.T) (*Driver, *httptest.Server) {
+	root, err := os.MkdirTemp(".", "presign-")
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(root)
+	})
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	opt := Options{
+		Root:            root,
+		PreSignEndpoint: server.URL + "/objects/",
+		PreSignSecret:   []byte("secret"),
+	}
+	h, err := NewHandler(opt)
+	assert.Nil(t, err)
+	mux.Handle("/objects/", h)
+	return newDriver(opt), server
+}
+
+func do(t *testing.T, req *http.Request, body []byte) *http.Response {
+	if body != nil {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	return resp
+}
+
+func TestPreSign(t *testing.T) {
+	d, _ := setupPreSign(t)
+	ctx := context.TODO()
+	content := []byte("Hello,World!")
+
+	req, err := d.PreSign(ctx, "dir/test file", options.PreSignOptions{Op: options.WriteOp, Expire: time.Minute})
+	assert.Nil(t, err)
+	resp := do(t, req, content)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	offset, size := uint64(3), uint64(5)
+	req, err = d.PreSign(ctx, "dir/test file", options.PreSignOptions{
+		Op:          options.ReadOp,
+		ReadOptions: &options.ReadOptions{Offset: &offset, Size: &size},
+		Expire:      time.Minute,
+	})
+	assert.Nil(t, err)
+	resp = do(t, req, nil)
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	b, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "lo,Wo", string(b))
+
+	// tampered path
+	req.URL.Path = "/objects/dir/other"
+	resp = do(t, req, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// expired
+	req, err = d.PreSign(ctx, "dir/test file", options.PreSignOptions{Op: options.ReadOp, Expire: -time.Minute})
+	assert.Nil(t, err)
+	resp = do(t, req, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// not found
+	req, err = d.PreSign(ctx, "not-exist", options.PreSignOptions{Op: options.ReadOp})
+	assert.Nil(t, err)
+	resp = do(t, req, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestPreSign_writeMultipart(t *testing.T) {
+	d, _ := setupPreSign(t)
+	ctx := context.TODO()
+
+	uploadId, err := d.CreateMultipart(ctx, "multipart", options.CreateMultipart{})
+	assert.Nil(t, err)
+
+	var parts []options.ObjectPart
+	for i, content := range []string{"Hello,", "World!"} {
+		req, err := d.PreSign(ctx, "multipart", options.PreSignOptions{
+			Op:             options.WriteMultipartOp,
+			WriteMultipart: &options.WriteMultipart{UploadId: uploadId, PartNumber: uint(i + 1)},
+		})
+		assert.Nil(t, err)
+		resp := do(t, req, []byte(content))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		parts = append(parts, object.ObjectPart{PartNumber: uint(i + 1), ETag: resp.Header.Get("etag")})
+	}
+
+	err = d.CompleteMultipart(ctx, "multipart", options.CompleteMultipart{UploadId: uploadId, ObjectParts: parts})
+	assert.Nil(t, err)
+
+	reader, err := d.Read(ctx, "multipart", options.ReadOptions{})
+	assert.Nil(t, err)
+	b, _ := io.ReadAll(reader)
+	_ = reader.Close()
+	assert.Equal(t, "Hello,World!", string(b))
+}
+
+func TestPreSign_statDelete(t *testing.T) {
+	d, _ := setupPreSign(t)
+	ctx := context.TODO()
+	content := []byte("Hello,World!")
+	_, err := d.Write(ctx, "test", options.WriteOptions{Size: uint64(len(content))}, bytes.NewReader(content))
+	assert.Nil(t, err)
+
+	req, err := d.PreSign(ctx, "test", options.PreSignOptions{Op: options.StatOp, Expire: time.Minute})
+	assert.Nil(t, err)
+	assert.Equal(t, http.MethodHead, req.Method)
+	resp := do(t, req, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int64(len(content)), resp.ContentLength)
+
+	req, err = d.PreSign(ctx, "test", options.PreSignOptions{Op: options.DeleteOp, Expire: time.Minute})
+	assert.Nil(t, err)
+	resp = do(t, req, nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	_, err = d.Stat(ctx, "test", options.StatOptions{})
+	assert.NotNil(t, err)
+
+	_, err = d.PreSign(ctx, "test", options.PreSignOptions{Op: options.CompleteMultipartOp})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,69 @@
+package fs
+
+import (
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/utils"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// absPath builds the path on disk, it rejects the path escaping from root,
+// including via symlinks if Options.NoSymlinkEscape is set.
+func (d Driver) absPath(path string) (string, error) {
+	p, err := utils.BuildAbsPath(d.root, path)
+	if err != nil {
+		return "", err
+	}
+	if d.noSymlinkEscape {
+		if err = d.checkSymlinks(p); err != nil {
+			return "", err
+		}
+	}
+	return p, nil
+}
+
+// checkSymlinks returns errors.ErrPathEscaped if p, or its deepest existing ancestor,
+// resolves to somewhere outside the root.
+func (d Driver) checkSymlinks(p string) error {
+	root, err := resolve(d.root[1:])
+	if err != nil {
+		return err
+	}
+	target, err := resolve(p)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return errors.ErrPathEscaped
+	}
+	return nil
+}
+
+// resolve evaluates symlinks of p, the non-existing trailing elements are kept as is.
+func resolve(p string) (string, error) {
+	p, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	var rest []string
+	for {
+		resolved, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return "", err
+		}
+		rest = append([]string{filepath.Base(p)}, rest...)
+		p = parent
+	}
+}
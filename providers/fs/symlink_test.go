@@ -0,0 +1,51 @@
+package fs
+
+import (
+	"context"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/options"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNoSymlinkEscape(t *testing.T) {
+	root, err := os.MkdirTemp(".", "symlink-")
+	assert.Nil(t, err)
+	outside, err := os.MkdirTemp(".", "outside-")
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(root)
+		_ = os.RemoveAll(outside)
+	})
+	assert.Nil(t, os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0644))
+	abs, err := filepath.Abs(outside)
+	assert.Nil(t, err)
+	assert.Nil(t, os.Symlink(abs, filepath.Join(root, "link")))
+	assert.Nil(t, os.Mkdir(filepath.Join(root, "dir"), 0755))
+	assert.Nil(t, os.Symlink("dir", filepath.Join(root, "inner")))
+
+	ctx := context.TODO()
+	d := NewDriver(Options{Root: root, NoSymlinkEscape: true})
+
+	_, err = d.Read(ctx, "link/secret", options.ReadOptions{})
+	assert.True(t, errors.Is(err, errors.ErrPathEscaped))
+
+	_, err = d.Write(ctx, "link/new-file", options.WriteOptions{}, nil)
+	assert.True(t, errors.Is(err, errors.ErrPathEscaped))
+
+	_, err = d.Read(ctx, "../"+outside+"/secret", options.ReadOptions{})
+	assert.True(t, errors.Is(err, errors.ErrPathEscaped))
+
+	// symlinks inside the root are followed
+	err = d.Create(ctx, "inner/file", options.CreateOptions{Mode: 1})
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(root, "dir", "file"))
+	assert.Nil(t, err)
+
+	// symlinks are followed without the option
+	d = NewDriver(Options{Root: root})
+	_, err = d.Read(ctx, "link/secret", options.ReadOptions{})
+	assert.Nil(t, err)
+}
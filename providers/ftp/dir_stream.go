@@ -0,0 +1,75 @@
+package ftp
+
+import (
+	"context"
+	"github.com/jlaffaye/ftp"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/object"
+)
+
+// DirStream lists the dir by `MLSD`, or by `LIST` if the server doesn't support it,
+// the entries are returned in a single page.
+type DirStream struct {
+	*Driver
+	root string
+	path string
+
+	done bool
+}
+
+func (d *DirStream) NextPage(ctx context.Context) ([]interfaces.Entry, error) {
+	if d.done {
+		return nil, nil
+	}
+	p, err := d.absPath(d.path)
+	if err != nil {
+		return nil, errors.ParseFtpError(errors.ErrListFailed, err, d.path)
+	}
+	var list []*ftp.Entry
+	err = d.withConn(ctx, func(c *ftp.ServerConn) (err error) {
+		list, err = c.List(p)
+		return
+	})
+	if err != nil {
+		return nil, errors.ParseFtpError(errors.ErrListFailed, err, d.path)
+	}
+	d.done = true
+
+	prefix := d.path
+	if prefix == "/" {
+		prefix = ""
+	}
+	entries := make([]interfaces.Entry, 0, len(list))
+	for _, entry := range list {
+		if entry.Name == "." || entry.Name == ".." {
+			continue
+		}
+		meta, err := object.NewMetadata(object.SetFromFileInfo(fileInfo{entry}))
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrListFailed, err)
+		}
+		isDir := entry.Type == ftp.EntryTypeFolder
+		path := prefix + entry.Name
+		if isDir {
+			path += "/"
+		}
+		entries = append(entries,
+			object.NewEntry(
+				d.Driver,
+				path,
+				meta,
+				!isDir),
+		)
+	}
+	return entries, nil
+}
+
+func NewDirStream(d *Driver, root, path string) interfaces.ObjectPageStream {
+	return &DirStream{
+		Driver: d,
+		root:   root,
+		path:   path,
+		done:   false,
+	}
+}
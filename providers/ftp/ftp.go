@@ -0,0 +1,413 @@
+package ftp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/jlaffaye/ftp"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/logger"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/providers"
+	"github.com/senrok/yadal/utils"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/textproto"
+	pathpkg "path"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxConns = 4
+	defaultTimeout  = 30 * time.Second
+)
+
+type Driver struct {
+	endpoint string
+	root     string
+	pool     *pool
+	logger.Logger
+}
+
+func (d *Driver) Metadata() interfaces.Metadata {
+	return providers.NewMetadata(interfaces.Ftp, d.root, d.endpoint, interfaces.Read|interfaces.Write|interfaces.List)
+}
+
+// absPath returns the absolute path on the server without the trailing slash, e.g. `/path/to/root/dir`.
+func (d *Driver) absPath(path string) (string, error) {
+	p, err := utils.BuildAbsPath(d.root, path)
+	if err != nil {
+		return "", err
+	}
+	return "/" + strings.TrimSuffix(p, "/"), nil
+}
+
+// withConn runs fn with a connection of the pool.
+func (d *Driver) withConn(ctx context.Context, fn func(c *ftp.ServerConn) error) error {
+	c, err := d.pool.get(ctx)
+	if err != nil {
+		return err
+	}
+	err = fn(c)
+	d.pool.put(c, err)
+	return err
+}
+
+// isCode returns true if the error is replied by the server with the code.
+func isCode(err error, code int) bool {
+	var protoErr *textproto.Error
+	return errors.As(err, &protoErr) && protoErr.Code == code
+}
+
+// mkdirAll creates the dir and its parents by `MKD`, the existing dirs are refused with 550,
+// so that the dir is stat-ed at last if it's refused.
+func mkdirAll(c *ftp.ServerConn, p string) error {
+	if p == "/" {
+		return nil
+	}
+	var err error
+	dir := ""
+	for _, name := range strings.Split(strings.TrimPrefix(p, "/"), "/") {
+		dir += "/" + name
+		if err = c.MakeDir(dir); err != nil && !isCode(err, ftp.StatusFileUnavailable) {
+			return err
+		}
+	}
+	if err == nil {
+		return nil
+	}
+	entry, err := stat(c, p)
+	if err != nil {
+		return err
+	}
+	if entry.Type != ftp.EntryTypeFolder {
+		return fmt.Errorf("%s is not a directory", p)
+	}
+	return nil
+}
+
+// stat returns the entry by `MLST`, or finds it in the `LIST` of the parent if `MLST` is not supported.
+func stat(c *ftp.ServerConn, p string) (*ftp.Entry, error) {
+	entry, err := c.GetEntry(p)
+	if err == nil || !isCode(err, ftp.StatusNotImplemented) {
+		return entry, err
+	}
+	entries, err := c.List(pathpkg.Dir(p))
+	if err != nil {
+		return nil, err
+	}
+	name := pathpkg.Base(p)
+	for _, entry := range entries {
+		if entry.Name == name {
+			return entry, nil
+		}
+	}
+	return nil, &textproto.Error{Code: ftp.StatusFileUnavailable, Msg: "No such file or directory"}
+}
+
+func (d *Driver) Create(ctx context.Context, path string, args options.CreateOptions) error {
+	p, err := d.absPath(path)
+	if err != nil {
+		return errors.ParseFtpError(errors.ErrCreateFailed, err, path)
+	}
+	err = d.withConn(ctx, func(c *ftp.ServerConn) error {
+		if interfaces.ObjectMode(args.Mode) == interfaces.DIR {
+			return mkdirAll(c, p)
+		}
+		if err := mkdirAll(c, pathpkg.Dir(p)); err != nil {
+			return err
+		}
+		return c.Stor(p, bytes.NewReader(nil))
+	})
+	if err != nil {
+		return errors.ParseFtpError(errors.ErrCreateFailed, err, path)
+	}
+	return nil
+}
+
+// reader streams the remote file over the data connection, the connection is returned to the pool once it's closed.
+type reader struct {
+	io.Reader
+	resp    *ftp.Response
+	release func(err error)
+	eof     bool
+	closed  bool
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		r.eof = true
+	}
+	return n, err
+}
+
+func (r *reader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	err := r.resp.Close()
+	r.release(err)
+	// the server replies the transfer aborted if the data connection is closed early
+	var protoErr *textproto.Error
+	if !r.eof && errors.As(err, &protoErr) {
+		return nil
+	}
+	return err
+}
+
+// Read retrieves the file by `RETR`, the offset is sent by `REST` in advance.
+func (d *Driver) Read(ctx context.Context, path string, args options.ReadOptions) (io.ReadCloser, error) {
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	p, err := d.absPath(path)
+	if err != nil {
+		return nil, errors.ParseFtpError(errors.ErrReadFailed, err, path)
+	}
+	c, err := d.pool.get(ctx)
+	if err != nil {
+		return nil, errors.ParseFtpError(errors.ErrReadFailed, err, path)
+	}
+	var offset uint64
+	if args.Offset != nil {
+		offset = *args.Offset
+	}
+	resp, err := c.RetrFrom(p, offset)
+	if err != nil {
+		d.pool.put(c, err)
+		return nil, errors.ParseFtpError(errors.ErrReadFailed, err, path)
+	}
+	r := &reader{
+		Reader: resp,
+		resp:   resp,
+		release: func(err error) {
+			d.pool.put(c, err)
+		},
+	}
+	if args.Size != nil {
+		r.Reader = io.LimitReader(resp, int64(*args.Size))
+	}
+	return r, nil
+}
+
+// counter counts the bytes read.
+type counter struct {
+	io.Reader
+	n uint64
+}
+
+func (c *counter) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += uint64(n)
+	return n, err
+}
+
+// Write stores the file by `STOR`, the parents are created in advance.
+func (d *Driver) Write(ctx context.Context, path string, args options.WriteOptions, reader io.Reader) (interfaces.WriteResult, error) {
+	p, err := d.absPath(path)
+	if err != nil {
+		return nil, errors.ParseFtpError(errors.ErrWriteFailed, err, path)
+	}
+	body := &counter{Reader: reader}
+	err = d.withConn(ctx, func(c *ftp.ServerConn) error {
+		if err := mkdirAll(c, pathpkg.Dir(p)); err != nil {
+			return err
+		}
+		return c.Stor(p, body)
+	})
+	if err != nil {
+		return nil, errors.ParseFtpError(errors.ErrWriteFailed, err, path)
+	}
+	return object.WriteResult{Size: body.n}, nil
+}
+
+// fileInfo adapts the entry to fs.FileInfo.
+type fileInfo struct {
+	*ftp.Entry
+}
+
+func (f fileInfo) Name() string {
+	return f.Entry.Name
+}
+
+func (f fileInfo) Size() int64 {
+	return int64(f.Entry.Size)
+}
+
+func (f fileInfo) Mode() fs.FileMode {
+	switch f.Type {
+	case ftp.EntryTypeFolder:
+		return fs.ModeDir
+	case ftp.EntryTypeLink:
+		return fs.ModeSymlink
+	}
+	return 0
+}
+
+func (f fileInfo) ModTime() time.Time {
+	return f.Time
+}
+
+func (f fileInfo) IsDir() bool {
+	return f.Type == ftp.EntryTypeFolder
+}
+
+func (f fileInfo) Sys() any {
+	return f.Entry
+}
+
+func (d *Driver) Stat(ctx context.Context, path string, args options.StatOptions) (interfaces.ObjectMetadata, error) {
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	p, err := d.absPath(path)
+	if err != nil {
+		return nil, errors.ParseFtpError(errors.ErrStatFailed, err, path)
+	}
+	if p == "/" {
+		return object.NewMetadata(object.SetMode(interfaces.DIR))
+	}
+	var entry *ftp.Entry
+	err = d.withConn(ctx, func(c *ftp.ServerConn) (err error) {
+		entry, err = stat(c, p)
+		return
+	})
+	if err != nil {
+		return nil, errors.ParseFtpError(errors.ErrStatFailed, err, path)
+	}
+	return object.NewMetadata(object.SetFromFileInfo(fileInfo{entry}))
+}
+
+// Delete removes the file, or the dir with all of its children.
+func (d *Driver) Delete(ctx context.Context, path string, args options.DeleteOptions) error {
+	if args.VersionId != "" {
+		return errors.ErrUnsupportedMethod
+	}
+	p, err := d.absPath(path)
+	if err != nil {
+		return errors.ParseFtpError(errors.ErrDeleteFailed, err, path)
+	}
+	err = d.withConn(ctx, func(c *ftp.ServerConn) error {
+		entry, err := stat(c, p)
+		if err != nil {
+			return err
+		}
+		if entry.Type == ftp.EntryTypeFolder {
+			return c.RemoveDirRecur(p)
+		}
+		return c.Delete(p)
+	})
+	if err != nil && !isCode(err, ftp.StatusFileUnavailable) {
+		return errors.ParseFtpError(errors.ErrDeleteFailed, err, path)
+	}
+	return nil
+}
+
+func (d *Driver) List(ctx context.Context, path string, args options.ListOptions) (interfaces.ObjectStream, error) {
+	return object.NewObjectStream(NewDirStream(d, d.root, path)), nil
+}
+
+func (d *Driver) ListVersions(ctx context.Context, path string, args options.ListVersions) (interfaces.ObjectStream, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) PreSign(ctx context.Context, path string, args options.PreSignOptions) (*http.Request, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) GetTags(ctx context.Context, path string, args options.GetTags) (map[string]string, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) SetTags(ctx context.Context, path string, args options.SetTags) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) CreateMultipart(ctx context.Context, path string, args options.CreateMultipart) (string, error) {
+	return "", errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.Reader) (interfaces.ObjectPart, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) CompleteMultipart(ctx context.Context, path string, args options.CompleteMultipart) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) AbortMultipart(ctx context.Context, path string, args options.AbortMultipart) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) ListMultipart(ctx context.Context, path string, args options.ListMultipart) ([]interfaces.MultipartUpload, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+// NewDriver returns a driver of the FTP server in the passive mode, the connections are dialed on demand and reused.
+func NewDriver(ctx context.Context, opt Options) (interfaces.Accessor, error) {
+	if opt.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required")
+	}
+	if opt.ExplicitTLS && opt.ImplicitTLS {
+		return nil, fmt.Errorf("either explicit or implicit TLS is allowed")
+	}
+	endpoint := opt.Endpoint
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		host = endpoint
+		port := "21"
+		if opt.ImplicitTLS {
+			port = "990"
+		}
+		endpoint = net.JoinHostPort(endpoint, port)
+	}
+	timeout := opt.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	maxConns := opt.MaxConns
+	if maxConns <= 0 {
+		maxConns = defaultMaxConns
+	}
+	tlsConfig := opt.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: host}
+	}
+
+	dial := func(ctx context.Context) (*ftp.ServerConn, error) {
+		dialOptions := []ftp.DialOption{
+			ftp.DialWithContext(ctx),
+			ftp.DialWithTimeout(timeout),
+			ftp.DialWithDisabledEPSV(opt.DisableEPSV),
+			ftp.DialWithDisabledMLSD(opt.DisableMLSD),
+		}
+		if opt.ExplicitTLS {
+			dialOptions = append(dialOptions, ftp.DialWithExplicitTLS(tlsConfig))
+		} else if opt.ImplicitTLS {
+			dialOptions = append(dialOptions, ftp.DialWithTLS(tlsConfig))
+		}
+		c, err := ftp.Dial(endpoint, dialOptions...)
+		if err != nil {
+			return nil, err
+		}
+		if err = c.Login(opt.User, opt.Password); err != nil {
+			_ = c.Quit()
+			return nil, err
+		}
+		return c, nil
+	}
+
+	return &Driver{
+		endpoint: endpoint,
+		root:     utils.NormalizeRoot(opt.Root),
+		pool:     newPool(maxConns, dial),
+	}, nil
+}
@@ -0,0 +1,208 @@
+package ftp
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/providers/ftp/ftptest"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newServer(t *testing.T, opt ftptest.Options) (*ftptest.Server, string) {
+	opt.Root = t.TempDir()
+	opt.User, opt.Password = "user", "pass"
+	server, err := ftptest.NewServer(opt)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+	return server, opt.Root
+}
+
+func setupDriver(t *testing.T, opt Options) *Driver {
+	if opt.User == "" {
+		opt.User, opt.Password = "user", "pass"
+	}
+	d, err := NewDriver(context.Background(), opt)
+	assert.Nil(t, err)
+	return d.(*Driver)
+}
+
+func testDriver(t *testing.T, d *Driver, root string) {
+	ctx := context.Background()
+	assert.Equal(t, interfaces.Ftp, d.Metadata().Provider())
+	assert.True(t, d.Metadata().Capability().Has(interfaces.Read, interfaces.Write, interfaces.List))
+
+	content := []byte("Hello,World!")
+	result, err := d.Write(ctx, "dir/sub/a b.txt", options.WriteOptions{Size: uint64(len(content))}, bytes.NewReader(content))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(len(content)), result.GetSize())
+	b, err := os.ReadFile(filepath.Join(root, "data/dir/sub/a b.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, content, b)
+
+	// overwrites the existing file
+	content = []byte("Hello,FTP!")
+	_, err = d.Write(ctx, "dir/sub/a b.txt", options.WriteOptions{Size: uint64(len(content))}, bytes.NewReader(content))
+	assert.Nil(t, err)
+
+	meta, err := d.Stat(ctx, "dir/sub/a b.txt", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.FILE, meta.Mode())
+	assert.Equal(t, uint64(len(content)), *meta.ContentLength())
+
+	offset, size := uint64(6), uint64(3)
+	reader, err := d.Read(ctx, "dir/sub/a b.txt", options.ReadOptions{Offset: &offset, Size: &size})
+	assert.Nil(t, err)
+	b, _ = io.ReadAll(reader)
+	assert.Nil(t, reader.Close())
+	assert.Equal(t, "FTP", string(b))
+
+	// the connection is reused after the transfer closed early
+	size = 1
+	for i := 0; i < defaultMaxConns+1; i++ {
+		reader, err = d.Read(ctx, "dir/sub/a b.txt", options.ReadOptions{Size: &size})
+		assert.Nil(t, err)
+		b, _ = io.ReadAll(reader)
+		assert.Nil(t, reader.Close())
+		assert.Equal(t, "H", string(b))
+	}
+
+	meta, err = d.Stat(ctx, "dir/", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.DIR, meta.Mode())
+	meta, err = d.Stat(ctx, "/", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.DIR, meta.Mode())
+
+	assert.Nil(t, d.Create(ctx, "empty/", options.CreateOptions{Mode: int8(interfaces.DIR)}))
+	assert.Nil(t, d.Create(ctx, "empty/", options.CreateOptions{Mode: int8(interfaces.DIR)}))
+	assert.Nil(t, d.Create(ctx, "file", options.CreateOptions{Mode: int8(interfaces.FILE)}))
+	meta, err = d.Stat(ctx, "file", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), *meta.ContentLength())
+	// a file is not a dir
+	assert.NotNil(t, d.Create(ctx, "file/", options.CreateOptions{Mode: int8(interfaces.DIR)}))
+
+	_, err = d.Stat(ctx, "not-exist", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+	_, err = d.Read(ctx, "not-exist", options.ReadOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+	// the children are deleted with the dir
+	assert.Nil(t, d.Delete(ctx, "dir/", options.DeleteOptions{}))
+	assert.Nil(t, d.Delete(ctx, "dir/", options.DeleteOptions{}))
+	_, err = os.Stat(filepath.Join(root, "data/dir"))
+	assert.True(t, os.IsNotExist(err))
+	assert.Nil(t, d.Delete(ctx, "file", options.DeleteOptions{}))
+	_, err = os.Stat(filepath.Join(root, "data/file"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDriver(t *testing.T) {
+	server, root := newServer(t, ftptest.Options{})
+	testDriver(t, setupDriver(t, Options{Endpoint: server.Addr, Root: "/data/"}), root)
+}
+
+func TestDriverWithoutMLST(t *testing.T) {
+	server, root := newServer(t, ftptest.Options{DisableMLST: true})
+	testDriver(t, setupDriver(t, Options{Endpoint: server.Addr, Root: "/data/"}), root)
+
+	server, root = newServer(t, ftptest.Options{})
+	testDriver(t, setupDriver(t, Options{Endpoint: server.Addr, Root: "/data/", DisableMLSD: true, DisableEPSV: true}), root)
+}
+
+// selfSignedConfig returns the server and client configs trusting a certificate of 127.0.0.1.
+func selfSignedConfig(t *testing.T) (*tls.Config, *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}},
+		&tls.Config{RootCAs: pool, ServerName: "127.0.0.1"}
+}
+
+func TestExplicitTLS(t *testing.T) {
+	serverConfig, clientConfig := selfSignedConfig(t)
+	server, root := newServer(t, ftptest.Options{TLSConfig: serverConfig})
+	testDriver(t, setupDriver(t, Options{Endpoint: server.Addr, Root: "/data/", ExplicitTLS: true, TLSConfig: clientConfig}), root)
+
+	// the certificate is not trusted
+	d := setupDriver(t, Options{Endpoint: server.Addr, Root: "/data/", ExplicitTLS: true})
+	_, err := d.Stat(context.Background(), "a", options.StatOptions{})
+	assert.NotNil(t, err)
+}
+
+func TestList(t *testing.T) {
+	for _, opt := range []ftptest.Options{{}, {DisableMLST: true}} {
+		server, _ := newServer(t, opt)
+		d := setupDriver(t, Options{Endpoint: server.Addr, Root: "/data/"})
+		ctx := context.Background()
+
+		for _, path := range []string{"dir/a", "dir/b c", "dir/sub/d"} {
+			_, err := d.Write(ctx, path, options.WriteOptions{Size: 1}, bytes.NewReader([]byte("x")))
+			assert.Nil(t, err)
+		}
+
+		for path, expected := range map[string]map[string]interfaces.ObjectMode{
+			"dir/": {
+				"dir/a":    interfaces.FILE,
+				"dir/b c":  interfaces.FILE,
+				"dir/sub/": interfaces.DIR,
+			},
+			"/": {
+				"dir/": interfaces.DIR,
+			},
+		} {
+			stream, err := d.List(ctx, path, options.ListOptions{})
+			assert.Nil(t, err)
+			entries := map[string]interfaces.ObjectMode{}
+			for stream.HasNext() {
+				entry, err := stream.Next(ctx)
+				assert.Nil(t, err)
+				entries[entry.Path()] = entry.Metadata().Mode()
+			}
+			assert.Equal(t, expected, entries)
+		}
+
+		_, err := NewDirStream(d, d.root, "not-exist/").NextPage(ctx)
+		assert.True(t, errors.Is(err, errors.ErrNotFound))
+	}
+}
+
+func TestAuth(t *testing.T) {
+	server, _ := newServer(t, ftptest.Options{})
+	d := setupDriver(t, Options{Endpoint: server.Addr, User: "user", Password: "wrong"})
+	_, err := d.Stat(context.Background(), "a", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrPermissionDenied))
+	// the failed dial frees the slot of the pool
+	assert.Len(t, d.pool.slots, 0)
+}
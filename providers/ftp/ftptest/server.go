@@ -0,0 +1,462 @@
+// Package ftptest provides an in-process FTP server for testing.
+package ftptest
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Options struct {
+	// Root the local dir served
+	Root     string
+	User     string
+	Password string
+	// TLSConfig enables `AUTH TLS` of the explicit FTPS if it's set.
+	TLSConfig *tls.Config
+	// DisableMLST hides `MLST` from the features, the clients fall back to `LIST`.
+	DisableMLST bool
+}
+
+// Server serves the local dir over FTP on a random port of 127.0.0.1, only the passive mode is supported.
+type Server struct {
+	// Addr the address like `127.0.0.1:port`
+	Addr string
+
+	opt      Options
+	listener net.Listener
+	wg       sync.WaitGroup
+}
+
+func NewServer(opt Options) (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		Addr:     listener.Addr().String(),
+		opt:      opt,
+		listener: listener,
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Close stops accepting connections, the accepted ones are closed by the clients.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go newSession(s, conn).run()
+	}
+}
+
+// session the state of a control connection.
+type session struct {
+	*Server
+	conn   net.Conn
+	reader *bufio.Reader
+
+	user     string
+	loggedIn bool
+	cwd      string
+	offset   int64
+	rename   string
+	// protected the data connections are over TLS.
+	protected bool
+	passive   net.Listener
+}
+
+func newSession(s *Server, conn net.Conn) *session {
+	return &session{Server: s, conn: conn, reader: bufio.NewReader(conn), cwd: "/"}
+}
+
+func (s *session) reply(code int, format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(s.conn, "%d %s\r\n", code, fmt.Sprintf(format, args...))
+}
+
+func (s *session) run() {
+	defer func() {
+		_ = s.conn.Close()
+		if s.passive != nil {
+			_ = s.passive.Close()
+		}
+	}()
+	s.reply(220, "ready")
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command, arg := line, ""
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			command, arg = line[:i], line[i+1:]
+		}
+		command = strings.ToUpper(command)
+		if command == "QUIT" {
+			s.reply(221, "bye")
+			return
+		}
+		s.handle(command, arg)
+	}
+}
+
+// localPath returns the local path of the arg resolved against the working dir, it never escapes the root.
+func (s *session) localPath(arg string) (string, string) {
+	p := arg
+	if !strings.HasPrefix(p, "/") {
+		p = pathpkg.Join(s.cwd, p)
+	}
+	p = pathpkg.Clean("/" + p)
+	return p, filepath.Join(s.opt.Root, filepath.FromSlash(p))
+}
+
+func (s *session) handle(command, arg string) {
+	switch command {
+	case "USER":
+		s.user = arg
+		s.reply(331, "password required")
+		return
+	case "PASS":
+		if s.user != s.opt.User || arg != s.opt.Password {
+			s.reply(530, "login incorrect")
+			return
+		}
+		s.loggedIn = true
+		s.reply(230, "logged in")
+		return
+	case "AUTH":
+		if s.opt.TLSConfig == nil || strings.ToUpper(arg) != "TLS" {
+			s.reply(502, "not implemented")
+			return
+		}
+		s.reply(234, "AUTH TLS successful")
+		tlsConn := tls.Server(s.conn, s.opt.TLSConfig)
+		s.conn, s.reader = tlsConn, bufio.NewReader(tlsConn)
+		return
+	case "FEAT":
+		features := []string{"UTF8", "SIZE", "MDTM", "REST STREAM", "EPSV"}
+		if !s.opt.DisableMLST {
+			features = append(features, "MLST type*;size*;modify*;")
+		}
+		if s.opt.TLSConfig != nil {
+			features = append(features, "AUTH TLS", "PBSZ", "PROT")
+		}
+		_, _ = fmt.Fprintf(s.conn, "211-Features:\r\n %s\r\n211 End\r\n", strings.Join(features, "\r\n "))
+		return
+	case "NOOP":
+		s.reply(200, "ok")
+		return
+	}
+	if !s.loggedIn {
+		s.reply(530, "not logged in")
+		return
+	}
+
+	switch command {
+	case "PBSZ", "TYPE", "OPTS", "MODE", "STRU":
+		s.reply(200, "ok")
+	case "PROT":
+		s.protected = strings.ToUpper(arg) == "P"
+		s.reply(200, "ok")
+	case "SYST":
+		s.reply(215, "UNIX Type: L8")
+	case "PWD":
+		s.reply(257, "%q is the current directory", s.cwd)
+	case "CWD", "CDUP":
+		if command == "CDUP" {
+			arg = ".."
+		}
+		p, local := s.localPath(arg)
+		if info, err := os.Stat(local); err != nil || !info.IsDir() {
+			s.reply(550, "no such directory")
+			return
+		}
+		s.cwd = p
+		s.reply(250, "directory changed to %s", p)
+	case "PASV", "EPSV":
+		s.openPassive(command)
+	case "REST":
+		offset, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || offset < 0 {
+			s.reply(501, "invalid offset")
+			return
+		}
+		s.offset = offset
+		s.reply(350, "restarting at %d", offset)
+	case "RETR":
+		s.retrieve(arg)
+	case "STOR":
+		s.store(arg)
+	case "LIST", "NLST", "MLSD":
+		s.list(command, arg)
+	case "MLST":
+		if s.opt.DisableMLST {
+			s.reply(502, "not implemented")
+			return
+		}
+		p, local := s.localPath(arg)
+		info, err := os.Stat(local)
+		if err != nil {
+			s.reply(550, "no such file or directory")
+			return
+		}
+		_, _ = fmt.Fprintf(s.conn, "250-File details\r\n %s\r\n250 End\r\n", facts(info, p))
+	case "SIZE":
+		_, local := s.localPath(arg)
+		info, err := os.Stat(local)
+		if err != nil || info.IsDir() {
+			s.reply(550, "no such file")
+			return
+		}
+		s.reply(213, "%d", info.Size())
+	case "MDTM":
+		_, local := s.localPath(arg)
+		info, err := os.Stat(local)
+		if err != nil {
+			s.reply(550, "no such file")
+			return
+		}
+		s.reply(213, "%s", info.ModTime().UTC().Format("20060102150405"))
+	case "MKD":
+		p, local := s.localPath(arg)
+		if err := os.Mkdir(local, 0755); err != nil {
+			s.reply(550, "failed to create directory")
+			return
+		}
+		s.reply(257, "%q created", p)
+	case "RMD":
+		_, local := s.localPath(arg)
+		if info, err := os.Stat(local); err != nil || !info.IsDir() || os.Remove(local) != nil {
+			s.reply(550, "failed to remove directory")
+			return
+		}
+		s.reply(250, "directory removed")
+	case "DELE":
+		_, local := s.localPath(arg)
+		if info, err := os.Stat(local); err != nil || info.IsDir() || os.Remove(local) != nil {
+			s.reply(550, "failed to delete file")
+			return
+		}
+		s.reply(250, "file deleted")
+	case "RNFR":
+		_, local := s.localPath(arg)
+		if _, err := os.Stat(local); err != nil {
+			s.reply(550, "no such file or directory")
+			return
+		}
+		s.rename = local
+		s.reply(350, "ready for RNTO")
+	case "RNTO":
+		_, local := s.localPath(arg)
+		if s.rename == "" || os.Rename(s.rename, local) != nil {
+			s.reply(550, "failed to rename")
+			return
+		}
+		s.rename = ""
+		s.reply(250, "renamed")
+	default:
+		s.reply(502, "not implemented")
+	}
+}
+
+func (s *session) openPassive(command string) {
+	if s.passive != nil {
+		_ = s.passive.Close()
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		s.reply(425, "can't open data connection")
+		return
+	}
+	s.passive = listener
+	port := listener.Addr().(*net.TCPAddr).Port
+	if command == "EPSV" {
+		s.reply(229, "Entering Extended Passive Mode (|||%d|)", port)
+		return
+	}
+	s.reply(227, "Entering Passive Mode (127,0,0,1,%d,%d)", port>>8, port&0xff)
+}
+
+// dataConn accepts the data connection of the last PASV or EPSV.
+func (s *session) dataConn() (net.Conn, error) {
+	if s.passive == nil {
+		return nil, fmt.Errorf("no passive listener")
+	}
+	listener := s.passive
+	s.passive = nil
+	defer listener.Close()
+	if l, ok := listener.(*net.TCPListener); ok {
+		_ = l.SetDeadline(time.Now().Add(10 * time.Second))
+	}
+	conn, err := listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if s.protected {
+		return tls.Server(conn, s.opt.TLSConfig), nil
+	}
+	return conn, nil
+}
+
+// transfer runs fn on the data connection, the status is replied once the data connection is closed.
+func (s *session) transfer(fn func(conn net.Conn) error) {
+	conn, err := s.dataConn()
+	if err != nil {
+		s.reply(425, "can't open data connection")
+		return
+	}
+	s.reply(150, "opening data connection")
+	err = fn(conn)
+	if closeErr := conn.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		s.reply(426, "connection closed; transfer aborted")
+		return
+	}
+	s.reply(226, "transfer complete")
+}
+
+func (s *session) retrieve(arg string) {
+	offset := s.offset
+	s.offset = 0
+	_, local := s.localPath(arg)
+	file, err := os.Open(local)
+	if err != nil {
+		s.reply(550, "no such file")
+		return
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err != nil || info.IsDir() {
+		s.reply(550, "not a plain file")
+		return
+	}
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		s.reply(550, "failed to seek")
+		return
+	}
+	s.transfer(func(conn net.Conn) error {
+		_, err := io.Copy(conn, file)
+		return err
+	})
+}
+
+func (s *session) store(arg string) {
+	offset := s.offset
+	s.offset = 0
+	_, local := s.localPath(arg)
+	flag := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flag |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(local, flag, 0644)
+	if err != nil {
+		s.reply(550, "failed to open file")
+		return
+	}
+	defer file.Close()
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		s.reply(550, "failed to seek")
+		return
+	}
+	s.transfer(func(conn net.Conn) error {
+		_, err := io.Copy(file, conn)
+		return err
+	})
+}
+
+func (s *session) list(command, arg string) {
+	// the options of `ls` like `-a` are ignored
+	if strings.HasPrefix(arg, "-") {
+		arg = ""
+	}
+	p, local := s.localPath(arg)
+	info, err := os.Stat(local)
+	if err != nil {
+		s.reply(550, "no such file or directory")
+		return
+	}
+	var infos []os.FileInfo
+	if info.IsDir() {
+		entries, err := os.ReadDir(local)
+		if err != nil {
+			s.reply(550, "failed to read directory")
+			return
+		}
+		for _, entry := range entries {
+			if info, err := entry.Info(); err == nil {
+				infos = append(infos, info)
+			}
+		}
+	} else if command == "MLSD" {
+		s.reply(501, "not a directory")
+		return
+	} else {
+		infos = append(infos, info)
+	}
+
+	s.transfer(func(conn net.Conn) error {
+		w := bufio.NewWriter(conn)
+		if command == "MLSD" {
+			_, _ = fmt.Fprintf(w, "%s\r\n", facts(info, "."))
+		}
+		for _, info := range infos {
+			switch command {
+			case "MLSD":
+				_, _ = fmt.Fprintf(w, "%s\r\n", facts(info, info.Name()))
+			case "NLST":
+				_, _ = fmt.Fprintf(w, "%s\r\n", pathpkg.Join(p, info.Name()))
+			default:
+				_, _ = fmt.Fprintf(w, "%s\r\n", lsLine(info))
+			}
+		}
+		return w.Flush()
+	})
+}
+
+// facts returns the line of MLST and MLSD.
+func facts(info os.FileInfo, name string) string {
+	kind := "file"
+	switch {
+	case name == ".":
+		kind = "cdir"
+	case info.IsDir():
+		kind = "dir"
+	}
+	return fmt.Sprintf("type=%s;size=%d;modify=%s; %s", kind, info.Size(), info.ModTime().UTC().Format("20060102150405"), name)
+}
+
+// lsLine returns the line of LIST in the format of `ls -l`.
+func lsLine(info os.FileInfo) string {
+	mode := "-rw-r--r--"
+	if info.IsDir() {
+		mode = "drwxr-xr-x"
+	}
+	modTime := info.ModTime().UTC()
+	timeFormat := "Jan _2 15:04"
+	if time.Since(modTime) > 180*24*time.Hour {
+		timeFormat = "Jan _2  2006"
+	}
+	return fmt.Sprintf("%s 1 ftp ftp %12d %s %s", mode, info.Size(), modTime.Format(timeFormat), info.Name())
+}
@@ -0,0 +1,33 @@
+package ftp
+
+import (
+	"crypto/tls"
+	"time"
+)
+
+type Options struct {
+	// Endpoint the address of the server, e.g. `example.com:21`, the port defaults to 21.
+	Endpoint string
+	// Root the absolute path on the server, e.g. `/home/user/data/`
+	Root string
+
+	User     string
+	Password string
+
+	// ExplicitTLS upgrades the connections by `AUTH TLS`, the data connections are protected as well.
+	ExplicitTLS bool
+	// ImplicitTLS dials the connections over TLS, it's usually served on the port 990.
+	ImplicitTLS bool
+	// TLSConfig the config of FTPS, the host of the endpoint is verified if it's not set.
+	TLSConfig *tls.Config
+
+	// DisableEPSV uses `PASV` instead of `EPSV` for the servers not supporting it.
+	DisableEPSV bool
+	// DisableMLSD uses `LIST` instead of `MLSD` and `MLST` even if the server supports them.
+	DisableMLSD bool
+
+	// MaxConns the max number of connections opened at the same time, defaults to 4.
+	MaxConns int
+	// Timeout the timeout of dialing and of the replies, defaults to 30s.
+	Timeout time.Duration
+}
@@ -0,0 +1,60 @@
+package ftp
+
+import (
+	"context"
+	"github.com/jlaffaye/ftp"
+	"github.com/senrok/yadal/errors"
+	"net/textproto"
+)
+
+// pool keeps the idle connections for reuse, at most `cap(slots)` connections are opened at the same time,
+// since a connection serves one command at a time.
+type pool struct {
+	dial  func(ctx context.Context) (*ftp.ServerConn, error)
+	idle  chan *ftp.ServerConn
+	slots chan struct{}
+}
+
+func newPool(size int, dial func(ctx context.Context) (*ftp.ServerConn, error)) *pool {
+	return &pool{
+		dial:  dial,
+		idle:  make(chan *ftp.ServerConn, size),
+		slots: make(chan struct{}, size),
+	}
+}
+
+// get returns an idle connection, or dials a new one if the pool is not full,
+// otherwise it waits for a connection returned.
+func (p *pool) get(ctx context.Context) (*ftp.ServerConn, error) {
+	select {
+	case c := <-p.idle:
+		return c, nil
+	default:
+	}
+
+	select {
+	case c := <-p.idle:
+		return c, nil
+	case p.slots <- struct{}{}:
+		c, err := p.dial(ctx)
+		if err != nil {
+			<-p.slots
+			return nil, err
+		}
+		return c, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// put returns the connection used, it's closed unless the error is replied by the server,
+// since the state of the control connection is unknown after the other errors.
+func (p *pool) put(c *ftp.ServerConn, err error) {
+	var protoErr *textproto.Error
+	if err != nil && !errors.As(err, &protoErr) {
+		_ = c.Quit()
+		<-p.slots
+		return
+	}
+	p.idle <- c
+}
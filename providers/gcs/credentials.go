@@ -0,0 +1,214 @@
+package gcs
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/senrok/yadal/errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTokenUri = "https://oauth2.googleapis.com/token"
+	jwtBearerGrant  = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+	// expiryWindow refreshes the token before it's really expired
+	expiryWindow = 5 * time.Minute
+)
+
+// TokenProvider provides the OAuth2 access tokens of the requests.
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken a TokenProvider of the given access token.
+type StaticToken string
+
+func (s StaticToken) Token(context.Context) (string, error) {
+	return string(s), nil
+}
+
+// ServiceAccount the json key of a service account.
+type ServiceAccount struct {
+	Type         string `json:"type"`
+	ProjectId    string `json:"project_id"`
+	PrivateKeyId string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenUri     string `json:"token_uri"`
+
+	key *rsa.PrivateKey
+}
+
+// ParseServiceAccount parses the json key of a service account.
+func ParseServiceAccount(b []byte) (*ServiceAccount, error) {
+	account := &ServiceAccount{}
+	if err := json.Unmarshal(b, account); err != nil {
+		return nil, err
+	}
+	if account.Type != "service_account" {
+		return nil, fmt.Errorf("unsupported credential type: %q", account.Type)
+	}
+	if account.ClientEmail == "" {
+		return nil, fmt.Errorf("client_email is required")
+	}
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("invalid private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		// the keys of old service accounts are PKCS1 encoded.
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return nil, err
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not RSA")
+	}
+	account.key = key
+	if account.TokenUri == "" {
+		account.TokenUri = defaultTokenUri
+	}
+	return account, nil
+}
+
+// loadServiceAccount loads the service account from the credential, then the credential path,
+// it returns nil if none is found.
+func loadServiceAccount(opt Options) (*ServiceAccount, error) {
+	credential := []byte(opt.Credential)
+	if len(credential) == 0 {
+		path := opt.CredentialPath
+		if path == "" {
+			path = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+		}
+		if path == "" {
+			return nil, nil
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		credential = b
+	}
+	return ParseServiceAccount(credential)
+}
+
+// Sign signs the digest of the content with RSA-SHA256.
+func (s *ServiceAccount) Sign(content []byte) ([]byte, error) {
+	digest := sha256.Sum256(content)
+	return rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+}
+
+// assertion returns the signed JWT exchanged for the access token.
+func (s *ServiceAccount) assertion(scope string, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+		"kid": s.PrivateKeyId,
+	})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   s.ClientEmail,
+		"scope": scope,
+		"aud":   s.TokenUri,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	encoding := base64.RawURLEncoding
+	content := encoding.EncodeToString(header) + "." + encoding.EncodeToString(claims)
+	signature, err := s.Sign([]byte(content))
+	if err != nil {
+		return "", err
+	}
+	return content + "." + encoding.EncodeToString(signature), nil
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+type serviceAccountProvider struct {
+	account *ServiceAccount
+	scope   string
+	client  *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewServiceAccountProvider returns a provider exchanging the signed JWT of the service account
+// for access tokens, the tokens are cached until expired.
+func NewServiceAccountProvider(account *ServiceAccount, scope string, client *http.Client) TokenProvider {
+	if scope == "" {
+		scope = DefaultScope
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &serviceAccountProvider{
+		account: account,
+		scope:   scope,
+		client:  client,
+	}
+}
+
+func (s *serviceAccountProvider) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Add(expiryWindow).Before(s.expiry) {
+		return s.token, nil
+	}
+
+	now := time.Now()
+	assertion, err := s.account.assertion(s.scope, now)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{}
+	form.Set("grant_type", jwtBearerGrant)
+	form.Set("assertion", assertion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.account.TokenUri, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return "", errors.Wrap(errors.ErrPermissionDenied, fmt.Errorf("fetch token: %s: %s", resp.Status, b))
+	}
+	output := tokenResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(&output); err != nil {
+		return "", err
+	}
+	s.token = output.AccessToken
+	s.expiry = now.Add(time.Duration(output.ExpiresIn) * time.Second)
+	return s.token, nil
+}
@@ -0,0 +1,100 @@
+package gcs
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/utils"
+	"net/http"
+	"strings"
+)
+
+type DirStream struct {
+	*Driver
+	root  string
+	path  string
+	token string
+
+	done bool
+}
+
+func (d *DirStream) NextPage(ctx context.Context) ([]interfaces.Entry, error) {
+	// a page could be empty if it only contains the dir itself, an empty page ends the stream.
+	for !d.done {
+		entries, err := d.nextPage(ctx)
+		if err != nil || len(entries) > 0 {
+			return entries, err
+		}
+	}
+	return nil, nil
+}
+
+func (d *DirStream) nextPage(ctx context.Context) ([]interfaces.Entry, error) {
+	resp, err := d.ListObjects(ctx, d.path, d.token)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrListFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.ParseGcsError(errors.ErrListFailed, d.path, resp)
+	}
+	output := Objects{}
+	if err = json.NewDecoder(resp.Body).Decode(&output); err != nil {
+		return nil, errors.Wrap(errors.ErrListFailed, err)
+	}
+	d.token = output.NextPageToken
+	d.done = output.NextPageToken == ""
+
+	entries := make([]interfaces.Entry, 0, len(output.Prefixes)+len(output.Items))
+	for _, prefix := range output.Prefixes {
+		path, err := utils.BuildRealPath(d.root, prefix)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries,
+			object.NewEntry(
+				d.Driver,
+				path,
+				object.Metadata{
+					ObjectMode: interfaces.DIR,
+				},
+				false),
+		)
+	}
+
+	for _, item := range output.Items {
+		// the dir itself is listed if it's created as an object ends-with `/`.
+		if strings.HasSuffix(item.Name, "/") {
+			continue
+		}
+		meta, err := item.metadata(interfaces.FILE, true)
+		if err != nil {
+			return nil, err
+		}
+		path, err := utils.BuildRealPath(d.root, item.Name)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries,
+			object.NewEntry(
+				d.Driver,
+				path,
+				meta,
+				true),
+		)
+	}
+	return entries, nil
+}
+
+func NewDirStream(d *Driver, root, path string) interfaces.ObjectPageStream {
+	return &DirStream{
+		Driver: d,
+		root:   root,
+		path:   path,
+		token:  "",
+		done:   false,
+	}
+}
@@ -0,0 +1,201 @@
+package gcs
+
+import (
+	"context"
+	"fmt"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/utils"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// objectUrl returns the url of the object resource of JSON API, the name is escaped as a single segment.
+func (d *Driver) objectUrl(path string) (string, error) {
+	p, err := utils.BuildAbsPath(d.root, path)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/storage/v1/b/%s/o/%s", d.endpoint, d.bucket, url.PathEscape(p)), nil
+}
+
+// xmlUrl returns the url of the object of XML API, it's used by signed urls.
+func (d *Driver) xmlUrl(path string) (string, error) {
+	p, err := utils.BuildAbsPath(d.root, path)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s/%s", d.endpoint, d.bucket, utils.EncodePath(p)), nil
+}
+
+func (d *Driver) uploadUrl(path, uploadType string) (string, error) {
+	p, err := utils.BuildAbsPath(d.root, path)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("uploadType", uploadType)
+	query.Set("name", p)
+	return fmt.Sprintf("%s/upload/storage/v1/b/%s/o?%s", d.endpoint, d.bucket, query.Encode()), nil
+}
+
+// withGeneration selects the generation of the object, the live one is selected if generation is empty.
+func withGeneration(u url.Values, generation string) url.Values {
+	if generation != "" {
+		u.Set("generation", generation)
+	}
+	return u
+}
+
+// authorize sets the access token of the request, the request is anonymous without a TokenProvider.
+func (d *Driver) authorize(ctx context.Context, req *http.Request) error {
+	if d.tokens == nil {
+		return nil
+	}
+	token, err := d.tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// do authorizes and sends the request.
+func (d *Driver) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if err := d.authorize(ctx, req); err != nil {
+		return nil, err
+	}
+	return d.client.Do(req)
+}
+
+func (d *Driver) GetObject(ctx context.Context, path string, offset, size *uint64, generation string) (*http.Response, error) {
+	u, err := d.objectUrl(path)
+	if err != nil {
+		return nil, err
+	}
+	query := withGeneration(url.Values{"alt": {"media"}}, generation)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset != nil || size != nil {
+		req.Header.Set("Range", options.NewBytesRange(offset, size).String())
+	}
+	return d.do(ctx, req)
+}
+
+func (d *Driver) StatObject(ctx context.Context, path string, generation string) (*http.Response, error) {
+	u, err := d.objectUrl(path)
+	if err != nil {
+		return nil, err
+	}
+	if query := withGeneration(url.Values{}, generation); len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.do(ctx, req)
+}
+
+// InsertObject uploads the object with a single request, see https://cloud.google.com/storage/docs/uploading-objects
+func (d *Driver) InsertObject(ctx context.Context, path string, size uint64, body io.Reader) (*http.Response, error) {
+	u, err := d.uploadUrl(path, "media")
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		body = http.NoBody
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(size)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	return d.do(ctx, req)
+}
+
+func (d *Driver) DeleteObject(ctx context.Context, path string, generation string) (*http.Response, error) {
+	u, err := d.objectUrl(path)
+	if err != nil {
+		return nil, err
+	}
+	if query := withGeneration(url.Values{}, generation); len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.do(ctx, req)
+}
+
+func (d *Driver) ListObjects(ctx context.Context, path string, pageToken string) (*http.Response, error) {
+	p, err := utils.BuildAbsPath(d.root, path)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	query.Set("prefix", p)
+	query.Set("delimiter", "/")
+	if pageToken != "" {
+		query.Set("pageToken", pageToken)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/storage/v1/b/%s/o?%s", d.endpoint, d.bucket, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.do(ctx, req)
+}
+
+// InitiateResumableUpload starts a resumable upload, the session uri is returned in the `Location` header.
+// see https://cloud.google.com/storage/docs/performing-resumable-uploads
+func (d *Driver) InitiateResumableUpload(ctx context.Context, path string) (*http.Response, error) {
+	u, err := d.uploadUrl(path, "resumable")
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = 0
+	return d.do(ctx, req)
+}
+
+// UploadChunk uploads size bytes at the offset of the session, the total size is unknown if total is nil.
+//
+// The session uri authorizes the request itself, no access token is sent.
+func (d *Driver) UploadChunk(ctx context.Context, session string, offset, size uint64, total *uint64, body io.Reader) (*http.Response, error) {
+	if size == 0 {
+		body = http.NoBody
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, session, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(size)
+
+	totalStr := "*"
+	if total != nil {
+		totalStr = strconv.FormatUint(*total, 10)
+	}
+	if size == 0 {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes */%s", totalStr))
+	} else {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%s", offset, offset+size-1, totalStr))
+	}
+	return d.client.Do(req)
+}
+
+// CancelResumableUpload cancels the session, the uploaded bytes are discarded.
+func (d *Driver) CancelResumableUpload(ctx context.Context, session string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, session, nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.client.Do(req)
+}
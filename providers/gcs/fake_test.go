@@ -0,0 +1,369 @@
+package gcs
+
+import (
+	"crypto"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer a minimal in-memory GCS serving JSON API, resumable uploads, signed urls of XML API
+// and the token endpoint of service accounts.
+type fakeServer struct {
+	*httptest.Server
+	bucket   string
+	pageSize int
+	key      *rsa.PrivateKey
+
+	mu          sync.Mutex
+	objects     map[string]fakeObject
+	uploads     map[string]*fakeUpload
+	generation  int
+	sessionSeq  int
+	tokenIssued int
+}
+
+type fakeObject struct {
+	data       []byte
+	generation int
+	updated    time.Time
+}
+
+type fakeUpload struct {
+	name string
+	data []byte
+}
+
+const fakeToken = "fake-access-token"
+
+func newFakeServer(t *testing.T) *fakeServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeServer{
+		bucket:   "bucket",
+		pageSize: 1000,
+		key:      key,
+		objects:  map[string]fakeObject{},
+		uploads:  map[string]*fakeUpload{},
+	}
+	f.Server = httptest.NewServer(f)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// credential returns the json key of the service account whose token uri is the fake server.
+func (f *fakeServer) credential(t *testing.T) string {
+	der, err := x509.MarshalPKCS8PrivateKey(f.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "project",
+		"private_key_id": "key-id",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "test@project.iam.gserviceaccount.com",
+		"token_uri":      f.URL + "/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func (f *fakeServer) resource(name string, o fakeObject) Object {
+	sum := md5.Sum(o.data)
+	return Object{
+		Name:         name,
+		Bucket:       f.bucket,
+		Generation:   strconv.Itoa(o.generation),
+		Size:         strconv.Itoa(len(o.data)),
+		MD5Hash:      base64.StdEncoding.EncodeToString(sum[:]),
+		ETag:         fmt.Sprintf("etag-%d", o.generation),
+		StorageClass: "STANDARD",
+		Updated:      o.updated,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, reason string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    status,
+			"message": reason,
+			"errors":  []map[string]string{{"reason": reason}},
+		},
+	})
+}
+
+// put stores the object as a new generation, the caller MUST hold the lock.
+func (f *fakeServer) put(name string, data []byte) fakeObject {
+	f.generation++
+	o := fakeObject{data: data, generation: f.generation, updated: time.Now().UTC().Truncate(time.Second)}
+	f.objects[name] = o
+	return o
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	jsonPrefix := "/storage/v1/b/" + f.bucket + "/o"
+	uploadPrefix := "/upload" + jsonPrefix
+	path := r.URL.EscapedPath()
+	switch {
+	case path == "/token":
+		f.serveToken(w, r)
+	case path == uploadPrefix && r.URL.Query().Has("upload_id"):
+		f.serveSession(w, r)
+	case path == uploadPrefix:
+		if !f.authorized(r) {
+			writeError(w, http.StatusUnauthorized, "required")
+			return
+		}
+		f.serveUpload(w, r)
+	case strings.HasPrefix(path, jsonPrefix):
+		if !f.authorized(r) {
+			writeError(w, http.StatusUnauthorized, "required")
+			return
+		}
+		f.serveJSON(w, r, strings.TrimPrefix(strings.TrimPrefix(path, jsonPrefix), "/"))
+	case strings.HasPrefix(path, "/"+f.bucket+"/"):
+		f.serveXML(w, r, strings.TrimPrefix(path, "/"+f.bucket+"/"))
+	default:
+		writeError(w, http.StatusNotFound, "notFound")
+	}
+}
+
+func (f *fakeServer) authorized(r *http.Request) bool {
+	return r.Header.Get("Authorization") == "Bearer "+fakeToken
+}
+
+// serveToken verifies the signed JWT of the service account.
+func (f *fakeServer) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("grant_type") != jwtBearerGrant {
+		writeError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	parts := strings.Split(r.FormValue("assertion"), ".")
+	if len(parts) != 3 {
+		writeError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(&f.key.PublicKey, crypto.SHA256, digest[:], signature) != nil {
+		writeError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	f.tokenIssued++
+	writeJSON(w, http.StatusOK, tokenResponse{AccessToken: fakeToken, ExpiresIn: 3600, TokenType: "Bearer"})
+}
+
+func (f *fakeServer) serveUpload(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	switch r.URL.Query().Get("uploadType") {
+	case "media":
+		data, _ := io.ReadAll(r.Body)
+		writeJSON(w, http.StatusOK, f.resource(name, f.put(name, data)))
+	case "resumable":
+		f.sessionSeq++
+		id := strconv.Itoa(f.sessionSeq)
+		f.uploads[id] = &fakeUpload{name: name}
+		w.Header().Set("Location", fmt.Sprintf("%s%s?uploadType=resumable&upload_id=%s", f.URL, r.URL.Path, id))
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeError(w, http.StatusBadRequest, "invalid")
+	}
+}
+
+// serveSession serves the chunks of resumable uploads, the `Content-Range` is `bytes */*`,
+// `bytes */total`, `bytes start-end/*` or `bytes start-end/total`.
+func (f *fakeServer) serveSession(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("upload_id")
+	upload, ok := f.uploads[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "notFound")
+		return
+	}
+	if r.Method == http.MethodDelete {
+		delete(f.uploads, id)
+		w.WriteHeader(statusClientClosedRequest)
+		return
+	}
+
+	var chunk, total string
+	if _, err := fmt.Sscanf(strings.Replace(r.Header.Get("Content-Range"), "/", " ", 1), "bytes %s %s", &chunk, &total); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid")
+		return
+	}
+	data, _ := io.ReadAll(r.Body)
+	if chunk != "*" {
+		var start, end int
+		if _, err := fmt.Sscanf(chunk, "%d-%d", &start, &end); err != nil || start != len(upload.data) || end-start+1 != len(data) {
+			writeError(w, http.StatusBadRequest, "invalid")
+			return
+		}
+		if total != "*" && len(data)%chunkSize != 0 && end+1 != mustAtoi(total) {
+			writeError(w, http.StatusBadRequest, "invalid")
+			return
+		}
+		if total == "*" && len(data)%chunkSize != 0 {
+			writeError(w, http.StatusBadRequest, "invalid")
+			return
+		}
+		upload.data = append(upload.data, data...)
+	}
+	if total != "*" && mustAtoi(total) == len(upload.data) {
+		delete(f.uploads, id)
+		writeJSON(w, http.StatusOK, f.resource(upload.name, f.put(upload.name, upload.data)))
+		return
+	}
+	if len(upload.data) > 0 {
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(upload.data)-1))
+	}
+	w.WriteHeader(statusResumeIncomplete)
+}
+
+func mustAtoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func (f *fakeServer) serveJSON(w http.ResponseWriter, r *http.Request, escaped string) {
+	if escaped == "" {
+		f.serveList(w, r)
+		return
+	}
+	name, _ := url.PathUnescape(escaped)
+	o, ok := f.objects[name]
+	if generation := r.URL.Query().Get("generation"); ok && generation != "" && generation != strconv.Itoa(o.generation) {
+		ok = false
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "notFound")
+		return
+	}
+	switch {
+	case r.Method == http.MethodDelete:
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Query().Get("alt") == "media":
+		serveData(w, r, o.data)
+	default:
+		writeJSON(w, http.StatusOK, f.resource(name, o))
+	}
+}
+
+func serveData(w http.ResponseWriter, r *http.Request, data []byte) {
+	var start, end int
+	if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err == nil {
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write(data[start : end+1])
+		return
+	}
+	_, _ = w.Write(data)
+}
+
+func (f *fakeServer) serveList(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	var names []string
+	prefixes := map[string]bool{}
+	for name := range f.objects {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if idx := strings.Index(name[len(prefix):], "/"); idx >= 0 {
+			p := name[:len(prefix)+idx+1]
+			if !prefixes[p] {
+				prefixes[p] = true
+				names = append(names, p)
+			}
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+	end := start + f.pageSize
+	output := Objects{}
+	if end < len(names) {
+		output.NextPageToken = strconv.Itoa(end)
+	} else {
+		end = len(names)
+	}
+	for _, name := range names[start:end] {
+		if prefixes[name] {
+			output.Prefixes = append(output.Prefixes, name)
+		} else {
+			output.Items = append(output.Items, f.resource(name, f.objects[name]))
+		}
+	}
+	writeJSON(w, http.StatusOK, output)
+}
+
+// serveXML serves the signed urls of XML API.
+func (f *fakeServer) serveXML(w http.ResponseWriter, r *http.Request, escaped string) {
+	query := r.URL.Query()
+	datetime, err := time.Parse("20060102T150405Z", query.Get("X-Goog-Date"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid")
+		return
+	}
+	expires, _ := strconv.Atoi(query.Get("X-Goog-Expires"))
+	if time.Now().After(datetime.Add(time.Duration(expires) * time.Second)) {
+		writeError(w, http.StatusBadRequest, "expired")
+		return
+	}
+	credential := strings.SplitN(query.Get("X-Goog-Credential"), "/", 2)
+	digest := sha256.Sum256([]byte(CanonicalRequest(r)))
+	stringToSign := strings.Join([]string{signAlgorithm, query.Get("X-Goog-Date"), credential[1], hex.EncodeToString(digest[:])}, "\n")
+	signature, _ := hex.DecodeString(query.Get("X-Goog-Signature"))
+	hashed := sha256.Sum256([]byte(stringToSign))
+	if rsa.VerifyPKCS1v15(&f.key.PublicKey, crypto.SHA256, hashed[:], signature) != nil {
+		writeError(w, http.StatusForbidden, "SignatureDoesNotMatch")
+		return
+	}
+
+	name, _ := url.PathUnescape(escaped)
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.put(name, data)
+	case http.MethodGet:
+		o, ok := f.objects[name]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		if v := query.Get("response-content-disposition"); v != "" {
+			w.Header().Set("Content-Disposition", v)
+		}
+		serveData(w, r, o.data)
+	}
+}
@@ -0,0 +1,373 @@
+package gcs
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/logger"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/providers"
+	"github.com/senrok/yadal/utils"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultPreSignExpire = time.Hour
+
+// statusClientClosedRequest the status of a cancelled resumable upload
+const statusClientClosedRequest = 499
+
+type Driver struct {
+	bucket   string
+	endpoint string
+	root     string
+	client   *http.Client
+	tokens   TokenProvider
+	account  *ServiceAccount
+	sessions *sessions
+	logger.Logger
+}
+
+func (d *Driver) Metadata() interfaces.Metadata {
+	capability := interfaces.Read | interfaces.Write | interfaces.List | interfaces.Multipart
+	// urls are signed by the private key of the service account.
+	if d.account != nil {
+		capability |= interfaces.PreSign
+	}
+	return providers.NewMetadata(interfaces.Gcs, d.root, d.bucket, capability)
+}
+
+func (d *Driver) Create(ctx context.Context, path string, _ options.CreateOptions) error {
+	resp, err := d.InsertObject(ctx, path, 0, nil)
+	if err != nil {
+		return errors.Wrap(errors.ErrCreateFailed, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusOK:
+		return nil
+	default:
+		return errors.ParseGcsError(errors.ErrCreateFailed, path, resp)
+	}
+}
+
+func (d *Driver) Read(ctx context.Context, path string, args options.ReadOptions) (io.ReadCloser, error) {
+	resp, err := d.GetObject(ctx, path, args.Offset, args.Size, args.VersionId)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrReadFailed, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent, http.StatusOK:
+		return resp.Body, nil
+	default:
+		return nil, errors.ParseGcsError(errors.ErrReadFailed, path, resp)
+	}
+}
+
+func (d *Driver) Write(ctx context.Context, path string, args options.WriteOptions, reader io.Reader) (interfaces.WriteResult, error) {
+	resp, err := d.InsertObject(ctx, path, args.Size, reader)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrWriteFailed, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusOK:
+		return writeResult(args.Size, resp)
+	default:
+		return nil, errors.ParseGcsError(errors.ErrWriteFailed, path, resp)
+	}
+}
+
+// writeResult decodes the object resource of the upload response, the generation is the version.
+func writeResult(size uint64, resp *http.Response) (interfaces.WriteResult, error) {
+	output := Object{}
+	if err := json.NewDecoder(resp.Body).Decode(&output); err != nil {
+		return nil, errors.Wrap(errors.ErrWriteFailed, err)
+	}
+	result := object.WriteResult{Size: size}
+	if output.ETag != "" {
+		result.ETag = &output.ETag
+	}
+	if output.Generation != "" {
+		result.VersionId = &output.Generation
+	}
+	return result, nil
+}
+
+func (d *Driver) Stat(ctx context.Context, path string, args options.StatOptions) (interfaces.ObjectMetadata, error) {
+	if path == "/" {
+		return object.Metadata{ObjectMode: interfaces.DIR}, nil
+	}
+
+	resp, err := d.StatObject(ctx, path, args.VersionId)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrStatFailed, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		output := Object{}
+		if err = json.NewDecoder(resp.Body).Decode(&output); err != nil {
+			return nil, errors.Wrap(errors.ErrStatFailed, err)
+		}
+		md, err := output.metadata(interfaces.ObjectModeFromPath(path), args.VersionId == "")
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrStatFailed, err)
+		}
+		return md, nil
+
+	case http.StatusNotFound:
+		if strings.HasSuffix(path, "/") {
+			return object.Metadata{ObjectMode: interfaces.DIR}, nil
+		}
+		fallthrough // handles other cases
+	default:
+		return nil, errors.ParseGcsError(errors.ErrStatFailed, path, resp)
+	}
+}
+
+func (d *Driver) Delete(ctx context.Context, path string, args options.DeleteOptions) error {
+	resp, err := d.DeleteObject(ctx, path, args.VersionId)
+	if err != nil {
+		return errors.Wrap(errors.ErrDeleteFailed, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusOK, http.StatusNotFound:
+		return nil
+	default:
+		return errors.ParseGcsError(errors.ErrDeleteFailed, path, resp)
+	}
+}
+
+func (d *Driver) List(ctx context.Context, path string, args options.ListOptions) (interfaces.ObjectStream, error) {
+	return object.NewObjectStream(NewDirStream(d, d.root, path)), nil
+}
+
+func (d *Driver) ListVersions(ctx context.Context, path string, args options.ListVersions) (interfaces.ObjectStream, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) PreSign(ctx context.Context, path string, args options.PreSignOptions) (*http.Request, error) {
+	if d.account == nil {
+		return nil, errors.ErrUnsupportedMethod
+	}
+
+	u, err := d.xmlUrl(path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+	}
+	var method string
+	switch args.Op {
+	case options.ReadOp:
+		method = http.MethodGet
+	case options.WriteOp:
+		method = http.MethodPut
+	case options.StatOp:
+		method = http.MethodHead
+	case options.DeleteOp:
+		method = http.MethodDelete
+	case options.WriteMultipartOp, options.CreateMultipartOp, options.CompleteMultipartOp:
+		// the session uri of resumable uploads authorizes the requests itself.
+		return nil, errors.ErrUnsupportedMethod
+	default:
+		return nil, errors.Wrap(errors.ErrPreSignFailed, errors.ErrUnknownPreSignOperation)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+	}
+
+	for k, values := range args.Headers {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	if args.Op == options.ReadOp && args.ReadOptions != nil {
+		query := req.URL.Query()
+		if args.ReadOptions.VersionId != "" {
+			query.Set("generation", args.ReadOptions.VersionId)
+		}
+		req.URL.RawQuery = query.Encode()
+		if args.ReadOptions.Offset != nil || args.ReadOptions.Size != nil {
+			req.Header.Set("Range", options.NewBytesRange(args.ReadOptions.Offset, args.ReadOptions.Size).String())
+		}
+	}
+	if args.Op == options.ReadOp && args.Response != nil {
+		setResponseOverrides(req, args.Response)
+	}
+
+	expire := args.Expire
+	if expire == 0 {
+		expire = defaultPreSignExpire
+	}
+	if err = d.account.PreSign(req, expire, time.Now()); err != nil {
+		return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+	}
+	return req, nil
+}
+
+// setResponseOverrides sets the `response-*` query of a GET request, only the content type and
+// disposition are supported by XML API.
+func setResponseOverrides(req *http.Request, o *options.ResponseOverrides) {
+	query := req.URL.Query()
+	if o.ContentType != "" {
+		query.Set("response-content-type", o.ContentType)
+	}
+	if o.ContentDisposition != "" {
+		query.Set("response-content-disposition", o.ContentDisposition)
+	}
+	req.URL.RawQuery = query.Encode()
+}
+
+// CreateMultipart starts a resumable upload, the session uri is the upload id.
+func (d *Driver) CreateMultipart(ctx context.Context, path string, _ options.CreateMultipart) (string, error) {
+	resp, err := d.InitiateResumableUpload(ctx, path)
+	if err != nil {
+		return "", errors.Wrap(errors.ErrCreateMultipartFailed, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		uploadId := resp.Header.Get("Location")
+		if uploadId == "" {
+			return "", errors.Wrap(errors.ErrCreateMultipartFailed, errors.ErrUploadIdRequired)
+		}
+		d.sessions.set(uploadId, session{})
+		return uploadId, nil
+	default:
+		return "", errors.ParseGcsError(errors.ErrCreateMultipartFailed, path, resp)
+	}
+}
+
+// WriteMultipart appends the part to the resumable upload, the parts MUST be written in the ascending order
+// of their numbers by the driver which creates the upload.
+func (d *Driver) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.Reader) (interfaces.ObjectPart, error) {
+	ss, ok := d.sessions.get(args.UploadId)
+	if !ok {
+		return nil, errors.ParseFsError(errors.ErrWriteMultipartFailed, errSessionNotFound, path)
+	}
+	// a resent or reordered part would be appended to the upload again
+	if args.PartNumber <= ss.lastPartNumber() {
+		return nil, errors.ParseFsError(errors.ErrWriteMultipartFailed, errors.ErrInvalidPart, path)
+	}
+	ss, etag, err := d.writeChunks(ctx, args.UploadId, ss, args.Size, reader)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrWriteMultipartFailed, err)
+	}
+	part := object.ObjectPart{
+		PartNumber: args.PartNumber,
+		ETag:       etag,
+	}
+	ss.parts = append(ss.parts[:len(ss.parts):len(ss.parts)], part)
+	d.sessions.set(args.UploadId, ss)
+	return part, nil
+}
+
+// CompleteMultipart uploads the tail as the final chunk, the parts are already in the upload,
+// so they MUST be the written ones in order.
+func (d *Driver) CompleteMultipart(ctx context.Context, path string, args options.CompleteMultipart) error {
+	ss, ok := d.sessions.get(args.UploadId)
+	if !ok {
+		return errors.ParseFsError(errors.ErrCompleteMultipartFailed, errSessionNotFound, path)
+	}
+	if !ss.matches(args.ObjectParts) {
+		return errors.ParseFsError(errors.ErrCompleteMultipartFailed, errors.ErrInvalidPart, path)
+	}
+	resp, err := d.finalize(ctx, args.UploadId, ss)
+	if err != nil {
+		return errors.Wrap(errors.ErrCompleteMultipartFailed, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		d.sessions.delete(args.UploadId)
+		return nil
+	default:
+		return errors.ParseGcsError(errors.ErrCompleteMultipartFailed, path, resp)
+	}
+}
+
+func (d *Driver) AbortMultipart(ctx context.Context, path string, args options.AbortMultipart) error {
+	resp, err := d.CancelResumableUpload(ctx, args.UploadId)
+	if err != nil {
+		return errors.Wrap(errors.ErrAbortMultipartFailed, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case statusClientClosedRequest, http.StatusOK, http.StatusNoContent:
+		d.sessions.delete(args.UploadId)
+		return nil
+	default:
+		return errors.ParseGcsError(errors.ErrAbortMultipartFailed, path, resp)
+	}
+}
+
+// ListMultipart is not supported, the sessions of resumable uploads can't be listed.
+func (d *Driver) ListMultipart(ctx context.Context, path string, args options.ListMultipart) ([]interfaces.MultipartUpload, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) GetTags(ctx context.Context, path string, args options.GetTags) (map[string]string, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) SetTags(ctx context.Context, path string, args options.SetTags) error {
+	return errors.ErrUnsupportedMethod
+}
+
+// NewDriver returns a driver of the bucket, the requests are authorized by the first found of
+//
+//   - Options.TokenProvider
+//   - Options.Token
+//   - the service account of Options.Credential, Options.CredentialPath or `GOOGLE_APPLICATION_CREDENTIALS` env
+//
+// the requests are anonymous if none is found.
+func NewDriver(ctx context.Context, opt Options) (interfaces.Accessor, error) {
+	client := opt.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	endpoint := opt.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	if !strings.HasPrefix(endpoint, "http") {
+		endpoint = "https://" + endpoint
+	}
+
+	d := &Driver{
+		bucket:   opt.Bucket,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		root:     utils.NormalizeRoot(opt.Root),
+		client:   client,
+		sessions: newSessions(),
+	}
+
+	account, err := loadServiceAccount(opt)
+	if err != nil {
+		return nil, err
+	}
+	d.account = account
+
+	switch {
+	case opt.TokenProvider != nil:
+		d.tokens = opt.TokenProvider
+	case opt.Token != "":
+		d.tokens = StaticToken(opt.Token)
+	case account != nil:
+		d.tokens = NewServiceAccountProvider(account, opt.Scope, client)
+	}
+	return d, nil
+}
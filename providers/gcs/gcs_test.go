@@ -0,0 +1,221 @@
+package gcs
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/options"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func setupDriver(t *testing.T, f *fakeServer, root string) interfaces.Accessor {
+	d, err := NewDriver(context.Background(), Options{
+		Bucket:     f.bucket,
+		Endpoint:   f.URL,
+		Root:       root,
+		Credential: f.credential(t),
+	})
+	assert.Nil(t, err)
+	return d
+}
+
+func TestDriver(t *testing.T) {
+	f := newFakeServer(t)
+	d := setupDriver(t, f, "/root/")
+	ctx := context.Background()
+	assert.Equal(t, interfaces.Gcs, d.Metadata().Provider())
+	assert.True(t, d.Metadata().Capability().Has(interfaces.Read, interfaces.Write, interfaces.List, interfaces.PreSign, interfaces.Multipart))
+
+	content := []byte("Hello,World!")
+	result, err := d.Write(ctx, "dir/a b.txt", options.WriteOptions{Size: uint64(len(content))}, bytes.NewReader(content))
+	assert.Nil(t, err)
+	assert.Equal(t, "1", *result.GetVersionId())
+	assert.Contains(t, f.objects, "root/dir/a b.txt")
+
+	meta, err := d.Stat(ctx, "dir/a b.txt", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.FILE, meta.Mode())
+	assert.Equal(t, uint64(len(content)), *meta.ContentLength())
+	sum := md5.Sum(content)
+	assert.Equal(t, hex.EncodeToString(sum[:]), *meta.ContentMD5())
+	assert.Equal(t, "STANDARD", *meta.StorageClass())
+	assert.Equal(t, "1", *meta.VersionId())
+
+	offset, size := uint64(6), uint64(5)
+	reader, err := d.Read(ctx, "dir/a b.txt", options.ReadOptions{Offset: &offset, Size: &size})
+	assert.Nil(t, err)
+	b, _ := io.ReadAll(reader)
+	assert.Equal(t, "World", string(b))
+
+	_, err = d.Read(ctx, "dir/a b.txt", options.ReadOptions{VersionId: "2"})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+	// stat a dir without the dir object
+	meta, err = d.Stat(ctx, "dir/", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.DIR, meta.Mode())
+
+	_, err = d.Stat(ctx, "not-exist", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+	assert.Nil(t, d.Delete(ctx, "dir/a b.txt", options.DeleteOptions{}))
+	assert.Nil(t, d.Delete(ctx, "dir/a b.txt", options.DeleteOptions{}))
+	assert.NotContains(t, f.objects, "root/dir/a b.txt")
+
+	// 1 token is issued and cached
+	assert.Equal(t, 1, f.tokenIssued)
+}
+
+func TestList(t *testing.T) {
+	f := newFakeServer(t)
+	f.pageSize = 2
+	d := setupDriver(t, f, "/")
+	ctx := context.Background()
+
+	assert.Nil(t, d.Create(ctx, "dir/", options.CreateOptions{Mode: int8(interfaces.DIR)}))
+	for _, path := range []string{"dir/a", "dir/b", "dir/c", "dir/sub/d"} {
+		_, err := d.Write(ctx, path, options.WriteOptions{Size: 1}, bytes.NewReader([]byte("x")))
+		assert.Nil(t, err)
+	}
+
+	// pages are fetched with the token of the previous one
+	stream := NewDirStream(d.(*Driver), "/", "dir/")
+	entries := map[string]interfaces.ObjectMode{}
+	for {
+		page, err := stream.NextPage(ctx)
+		assert.Nil(t, err)
+		if page == nil {
+			break
+		}
+		for _, entry := range page {
+			entries[entry.Path()] = entry.Metadata().Mode()
+		}
+	}
+	assert.Equal(t, map[string]interfaces.ObjectMode{
+		"dir/a":    interfaces.FILE,
+		"dir/b":    interfaces.FILE,
+		"dir/c":    interfaces.FILE,
+		"dir/sub/": interfaces.DIR,
+	}, entries)
+}
+
+func TestMultipart(t *testing.T) {
+	f := newFakeServer(t)
+	d := setupDriver(t, f, "/")
+	ctx := context.Background()
+
+	uploadId, err := d.CreateMultipart(ctx, "multipart", options.CreateMultipart{})
+	assert.Nil(t, err)
+
+	// the first part is not aligned to the chunks, its tail is sent with the second one.
+	contents := [][]byte{bytes.Repeat([]byte("a"), chunkSize+100), bytes.Repeat([]byte("b"), chunkSize), []byte("c")}
+	var parts []options.ObjectPart
+	for i, content := range contents {
+		part, err := d.WriteMultipart(ctx, "multipart", options.WriteMultipart{
+			UploadId:   uploadId,
+			PartNumber: uint(i + 1),
+			Size:       uint64(len(content)),
+		}, bytes.NewReader(content))
+		assert.Nil(t, err)
+		parts = append(parts, part)
+	}
+	assert.Len(t, f.uploads, 1)
+
+	// the resent part is not appended again
+	_, err = d.WriteMultipart(ctx, "multipart", options.WriteMultipart{UploadId: uploadId, PartNumber: 2, Size: 1}, bytes.NewReader([]byte("b")))
+	assert.True(t, errors.Is(err, errors.ErrInvalidPart))
+
+	// the parts must be the written ones in order
+	for _, invalid := range [][]options.ObjectPart{
+		nil,
+		parts[:2],
+		{parts[1], parts[0], parts[2]},
+		{parts[0], parts[1], object.ObjectPart{PartNumber: 3, ETag: parts[0].GetETag()}},
+	} {
+		err = d.CompleteMultipart(ctx, "multipart", options.CompleteMultipart{UploadId: uploadId, ObjectParts: invalid})
+		assert.True(t, errors.Is(err, errors.ErrInvalidPart))
+	}
+	assert.NotContains(t, f.objects, "multipart")
+	assert.Nil(t, d.CompleteMultipart(ctx, "multipart", options.CompleteMultipart{UploadId: uploadId, ObjectParts: parts}))
+	assert.Equal(t, bytes.Join(contents, nil), f.objects["multipart"].data)
+	assert.Len(t, f.uploads, 0)
+
+	// the upload is not resumed by the other drivers, since the tail of the parts is kept by the driver.
+	uploadId, err = d.CreateMultipart(ctx, "resumed", options.CreateMultipart{})
+	assert.Nil(t, err)
+	part, err := d.WriteMultipart(ctx, "resumed", options.WriteMultipart{UploadId: uploadId, PartNumber: 1, Size: 1}, bytes.NewReader([]byte("c")))
+	assert.Nil(t, err)
+	another := setupDriver(t, f, "/")
+	_, err = another.WriteMultipart(ctx, "resumed", options.WriteMultipart{UploadId: uploadId, PartNumber: 2, Size: 1}, bytes.NewReader([]byte("c")))
+	assert.True(t, errors.Is(err, errors.ErrWriteMultipartFailed))
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+	err = another.CompleteMultipart(ctx, "resumed", options.CompleteMultipart{UploadId: uploadId, ObjectParts: []options.ObjectPart{part}})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+	assert.NotContains(t, f.objects, "resumed")
+	assert.Nil(t, d.CompleteMultipart(ctx, "resumed", options.CompleteMultipart{UploadId: uploadId, ObjectParts: []options.ObjectPart{part}}))
+	assert.Equal(t, []byte("c"), f.objects["resumed"].data)
+
+	// an aborted upload doesn't create the object.
+	uploadId, err = d.CreateMultipart(ctx, "aborted", options.CreateMultipart{})
+	assert.Nil(t, err)
+	_, err = d.WriteMultipart(ctx, "aborted", options.WriteMultipart{UploadId: uploadId, PartNumber: 1, Size: 1}, bytes.NewReader([]byte("c")))
+	assert.Nil(t, err)
+	assert.Nil(t, d.AbortMultipart(ctx, "aborted", options.AbortMultipart{UploadId: uploadId}))
+	assert.NotContains(t, f.objects, "aborted")
+	assert.Len(t, f.uploads, 0)
+
+	_, err = d.ListMultipart(ctx, "/", options.ListMultipart{})
+	assert.Equal(t, errors.ErrUnsupportedMethod, err)
+}
+
+func TestPreSign(t *testing.T) {
+	f := newFakeServer(t)
+	d := setupDriver(t, f, "/root/")
+	ctx := context.Background()
+
+	content := []byte("Hello,World!")
+	req, err := d.PreSign(ctx, "a b.txt", options.PreSignOptions{Op: options.WriteOp, Headers: http.Header{"Content-Type": {"text/plain"}}})
+	assert.Nil(t, err)
+	assert.Equal(t, http.MethodPut, req.Method)
+	assert.Equal(t, "content-type;host", req.URL.Query().Get("X-Goog-SignedHeaders"))
+	req.Body = io.NopCloser(bytes.NewReader(content))
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, content, f.objects["root/a b.txt"].data)
+
+	offset, size := uint64(6), uint64(5)
+	req, err = d.PreSign(ctx, "a b.txt", options.PreSignOptions{
+		Op:          options.ReadOp,
+		ReadOptions: &options.ReadOptions{Offset: &offset, Size: &size},
+		Response:    &options.ResponseOverrides{ContentDisposition: "attachment; filename=\"a.txt\""},
+	})
+	assert.Nil(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	b, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, "World", string(b))
+	assert.Equal(t, "attachment; filename=\"a.txt\"", resp.Header.Get("Content-Disposition"))
+
+	// the signed headers can't be changed
+	req.Header.Set("Range", "bytes=0-4")
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// urls are not signed without service account
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	anonymous, err := NewDriver(ctx, Options{Bucket: f.bucket, Endpoint: f.URL})
+	assert.Nil(t, err)
+	assert.False(t, anonymous.Metadata().Capability().Has(interfaces.PreSign))
+	_, err = anonymous.PreSign(ctx, "a b.txt", options.PreSignOptions{Op: options.ReadOp})
+	assert.Equal(t, errors.ErrUnsupportedMethod, err)
+}
@@ -0,0 +1,52 @@
+package gcs
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/object"
+	"strconv"
+	"time"
+)
+
+// Object the object resource, see https://cloud.google.com/storage/docs/json_api/v1/objects#resource
+type Object struct {
+	Name         string    `json:"name"`
+	Bucket       string    `json:"bucket"`
+	Generation   string    `json:"generation"`
+	Size         string    `json:"size"`
+	MD5Hash      string    `json:"md5Hash"`
+	ETag         string    `json:"etag"`
+	StorageClass string    `json:"storageClass"`
+	ContentType  string    `json:"contentType"`
+	Updated      time.Time `json:"updated"`
+}
+
+// metadata converts the resource into the metadata of mode, latest reports whether the generation is the live one.
+func (o Object) metadata(mode interfaces.ObjectMode, latest bool) (interfaces.ObjectMetadata, error) {
+	size, err := strconv.ParseUint(o.Size, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size %q: %w", o.Size, err)
+	}
+	opts := []object.MetadataOptions{
+		object.SetMode(mode),
+		object.SetMetadata(size, o.Updated, o.ETag),
+		object.SetStorageClass(o.StorageClass),
+	}
+	// the etag of gcs is not the md5, the md5Hash is base64 encoded.
+	if raw, err := base64.StdEncoding.DecodeString(o.MD5Hash); err == nil && len(raw) > 0 {
+		opts = append(opts, object.SetContentMD5(hex.EncodeToString(raw)))
+	}
+	if o.Generation != "" {
+		opts = append(opts, object.SetVersion(o.Generation, latest, false))
+	}
+	return object.NewMetadata(opts...)
+}
+
+// Objects the response of listing objects, see https://cloud.google.com/storage/docs/json_api/v1/objects/list
+type Objects struct {
+	Prefixes      []string `json:"prefixes"`
+	Items         []Object `json:"items"`
+	NextPageToken string   `json:"nextPageToken"`
+}
@@ -0,0 +1,31 @@
+package gcs
+
+import "net/http"
+
+const (
+	// DefaultEndpoint the endpoint of both JSON and XML API
+	DefaultEndpoint = "https://storage.googleapis.com"
+	// DefaultScope the OAuth2 scope of the access tokens
+	DefaultScope = "https://www.googleapis.com/auth/devstorage.read_write"
+)
+
+type Options struct {
+	Bucket string
+	// Endpoint defaults to DefaultEndpoint, e.g. `http://127.0.0.1:4443` of a fake server.
+	Endpoint string
+	Root     string
+
+	// Credential the content of the service account json key.
+	Credential string
+	// CredentialPath the path of the service account json key, defaults to `GOOGLE_APPLICATION_CREDENTIALS` env.
+	CredentialPath string
+	// Token a static OAuth2 access token, it's used instead of the service account if set.
+	Token string
+	// TokenProvider overrides the tokens of both Token and the service account if set.
+	TokenProvider TokenProvider
+	// Scope the OAuth2 scope requested by the service account, defaults to DefaultScope.
+	Scope string
+
+	// Client the http client, defaults to http.DefaultClient.
+	Client *http.Client
+}
@@ -0,0 +1,145 @@
+package gcs
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/options"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// chunkSize the granularity of non-final chunks of resumable uploads
+const chunkSize = 256 * 1024
+
+// statusResumeIncomplete the status of an incomplete resumable upload
+const statusResumeIncomplete = 308
+
+// errSessionNotFound the upload is not started by this driver, its tail and parts can't be recovered
+// from the server, so it's not resumed.
+var errSessionNotFound = fmt.Errorf("%w: the resumable upload is not started by this driver", errors.ErrNotFound)
+
+// session the progress of a resumable upload.
+//
+// The parts of multipart map onto the chunks of the session in order, the bytes not aligned to chunkSize
+// are kept as the tail until the next part or the completion, so the parts MUST be written sequentially
+// by the driver which creates the upload.
+type session struct {
+	offset uint64
+	tail   []byte
+	// parts the parts written in order.
+	parts []options.ObjectPart
+}
+
+// lastPartNumber returns the number of the last written part, or 0 if none is written.
+func (s session) lastPartNumber() uint {
+	if len(s.parts) == 0 {
+		return 0
+	}
+	return s.parts[len(s.parts)-1].GetPartNumber()
+}
+
+// matches reports whether the parts are the written ones in order.
+func (s session) matches(parts []options.ObjectPart) bool {
+	if len(parts) != len(s.parts) {
+		return false
+	}
+	for i, part := range parts {
+		if part.GetPartNumber() != s.parts[i].GetPartNumber() || part.GetETag() != s.parts[i].GetETag() {
+			return false
+		}
+	}
+	return true
+}
+
+type sessions struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+func newSessions() *sessions {
+	return &sessions{sessions: map[string]*session{}}
+}
+
+func (s *sessions) get(uploadId string) (session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ss, ok := s.sessions[uploadId]; ok {
+		return *ss, true
+	}
+	return session{}, false
+}
+
+func (s *sessions) set(uploadId string, ss session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[uploadId] = &ss
+}
+
+func (s *sessions) delete(uploadId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, uploadId)
+}
+
+// persistedSize parses the `Range: bytes=0-N` header of 308 responses, no header means nothing is persisted.
+func persistedSize(resp *http.Response) (uint64, error) {
+	r := resp.Header.Get("Range")
+	if r == "" {
+		return 0, nil
+	}
+	idx := strings.LastIndex(r, "-")
+	if !strings.HasPrefix(r, "bytes=0-") || idx < 0 {
+		return 0, fmt.Errorf("invalid range %q", r)
+	}
+	end, err := strconv.ParseUint(r[idx+1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid range %q: %w", r, err)
+	}
+	return end + 1, nil
+}
+
+// writeChunks uploads the tail and the part of size aligned to chunkSize, the rest is kept as the new tail.
+// it returns the quoted md5 of the part as the etag.
+func (d *Driver) writeChunks(ctx context.Context, uploadId string, ss session, size uint64, reader io.Reader) (session, string, error) {
+	hash := md5.New()
+	body := io.MultiReader(bytes.NewReader(ss.tail), io.TeeReader(reader, hash))
+	total := uint64(len(ss.tail)) + size
+	aligned := total - total%chunkSize
+
+	if aligned > 0 {
+		resp, err := d.UploadChunk(ctx, uploadId, ss.offset, aligned, nil, io.LimitReader(body, int64(aligned)))
+		if err != nil {
+			return ss, "", err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != statusResumeIncomplete {
+			return ss, "", errors.ParseGcsError(errors.ErrWriteMultipartFailed, uploadId, resp)
+		}
+		persisted, err := persistedSize(resp)
+		if err != nil {
+			return ss, "", err
+		}
+		if persisted != ss.offset+aligned {
+			return ss, "", fmt.Errorf("%d bytes are persisted, expected %d", persisted, ss.offset+aligned)
+		}
+	}
+
+	tail := make([]byte, total-aligned)
+	if _, err := io.ReadFull(body, tail); err != nil {
+		return ss, "", err
+	}
+	return session{offset: ss.offset + aligned, tail: tail, parts: ss.parts}, "\"" + hex.EncodeToString(hash.Sum(nil)) + "\"", nil
+}
+
+// finalize uploads the tail as the final chunk, the object is created.
+func (d *Driver) finalize(ctx context.Context, uploadId string, ss session) (*http.Response, error) {
+	total := ss.offset + uint64(len(ss.tail))
+	return d.UploadChunk(ctx, uploadId, ss.offset, uint64(len(ss.tail)), &total, bytes.NewReader(ss.tail))
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"context"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/object"
+	"net/http"
+	"net/url"
+)
+
+// DirStream lists the dir by parsing its index page, the entries are returned in a single page.
+type DirStream struct {
+	*Driver
+	root string
+	path string
+
+	done bool
+}
+
+func (d *DirStream) NextPage(ctx context.Context) ([]interfaces.Entry, error) {
+	if d.done {
+		return nil, nil
+	}
+	abs, err := d.absPath(d.path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrListFailed, err)
+	}
+	resp, err := d.GetIndex(ctx, abs)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrListFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.ParseHttpError(errors.ErrListFailed, d.path, resp)
+	}
+	var list []listEntry
+	if d.listFormat == ListJSON {
+		list, err = parseJSON(resp.Body)
+	} else {
+		var dir *url.URL
+		if dir, err = url.Parse(d.buildUrl(abs)); err != nil {
+			return nil, errors.Wrap(errors.ErrListFailed, err)
+		}
+		list, err = parseAutoindex(resp.Body, dir)
+	}
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrListFailed, err)
+	}
+	d.done = true
+
+	prefix := d.path
+	if prefix == "/" {
+		prefix = ""
+	}
+	entries := make([]interfaces.Entry, 0, len(list))
+	for _, e := range list {
+		mode, path := interfaces.FILE, prefix+e.name
+		if e.dir {
+			mode, path = interfaces.DIR, path+"/"
+		}
+		meta, err := object.NewMetadata(
+			object.SetMode(mode),
+			object.SetMetadataFromHeader(e.header),
+		)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrListFailed, err)
+		}
+		entries = append(entries,
+			object.NewEntry(
+				d.Driver,
+				path,
+				meta,
+				// the HTML listings don't carry the metadata
+				!e.dir && e.header != nil),
+		)
+	}
+	return entries, nil
+}
+
+func NewDirStream(d *Driver, root, path string) interfaces.ObjectPageStream {
+	return &DirStream{
+		Driver: d,
+		root:   root,
+		path:   path,
+		done:   false,
+	}
+}
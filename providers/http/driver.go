@@ -0,0 +1,62 @@
+package http
+
+import (
+	"context"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/utils"
+	"net/http"
+)
+
+// absPath returns the path towards the endpoint, e.g. `path/to/root/dir/`.
+func (d *Driver) absPath(path string) (string, error) {
+	return utils.BuildAbsPath(d.root, path)
+}
+
+func (d *Driver) buildUrl(abs string) string {
+	return d.endpoint + "/" + utils.EncodePath(abs)
+}
+
+func (d *Driver) newRequest(ctx context.Context, method, abs string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, d.buildUrl(abs), nil)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case d.username != "":
+		req.SetBasicAuth(d.username, d.password)
+	case d.token != "":
+		req.Header.Set("Authorization", "Bearer "+d.token)
+	}
+	return req, nil
+}
+
+func (d *Driver) GetFile(ctx context.Context, abs string, offset, size *uint64) (*http.Response, error) {
+	req, err := d.newRequest(ctx, http.MethodGet, abs)
+	if err != nil {
+		return nil, err
+	}
+	if offset != nil || size != nil {
+		req.Header.Set("Range", options.NewBytesRange(offset, size).String())
+	}
+	return d.client.Do(req)
+}
+
+func (d *Driver) HeadFile(ctx context.Context, abs string) (*http.Response, error) {
+	req, err := d.newRequest(ctx, http.MethodHead, abs)
+	if err != nil {
+		return nil, err
+	}
+	return d.client.Do(req)
+}
+
+// GetIndex requests the listing of the dir, the JSON listing is accepted if the format is JSON.
+func (d *Driver) GetIndex(ctx context.Context, abs string) (*http.Response, error) {
+	req, err := d.newRequest(ctx, http.MethodGet, abs)
+	if err != nil {
+		return nil, err
+	}
+	if d.listFormat == ListJSON {
+		req.Header.Set("Accept", "application/json")
+	}
+	return d.client.Do(req)
+}
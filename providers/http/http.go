@@ -0,0 +1,190 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"github.com/senrok/yadal/constants"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/logger"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/providers"
+	"github.com/senrok/yadal/utils"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type Driver struct {
+	endpoint   string
+	root       string
+	username   string
+	password   string
+	token      string
+	listFormat ListFormat
+	client     *http.Client
+	logger.Logger
+}
+
+func (d *Driver) Metadata() interfaces.Metadata {
+	capability := interfaces.Read
+	if d.listFormat != ListNone {
+		capability |= interfaces.List
+	}
+	return providers.NewMetadata(interfaces.Http, d.root, d.endpoint, capability)
+}
+
+func (d *Driver) Create(ctx context.Context, path string, args options.CreateOptions) error {
+	return errors.ErrUnsupportedMethod
+}
+
+// Read gets the file with the range, the range is applied on the body if the server ignores it and replies 200.
+func (d *Driver) Read(ctx context.Context, path string, args options.ReadOptions) (io.ReadCloser, error) {
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	abs, err := d.absPath(path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrReadFailed, err)
+	}
+	resp, err := d.GetFile(ctx, abs, args.Offset, args.Size)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrReadFailed, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		return resp.Body, nil
+	case http.StatusOK:
+		if args.Offset != nil && *args.Offset > 0 {
+			if _, err = io.CopyN(io.Discard, resp.Body, int64(*args.Offset)); err != nil && err != io.EOF {
+				_ = resp.Body.Close()
+				return nil, errors.Wrap(errors.ErrReadFailed, err)
+			}
+		}
+		if args.Size != nil {
+			return utils.NewReadCloser(io.LimitReader(resp.Body, int64(*args.Size)), resp.Body), nil
+		}
+		return resp.Body, nil
+	default:
+		defer resp.Body.Close()
+		return nil, errors.ParseHttpError(errors.ErrReadFailed, path, resp)
+	}
+}
+
+func (d *Driver) Write(ctx context.Context, path string, args options.WriteOptions, reader io.Reader) (interfaces.WriteResult, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+// Stat heads the file, the paths with the trailing slash are dirs.
+// The etag of the static servers is not the md5.
+func (d *Driver) Stat(ctx context.Context, path string, args options.StatOptions) (interfaces.ObjectMetadata, error) {
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	if path == "/" {
+		return object.Metadata{ObjectMode: interfaces.DIR}, nil
+	}
+	abs, err := d.absPath(path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrStatFailed, err)
+	}
+	resp, err := d.HeadFile(ctx, abs)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrStatFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.ParseHttpError(errors.ErrStatFailed, path, resp)
+	}
+	mode := interfaces.FILE
+	if strings.HasSuffix(path, "/") {
+		mode = interfaces.DIR
+	}
+	header := resp.Header.Clone()
+	etag := header.Get(constants.ETag)
+	header.Del(constants.ETag)
+	meta, err := object.NewMetadata(
+		object.SetMode(mode),
+		object.SetMetadataFromHeader(header),
+		object.SetETag(etag),
+	)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrStatFailed, err)
+	}
+	return meta, nil
+}
+
+func (d *Driver) Delete(ctx context.Context, path string, args options.DeleteOptions) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) List(ctx context.Context, path string, args options.ListOptions) (interfaces.ObjectStream, error) {
+	if d.listFormat == ListNone {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	return object.NewObjectStream(NewDirStream(d, d.root, path)), nil
+}
+
+func (d *Driver) ListVersions(ctx context.Context, path string, args options.ListVersions) (interfaces.ObjectStream, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) PreSign(ctx context.Context, path string, args options.PreSignOptions) (*http.Request, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) GetTags(ctx context.Context, path string, args options.GetTags) (map[string]string, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) SetTags(ctx context.Context, path string, args options.SetTags) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) CreateMultipart(ctx context.Context, path string, args options.CreateMultipart) (string, error) {
+	return "", errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.Reader) (interfaces.ObjectPart, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) CompleteMultipart(ctx context.Context, path string, args options.CompleteMultipart) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) AbortMultipart(ctx context.Context, path string, args options.AbortMultipart) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) ListMultipart(ctx context.Context, path string, args options.ListMultipart) ([]interfaces.MultipartUpload, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+// NewDriver returns a read-only driver of the files served over HTTP, the requests are authorized with Basic
+// authentication if the username is set, or with the Bearer token, otherwise they are anonymous.
+func NewDriver(ctx context.Context, opt Options) (interfaces.Accessor, error) {
+	if opt.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required")
+	}
+	u, err := url.Parse(strings.TrimSuffix(opt.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	client := opt.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Driver{
+		endpoint:   u.String(),
+		root:       utils.NormalizeRoot(opt.Root),
+		username:   opt.Username,
+		password:   opt.Password,
+		token:      opt.Token,
+		listFormat: opt.ListFormat,
+		client:     client,
+	}, nil
+}
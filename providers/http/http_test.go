@@ -0,0 +1,195 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/options"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newServer serves the temp dir under `/static/` by http.FileServer, the requests are authorized with Basic authentication.
+func newServer(t *testing.T) (*httptest.Server, string) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"root/dir/a b.txt":   "Hello,World!",
+		"root/dir/sub/c.txt": "c",
+		"root/d.txt":         "d",
+	} {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0644))
+	}
+	handler := http.StripPrefix("/static/", http.FileServer(http.Dir(dir)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, dir
+}
+
+func setupDriver(t *testing.T, opt Options) interfaces.Accessor {
+	d, err := NewDriver(context.Background(), opt)
+	assert.Nil(t, err)
+	return d
+}
+
+func TestDriver(t *testing.T) {
+	server, _ := newServer(t)
+	d := setupDriver(t, Options{Endpoint: server.URL + "/static/", Root: "/root/", Username: "user", Password: "pass"})
+	ctx := context.Background()
+	assert.Equal(t, interfaces.Http, d.Metadata().Provider())
+	assert.True(t, d.Metadata().Capability().Has(interfaces.Read))
+	assert.False(t, d.Metadata().Capability().Has(interfaces.List))
+	assert.False(t, d.Metadata().Capability().Has(interfaces.Write))
+
+	meta, err := d.Stat(ctx, "dir/a b.txt", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.FILE, meta.Mode())
+	assert.Equal(t, uint64(12), *meta.ContentLength())
+	assert.NotNil(t, meta.LastModified())
+	assert.Nil(t, meta.ContentMD5())
+
+	meta, err = d.Stat(ctx, "dir/", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.DIR, meta.Mode())
+
+	_, err = d.Stat(ctx, "not-exist", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+	reader, err := d.Read(ctx, "dir/a b.txt", options.ReadOptions{})
+	assert.Nil(t, err)
+	b, _ := io.ReadAll(reader)
+	assert.Nil(t, reader.Close())
+	assert.Equal(t, "Hello,World!", string(b))
+
+	offset, size := uint64(6), uint64(5)
+	reader, err = d.Read(ctx, "dir/a b.txt", options.ReadOptions{Offset: &offset, Size: &size})
+	assert.Nil(t, err)
+	b, _ = io.ReadAll(reader)
+	assert.Nil(t, reader.Close())
+	assert.Equal(t, "World", string(b))
+
+	_, err = d.Read(ctx, "not-exist", options.ReadOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+	_, err = d.List(ctx, "dir/", options.ListOptions{})
+	assert.Equal(t, errors.ErrUnsupportedMethod, err)
+	_, err = d.Write(ctx, "a", options.WriteOptions{}, bytes.NewReader(nil))
+	assert.Equal(t, errors.ErrUnsupportedMethod, err)
+	assert.Equal(t, errors.ErrUnsupportedMethod, d.Create(ctx, "a", options.CreateOptions{}))
+	assert.Equal(t, errors.ErrUnsupportedMethod, d.Delete(ctx, "a", options.DeleteOptions{}))
+
+	// unauthorized
+	d = setupDriver(t, Options{Endpoint: server.URL + "/static/", Root: "/root/"})
+	_, err = d.Read(ctx, "dir/a b.txt", options.ReadOptions{})
+	assert.True(t, errors.Is(err, errors.ErrPermissionDenied))
+}
+
+func TestReadIgnoringRange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("Hello,World!"))
+	}))
+	t.Cleanup(server.Close)
+	d := setupDriver(t, Options{Endpoint: server.URL})
+
+	offset, size := uint64(6), uint64(5)
+	reader, err := d.Read(context.Background(), "a", options.ReadOptions{Offset: &offset, Size: &size})
+	assert.Nil(t, err)
+	b, _ := io.ReadAll(reader)
+	assert.Nil(t, reader.Close())
+	assert.Equal(t, "World", string(b))
+}
+
+func list(t *testing.T, d interfaces.Accessor, path string) map[string]interfaces.Entry {
+	ctx := context.Background()
+	stream, err := d.List(ctx, path, options.ListOptions{})
+	assert.Nil(t, err)
+	entries := map[string]interfaces.Entry{}
+	for stream.HasNext() {
+		entry, err := stream.Next(ctx)
+		assert.Nil(t, err)
+		entries[entry.Path()] = entry
+	}
+	return entries
+}
+
+func TestListAutoindex(t *testing.T) {
+	server, _ := newServer(t)
+	d := setupDriver(t, Options{Endpoint: server.URL + "/static/", Root: "/root/", Username: "user", Password: "pass", ListFormat: ListAutoindex})
+	assert.True(t, d.Metadata().Capability().Has(interfaces.Read, interfaces.List))
+
+	entries := list(t, d, "dir/")
+	assert.Len(t, entries, 2)
+	assert.Equal(t, interfaces.FILE, entries["dir/a b.txt"].Metadata().Mode())
+	assert.Equal(t, interfaces.DIR, entries["dir/sub/"].Metadata().Mode())
+
+	entries = list(t, d, "/")
+	assert.Len(t, entries, 2)
+	assert.Contains(t, entries, "dir/")
+	assert.Contains(t, entries, "d.txt")
+
+	_, err := NewDirStream(d.(*Driver), "/root/", "not-exist/").NextPage(context.Background())
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+}
+
+func TestListJSON(t *testing.T) {
+	mtime := time.Date(2023, 10, 18, 3, 3, 23, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/root/dir/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+		size := uint64(12)
+		_ = json.NewEncoder(w).Encode([]jsonEntry{
+			{Name: "a b.txt", Type: "file", MTime: mtime.Format(http.TimeFormat), Size: &size},
+			{Name: "sub", Type: "directory", MTime: mtime.Format(http.TimeFormat)},
+		})
+	}))
+	t.Cleanup(server.Close)
+	d := setupDriver(t, Options{Endpoint: server.URL, Root: "/root/", ListFormat: ListJSON})
+
+	entries := list(t, d, "dir/")
+	assert.Len(t, entries, 2)
+	file := entries["dir/a b.txt"].Metadata()
+	assert.Equal(t, interfaces.FILE, file.Mode())
+	assert.Equal(t, uint64(12), *file.ContentLength())
+	assert.True(t, mtime.Equal(*file.LastModified()))
+	assert.Equal(t, interfaces.DIR, entries["dir/sub/"].Metadata().Mode())
+}
+
+func TestParseAutoindex(t *testing.T) {
+	dir, _ := url.Parse("https://example.com/static/dir/")
+	// the pages of Apache mod_autoindex link to the parent and the sorting
+	entries, err := parseAutoindex(strings.NewReader(`<html><body><h1>Index of /static/dir</h1><table>
+<tr><th><a href="?C=N;O=D">Name</a></th></tr>
+<tr><td><a href="/static/">Parent Directory</a></td></tr>
+<tr><td><a href="a%20b.txt">a b.txt</a></td></tr>
+<tr><td><a href="sub/">sub/</a></td></tr>
+<tr><td><a href="/static/dir/e.txt">e.txt</a></td></tr>
+<tr><td><a href="sub/c.txt">c.txt</a></td></tr>
+<tr><td><a href="https://other.com/static/dir/f.txt">f.txt</a></td></tr>
+<tr><td><a href="#top">top</a></td></tr>
+</table><a href="../">../</a><a href="a%20b.txt">a b.txt</a></body></html>`), dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []listEntry{
+		{name: "a b.txt"},
+		{name: "sub", dir: true},
+		{name: "e.txt"},
+	}, entries)
+}
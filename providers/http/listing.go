@@ -0,0 +1,118 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/senrok/yadal/constants"
+	"golang.org/x/net/html"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type ListFormat int
+
+const (
+	// ListNone the dir listings are not served.
+	ListNone ListFormat = iota
+	// ListAutoindex the HTML listings of the links to the children,
+	// e.g. nginx autoindex, Apache mod_autoindex and Go http.FileServer.
+	ListAutoindex
+	// ListJSON the JSON listings of nginx `autoindex_format json`.
+	ListJSON
+)
+
+// listEntry a child of the dir, the header carries the content length and the last modified if they are listed.
+type listEntry struct {
+	name   string
+	dir    bool
+	header http.Header
+}
+
+// parseAutoindex returns the children linked by the listing of the dir url,
+// the links to the other pages, e.g. the parent and the sorting links, are skipped.
+func parseAutoindex(body io.Reader, dir *url.URL) ([]listEntry, error) {
+	var entries []listEntry
+	seen := map[string]bool{}
+	tokenizer := html.NewTokenizer(body)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if tokenizer.Err() == io.EOF {
+				return entries, nil
+			}
+			return nil, tokenizer.Err()
+		case html.StartTagToken:
+			name, hasAttr := tokenizer.TagName()
+			if string(name) != "a" || !hasAttr {
+				continue
+			}
+			for {
+				key, value, more := tokenizer.TagAttr()
+				if string(key) == "href" {
+					if entry, ok := childOf(dir, string(value)); ok && !seen[entry.name] {
+						seen[entry.name] = true
+						entries = append(entries, entry)
+					}
+				}
+				if !more {
+					break
+				}
+			}
+		}
+	}
+}
+
+// childOf returns the child of the dir referred by the href, ok is false if it refers to the others.
+func childOf(dir *url.URL, href string) (entry listEntry, ok bool) {
+	ref, err := url.Parse(href)
+	if err != nil || ref.RawQuery != "" || (ref.Path == "" && ref.Fragment != "") {
+		return entry, false
+	}
+	u := dir.ResolveReference(ref)
+	if u.Scheme != dir.Scheme || u.Host != dir.Host || !strings.HasPrefix(u.Path, dir.Path) {
+		return entry, false
+	}
+	name := strings.TrimPrefix(u.Path, dir.Path)
+	entry.dir = strings.HasSuffix(name, "/")
+	entry.name = strings.TrimSuffix(name, "/")
+	if entry.name == "" || strings.Contains(entry.name, "/") {
+		return entry, false
+	}
+	return entry, true
+}
+
+// jsonEntry the entry of nginx `autoindex_format json`, the mtime is in RFC 7231.
+type jsonEntry struct {
+	Name  string  `json:"name"`
+	Type  string  `json:"type"`
+	MTime string  `json:"mtime"`
+	Size  *uint64 `json:"size"`
+}
+
+func parseJSON(body io.Reader) ([]listEntry, error) {
+	var output []jsonEntry
+	if err := json.NewDecoder(body).Decode(&output); err != nil {
+		return nil, err
+	}
+	entries := make([]listEntry, 0, len(output))
+	for _, e := range output {
+		if e.Name == "" || e.Name == "." || e.Name == ".." {
+			continue
+		}
+		header := http.Header{}
+		if e.Size != nil {
+			header.Set(constants.ContentLength, strconv.FormatUint(*e.Size, 10))
+		}
+		if e.MTime != "" {
+			header.Set(constants.LastModified, e.MTime)
+		}
+		entries = append(entries, listEntry{
+			name:   e.Name,
+			dir:    e.Type == "directory",
+			header: header,
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,21 @@
+package http
+
+import "net/http"
+
+type Options struct {
+	// Endpoint the url serving the files, e.g. `https://example.com/assets/`
+	Endpoint string
+	Root     string
+
+	// Username and Password are sent with Basic authentication if Username is set.
+	Username string
+	Password string
+	// Token is sent as the Bearer token if Username is not set.
+	Token string
+
+	// ListFormat the format of the dir listings, List is unsupported if it's not set.
+	ListFormat ListFormat
+
+	// Client the http client, defaults to http.DefaultClient.
+	Client *http.Client
+}
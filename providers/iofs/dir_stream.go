@@ -0,0 +1,73 @@
+package iofs
+
+import (
+	"context"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/utils"
+	"io/fs"
+	"os"
+)
+
+// DirStream lists the entries of the dir by fs.ReadDir, they are returned in a single page.
+type DirStream struct {
+	*Driver
+	root string
+	path string
+
+	done bool
+}
+
+func (d *DirStream) NextPage(ctx context.Context) ([]interfaces.Entry, error) {
+	if d.done {
+		return nil, nil
+	}
+	info, name, err := d.stat(d.path)
+	if err == nil && !info.IsDir() {
+		err = os.ErrNotExist
+	}
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrListFailed, err, d.path)
+	}
+	dirEntries, err := fs.ReadDir(d.fsys, name)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrListFailed, err, d.path)
+	}
+	d.done = true
+
+	prefix := "/"
+	if name != "." {
+		prefix += name + "/"
+	}
+	entries := make([]interfaces.Entry, 0, len(dirEntries))
+	for _, e := range dirEntries {
+		info, err := e.Info()
+		if err != nil {
+			return nil, errors.ParseFsError(errors.ErrListFailed, err, d.path)
+		}
+		meta, err := object.NewMetadata(object.SetFromFileInfo(info))
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrListFailed, err)
+		}
+		abs := prefix + e.Name()
+		if e.IsDir() {
+			abs += "/"
+		}
+		path, err := utils.BuildRealPath(d.root, abs)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrListFailed, err)
+		}
+		entries = append(entries, object.NewEntry(d.Driver, path, meta, meta.Mode() == interfaces.FILE))
+	}
+	return entries, nil
+}
+
+func NewDirStream(d *Driver, root, path string) interfaces.ObjectPageStream {
+	return &DirStream{
+		Driver: d,
+		root:   root,
+		path:   path,
+		done:   false,
+	}
+}
@@ -0,0 +1,175 @@
+package iofs
+
+import (
+	"context"
+	"fmt"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/logger"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/providers"
+	"github.com/senrok/yadal/utils"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+)
+
+type Driver struct {
+	fsys fs.FS
+	root string
+	logger.Logger
+}
+
+func (d *Driver) Metadata() interfaces.Metadata {
+	return providers.NewMetadata(interfaces.IoFs, d.root, fmt.Sprintf("%T", d.fsys), interfaces.Read|interfaces.List)
+}
+
+// name returns the name of the path in the file system, e.g. `path/to/root/dir`, the name of the top dir is `.`.
+func (d *Driver) name(path string) (string, error) {
+	if path == "/" {
+		path = ""
+	}
+	p, err := utils.BuildAbsPath(d.root, path)
+	if err != nil {
+		return "", err
+	}
+	p = strings.TrimSuffix(p, "/")
+	if p == "" {
+		return ".", nil
+	}
+	return p, nil
+}
+
+// stat returns the info of the path, the dirs are only found by the paths ending with `/`, and the files without.
+func (d *Driver) stat(path string) (fs.FileInfo, string, error) {
+	name, err := d.name(path)
+	if err != nil {
+		return nil, "", err
+	}
+	info, err := fs.Stat(d.fsys, name)
+	if err != nil {
+		return nil, "", err
+	}
+	if info.IsDir() != (name == "." || strings.HasSuffix(path, "/")) {
+		return nil, "", os.ErrNotExist
+	}
+	return info, name, nil
+}
+
+func (d *Driver) Create(ctx context.Context, path string, args options.CreateOptions) error {
+	return errors.ErrUnsupportedMethod
+}
+
+// Read seeks the offset if the file is seekable, otherwise the bytes before the offset are discarded.
+func (d *Driver) Read(ctx context.Context, path string, args options.ReadOptions) (io.ReadCloser, error) {
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	info, name, err := d.stat(path)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrReadFailed, err, path)
+	}
+	if info.IsDir() {
+		return nil, errors.ParseFsError(errors.ErrReadFailed, os.ErrNotExist, path)
+	}
+	file, err := d.fsys.Open(name)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrReadFailed, err, path)
+	}
+	if args.Offset != nil && *args.Offset > 0 {
+		// some files refuse to seek beyond the end, e.g. the files of embed.FS
+		offset := int64(*args.Offset)
+		if offset > info.Size() {
+			offset = info.Size()
+		}
+		if seeker, ok := file.(io.Seeker); ok {
+			_, err = seeker.Seek(offset, io.SeekStart)
+		} else {
+			_, err = io.CopyN(io.Discard, file, offset)
+			if err == io.EOF {
+				err = nil
+			}
+		}
+		if err != nil {
+			_ = file.Close()
+			return nil, errors.ParseFsError(errors.ErrReadFailed, err, path)
+		}
+	}
+	if args.Size != nil {
+		return utils.NewReadCloser(io.LimitReader(file, int64(*args.Size)), file), nil
+	}
+	return file, nil
+}
+
+func (d *Driver) Write(ctx context.Context, path string, args options.WriteOptions, reader io.Reader) (interfaces.WriteResult, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) Stat(ctx context.Context, path string, args options.StatOptions) (interfaces.ObjectMetadata, error) {
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	info, _, err := d.stat(path)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrStatFailed, err, path)
+	}
+	return object.NewMetadata(object.SetFromFileInfo(info))
+}
+
+func (d *Driver) Delete(ctx context.Context, path string, args options.DeleteOptions) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) List(ctx context.Context, path string, args options.ListOptions) (interfaces.ObjectStream, error) {
+	return object.NewObjectStream(NewDirStream(d, d.root, path)), nil
+}
+
+func (d *Driver) ListVersions(ctx context.Context, path string, args options.ListVersions) (interfaces.ObjectStream, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) PreSign(ctx context.Context, path string, args options.PreSignOptions) (*http.Request, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) GetTags(ctx context.Context, path string, args options.GetTags) (map[string]string, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) SetTags(ctx context.Context, path string, args options.SetTags) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) CreateMultipart(ctx context.Context, path string, args options.CreateMultipart) (string, error) {
+	return "", errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.Reader) (interfaces.ObjectPart, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) CompleteMultipart(ctx context.Context, path string, args options.CompleteMultipart) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) AbortMultipart(ctx context.Context, path string, args options.AbortMultipart) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) ListMultipart(ctx context.Context, path string, args options.ListMultipart) ([]interfaces.MultipartUpload, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+// NewDriver returns a read-only driver of the file system, e.g. the assets embedded by embed.FS.
+func NewDriver(ctx context.Context, opt Options) (interfaces.Accessor, error) {
+	if opt.FS == nil {
+		return nil, fmt.Errorf("fs is required")
+	}
+	return &Driver{
+		fsys: opt.FS,
+		root: utils.NormalizeRoot(opt.Root),
+	}, nil
+}
@@ -0,0 +1,135 @@
+package iofs
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/options"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+//go:embed testdata/assets
+var assets embed.FS
+
+// noSeekFS hides the Seek of the files.
+type noSeekFS struct {
+	fs.FS
+}
+
+func (f noSeekFS) Open(name string) (fs.File, error) {
+	file, err := f.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ fs.File }{file}, nil
+}
+
+func TestDriver(t *testing.T) {
+	ctx := context.Background()
+	mapFS := fstest.MapFS{
+		"testdata/assets/hello.txt": {Data: []byte("Hello,World!"), ModTime: time.Unix(1136214245, 0)},
+		"testdata/assets/sub/x.txt": {Data: []byte("x")},
+	}
+	for name, fsys := range map[string]fs.FS{
+		"embed":  assets,
+		"map":    mapFS,
+		"noSeek": noSeekFS{mapFS},
+	} {
+		acc, err := NewDriver(ctx, Options{FS: fsys, Root: "/testdata/assets/"})
+		assert.Nil(t, err)
+		assert.Equal(t, interfaces.IoFs, acc.Metadata().Provider())
+		assert.True(t, acc.Metadata().Capability().Has(interfaces.Read, interfaces.List))
+		assert.False(t, acc.Metadata().Capability().Has(interfaces.Write))
+
+		meta, err := acc.Stat(ctx, "hello.txt", options.StatOptions{})
+		assert.Nil(t, err, name)
+		assert.Equal(t, interfaces.FILE, meta.Mode())
+		assert.Equal(t, uint64(12), *meta.ContentLength())
+
+		for _, c := range []struct {
+			offset, size uint64
+			expected     string
+		}{
+			{0, 100, "Hello,World!"},
+			{6, 5, "World"},
+			{12, 5, ""},
+			{20, 5, ""},
+		} {
+			reader, err := acc.Read(ctx, "hello.txt", options.ReadOptions{Offset: &c.offset, Size: &c.size})
+			assert.Nil(t, err, name)
+			b, err := io.ReadAll(reader)
+			assert.Nil(t, err)
+			assert.Nil(t, reader.Close())
+			assert.Equal(t, c.expected, string(b), name)
+		}
+
+		for _, path := range []string{"/", "sub/"} {
+			meta, err = acc.Stat(ctx, path, options.StatOptions{})
+			assert.Nil(t, err, name)
+			assert.Equal(t, interfaces.DIR, meta.Mode())
+		}
+
+		// the dirs are only found by the paths ending with `/`, and the files without
+		for _, path := range []string{"not-exist", "sub", "hello.txt/"} {
+			_, err = acc.Stat(ctx, path, options.StatOptions{})
+			assert.True(t, errors.Is(err, errors.ErrNotFound), path)
+		}
+		_, err = acc.Read(ctx, "sub/", options.ReadOptions{})
+		assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+		_, err = acc.Write(ctx, "file", options.WriteOptions{}, bytes.NewReader(nil))
+		assert.Equal(t, errors.ErrUnsupportedMethod, err)
+	}
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	for root, expected := range map[string]map[string]map[string]interfaces.ObjectMode{
+		"/testdata/assets/": {
+			"/": {
+				"hello.txt": interfaces.FILE,
+				"sub/":      interfaces.DIR,
+			},
+			"sub/": {
+				"sub/x.txt": interfaces.FILE,
+			},
+		},
+		"/": {
+			"testdata/assets/": {
+				"testdata/assets/hello.txt": interfaces.FILE,
+				"testdata/assets/sub/":      interfaces.DIR,
+			},
+			"/": {
+				"testdata/": interfaces.DIR,
+			},
+		},
+	} {
+		acc, err := NewDriver(ctx, Options{FS: assets, Root: root})
+		assert.Nil(t, err)
+		for path, entries := range expected {
+			stream, err := acc.List(ctx, path, options.ListOptions{})
+			assert.Nil(t, err)
+			actual := map[string]interfaces.ObjectMode{}
+			for stream.HasNext() {
+				entry, err := stream.Next(ctx)
+				assert.Nil(t, err)
+				actual[entry.Path()] = entry.Metadata().Mode()
+			}
+			assert.Equal(t, entries, actual, path)
+		}
+	}
+
+	d, err := NewDriver(ctx, Options{FS: assets})
+	assert.Nil(t, err)
+	for _, path := range []string{"not-exist/", "testdata/assets/hello.txt/"} {
+		_, err = NewDirStream(d.(*Driver), "/", path).NextPage(ctx)
+		assert.True(t, errors.Is(err, errors.ErrNotFound), path)
+	}
+}
@@ -0,0 +1,9 @@
+package iofs
+
+import "io/fs"
+
+type Options struct {
+	// FS the file system serving the objects, e.g. embed.FS or fstest.MapFS.
+	FS   fs.FS
+	Root string
+}
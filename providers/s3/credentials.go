@@ -0,0 +1,413 @@
+package s3
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultEC2MetadataEndpoint the EC2 instance metadata service endpoint
+	DefaultEC2MetadataEndpoint = "http://169.254.169.254"
+	// DefaultECSEndpoint the ECS container credentials endpoint, used with `AWS_CONTAINER_CREDENTIALS_RELATIVE_URI`
+	DefaultECSEndpoint = "http://169.254.170.2"
+	// DefaultSTSEndpoint the global STS endpoint
+	DefaultSTSEndpoint = "https://sts.amazonaws.com"
+
+	// expiryWindow refreshes the credentials before they are really expired
+	expiryWindow = 5 * time.Minute
+)
+
+// NewStaticProvider returns a provider of static credentials, the session token is optional.
+func NewStaticProvider(accessKey, secretKey, sessionToken string) credentials.Provider {
+	return &credentials.StaticProvider{Value: credentials.Value{
+		AccessKeyID:     accessKey,
+		SecretAccessKey: secretKey,
+		SessionToken:    sessionToken,
+	}}
+}
+
+// NewEnvProvider returns a provider reading `AWS_ACCESS_KEY_ID`, `AWS_SECRET_ACCESS_KEY` and `AWS_SESSION_TOKEN` env.
+func NewEnvProvider() credentials.Provider {
+	return &credentials.EnvProvider{}
+}
+
+type sharedFileProvider struct {
+	credentialsFile string
+	configFile      string
+	profile         string
+	retrieved       bool
+}
+
+func (s *sharedFileProvider) Retrieve() (credentials.Value, error) {
+	s.retrieved = false
+	profile := s.profile
+	if profile == "" {
+		profile = os.Getenv("AWS_PROFILE")
+	}
+	if profile == "" {
+		profile = "default"
+	}
+	value, err := (&credentials.SharedCredentialsProvider{Filename: s.credentialsFile, Profile: profile}).Retrieve()
+	if err == nil {
+		s.retrieved = true
+		return value, nil
+	}
+	configFile := s.configFile
+	if configFile == "" {
+		configFile = os.Getenv("AWS_CONFIG_FILE")
+	}
+	if configFile == "" {
+		home, herr := os.UserHomeDir()
+		if herr != nil {
+			return credentials.Value{}, err
+		}
+		configFile = filepath.Join(home, ".aws", "config")
+	}
+	// profiles in config file are named as `[profile name]`, except the default one.
+	section := profile
+	if profile != "default" {
+		section = "profile " + profile
+	}
+	value, cerr := (&credentials.SharedCredentialsProvider{Filename: configFile, Profile: section}).Retrieve()
+	if cerr != nil {
+		return credentials.Value{}, err
+	}
+	s.retrieved = true
+	return value, nil
+}
+
+func (s *sharedFileProvider) IsExpired() bool {
+	return !s.retrieved
+}
+
+// NewSharedFileProvider returns a provider reading the profile from shared credentials file,
+// then shared config file.
+//
+//   - credentialsFile defaults to `AWS_SHARED_CREDENTIALS_FILE` env or `~/.aws/credentials`.
+//   - configFile defaults to `AWS_CONFIG_FILE` env or `~/.aws/config`.
+//   - profile defaults to `AWS_PROFILE` env or `default`.
+func NewSharedFileProvider(credentialsFile, configFile, profile string) credentials.Provider {
+	return &sharedFileProvider{
+		credentialsFile: credentialsFile,
+		configFile:      configFile,
+		profile:         profile,
+	}
+}
+
+// metadataCredentials the credentials returned by EC2 and ECS endpoints
+type metadataCredentials struct {
+	Code            string
+	Message         string
+	AccessKeyId     string
+	SecretAccessKey string
+	Token           string
+	Expiration      *time.Time
+}
+
+func (m metadataCredentials) value(provider string) credentials.Value {
+	return credentials.Value{
+		AccessKeyID:     m.AccessKeyId,
+		SecretAccessKey: m.SecretAccessKey,
+		SessionToken:    m.Token,
+		ProviderName:    provider,
+	}
+}
+
+func decodeMetadataCredentials(resp *http.Response) (*metadataCredentials, error) {
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to fetch credentials: %s %s", resp.Status, string(b))
+	}
+	output := &metadataCredentials{}
+	if err := json.NewDecoder(resp.Body).Decode(output); err != nil {
+		return nil, err
+	}
+	if output.Code != "" && output.Code != "Success" {
+		return nil, fmt.Errorf("failed to fetch credentials: %s %s", output.Code, output.Message)
+	}
+	return output, nil
+}
+
+type ec2RoleProvider struct {
+	credentials.Expiry
+	endpoint string
+	client   *http.Client
+}
+
+// token fetches IMDSv2 session token, it returns an empty token if IMDSv2 is unavailable.
+func (e *ec2RoleProvider) token() string {
+	req, err := http.NewRequest(http.MethodPut, e.endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("x-aws-ec2-metadata-token-ttl-seconds", "21600")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return ""
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	b, _ := io.ReadAll(resp.Body)
+	return string(b)
+}
+
+func (e *ec2RoleProvider) get(path, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, e.endpoint+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("x-aws-ec2-metadata-token", token)
+	}
+	return e.client.Do(req)
+}
+
+func (e *ec2RoleProvider) Retrieve() (credentials.Value, error) {
+	const rolesPath = "/latest/meta-data/iam/security-credentials/"
+	token := e.token()
+	resp, err := e.get(rolesPath, token)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	b, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return credentials.Value{}, fmt.Errorf("failed to fetch ec2 role: %s", resp.Status)
+	}
+	role := strings.TrimSpace(strings.SplitN(string(b), "\n", 2)[0])
+	if role == "" {
+		return credentials.Value{}, fmt.Errorf("no ec2 role attached")
+	}
+	resp, err = e.get(rolesPath+role, token)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	output, err := decodeMetadataCredentials(resp)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	if output.Expiration != nil {
+		e.SetExpiration(*output.Expiration, expiryWindow)
+	}
+	return output.value("EC2RoleProvider"), nil
+}
+
+// NewEC2RoleProvider returns a provider fetching the credentials of the role attached to the EC2 instance,
+// via the instance metadata service (IMDSv2, falls back to IMDSv1).
+//
+// endpoint defaults to DefaultEC2MetadataEndpoint, client defaults to a client with 1 second timeout.
+func NewEC2RoleProvider(endpoint string, client *http.Client) credentials.Provider {
+	if endpoint == "" {
+		endpoint = DefaultEC2MetadataEndpoint
+	}
+	if client == nil {
+		client = &http.Client{Timeout: time.Second}
+	}
+	return &ec2RoleProvider{endpoint: strings.TrimSuffix(endpoint, "/"), client: client}
+}
+
+type ecsProvider struct {
+	credentials.Expiry
+	uri       string
+	authToken string
+	client    *http.Client
+}
+
+func (e *ecsProvider) Retrieve() (credentials.Value, error) {
+	req, err := http.NewRequest(http.MethodGet, e.uri, nil)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	if e.authToken != "" {
+		req.Header.Set("authorization", e.authToken)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	output, err := decodeMetadataCredentials(resp)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	if output.Expiration != nil {
+		e.SetExpiration(*output.Expiration, expiryWindow)
+	}
+	return output.value("ECSProvider"), nil
+}
+
+// NewECSProvider returns a provider fetching the credentials from the container credentials endpoint.
+//
+// uri is the full uri of the endpoint, authToken is sent as the `Authorization` header if not empty.
+func NewECSProvider(uri, authToken string, client *http.Client) credentials.Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &ecsProvider{uri: uri, authToken: authToken, client: client}
+}
+
+// newECSProviderFromEnv returns nil if neither `AWS_CONTAINER_CREDENTIALS_RELATIVE_URI` nor
+// `AWS_CONTAINER_CREDENTIALS_FULL_URI` is set.
+func newECSProviderFromEnv(client *http.Client) credentials.Provider {
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if rel := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); rel != "" {
+		return NewECSProvider(DefaultECSEndpoint+rel, token, client)
+	}
+	if full := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"); full != "" {
+		return NewECSProvider(full, token, client)
+	}
+	return nil
+}
+
+// stsCredentials the credentials returned by STS AssumeRole* actions
+type stsCredentials struct {
+	AccessKeyId     string    `xml:"AccessKeyId"`
+	SecretAccessKey string    `xml:"SecretAccessKey"`
+	SessionToken    string    `xml:"SessionToken"`
+	Expiration      time.Time `xml:"Expiration"`
+}
+
+type AssumeRoleWithWebIdentityResponse struct {
+	Credentials stsCredentials `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+}
+
+type webIdentityProvider struct {
+	credentials.Expiry
+	endpoint    string
+	roleArn     string
+	sessionName string
+	tokenFile   string
+	client      *http.Client
+}
+
+func (w *webIdentityProvider) Retrieve() (credentials.Value, error) {
+	token, err := os.ReadFile(w.tokenFile)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	sessionName := w.sessionName
+	if sessionName == "" {
+		sessionName = "yadal-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+	form := url.Values{}
+	form.Set("Action", "AssumeRoleWithWebIdentity")
+	form.Set("Version", "2011-06-15")
+	form.Set("RoleArn", w.roleArn)
+	form.Set("RoleSessionName", sessionName)
+	form.Set("WebIdentityToken", strings.TrimSpace(string(token)))
+
+	resp, err := w.client.PostForm(w.endpoint, form)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return credentials.Value{}, fmt.Errorf("failed to assume role with web identity: %s %s", resp.Status, string(b))
+	}
+	output := AssumeRoleWithWebIdentityResponse{}
+	if err = xml.NewDecoder(resp.Body).Decode(&output); err != nil {
+		return credentials.Value{}, err
+	}
+	w.SetExpiration(output.Credentials.Expiration, expiryWindow)
+	return credentials.Value{
+		AccessKeyID:     output.Credentials.AccessKeyId,
+		SecretAccessKey: output.Credentials.SecretAccessKey,
+		SessionToken:    output.Credentials.SessionToken,
+		ProviderName:    "WebIdentityProvider",
+	}, nil
+}
+
+// NewWebIdentityProvider returns a provider exchanging the token in tokenFile for temporary credentials
+// of roleArn, via STS `AssumeRoleWithWebIdentity`.
+//
+// endpoint defaults to DefaultSTSEndpoint, sessionName is generated if empty.
+func NewWebIdentityProvider(endpoint, roleArn, sessionName, tokenFile string, client *http.Client) credentials.Provider {
+	if endpoint == "" {
+		endpoint = DefaultSTSEndpoint
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &webIdentityProvider{
+		endpoint:    endpoint,
+		roleArn:     roleArn,
+		sessionName: sessionName,
+		tokenFile:   tokenFile,
+		client:      client,
+	}
+}
+
+// newWebIdentityProviderFromEnv returns nil if `AWS_WEB_IDENTITY_TOKEN_FILE` or `AWS_ROLE_ARN` is not set.
+func newWebIdentityProviderFromEnv(client *http.Client) credentials.Provider {
+	tokenFile, roleArn := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), os.Getenv("AWS_ROLE_ARN")
+	if tokenFile == "" || roleArn == "" {
+		return nil
+	}
+	return NewWebIdentityProvider(os.Getenv("AWS_STS_ENDPOINT"), roleArn, os.Getenv("AWS_ROLE_SESSION_NAME"), tokenFile, client)
+}
+
+// NewDefaultCredentialChain returns the credential chain used by NewDriver, the first provider succeeded is used:
+//
+//   - Options.AccessKey and Options.SecretKey, if both are set.
+//   - `AWS_ACCESS_KEY_ID`, `AWS_SECRET_ACCESS_KEY` and `AWS_SESSION_TOKEN` env.
+//   - Options.Profile in shared credentials and config files.
+//   - Web identity token, if `AWS_WEB_IDENTITY_TOKEN_FILE` and `AWS_ROLE_ARN` env are set.
+//   - ECS container credentials, if `AWS_CONTAINER_CREDENTIALS_RELATIVE_URI` or `AWS_CONTAINER_CREDENTIALS_FULL_URI` env is set.
+//   - EC2 instance role, unless `AWS_EC2_METADATA_DISABLED` env is `true`.
+//
+// Expiring credentials are refreshed once they are expired.
+//
+// The http based providers send the requests by client, e.g. the one of NewHTTPClient, so that the proxy and
+// TLS settings apply to them as well, their own timeouts are kept. They use their default clients if client is nil.
+func NewDefaultCredentialChain(opt Options, client *http.Client) credentials.Provider {
+	var chain []credentials.Provider
+	if opt.AccessKey != "" && opt.SecretKey != "" {
+		chain = append(chain, NewStaticProvider(opt.AccessKey, opt.SecretKey, opt.SessionToken))
+	}
+	chain = append(chain, NewEnvProvider(), NewSharedFileProvider("", "", opt.Profile))
+	if p := newWebIdentityProviderFromEnv(withTimeout(client, 10*time.Second)); p != nil {
+		chain = append(chain, p)
+	}
+	if p := newECSProviderFromEnv(withTimeout(client, 5*time.Second)); p != nil {
+		chain = append(chain, p)
+	}
+	if !strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		chain = append(chain, NewEC2RoleProvider("", withTimeout(client, time.Second)))
+	}
+	return &credentials.ChainProvider{Providers: chain, VerboseErrors: true}
+}
+
+// withTimeout returns a copy of the client sharing its transport, the timeout is capped by timeout,
+// so that the unavailable endpoints, e.g. the EC2 metadata outside of EC2, fail fast.
+func withTimeout(client *http.Client, timeout time.Duration) *http.Client {
+	if client == nil {
+		return nil
+	}
+	c := *client
+	if c.Timeout == 0 || c.Timeout > timeout {
+		c.Timeout = timeout
+	}
+	return &c
+}
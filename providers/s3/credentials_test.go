@@ -0,0 +1,289 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvProvider(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "env-ak")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-sk")
+	t.Setenv("AWS_SESSION_TOKEN", "env-token")
+	v, err := NewEnvProvider().Retrieve()
+	assert.Nil(t, err)
+	assert.Equal(t, "env-ak", v.AccessKeyID)
+	assert.Equal(t, "env-sk", v.SecretAccessKey)
+	assert.Equal(t, "env-token", v.SessionToken)
+}
+
+func TestSharedFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "credentials")
+	configFile := filepath.Join(dir, "config")
+	assert.Nil(t, os.WriteFile(credentialsFile, []byte(`[default]
+aws_access_key_id = default-ak
+aws_secret_access_key = default-sk
+`), 0600))
+	assert.Nil(t, os.WriteFile(configFile, []byte(`[profile dev]
+aws_access_key_id = dev-ak
+aws_secret_access_key = dev-sk
+aws_session_token = dev-token
+`), 0600))
+	t.Setenv("AWS_PROFILE", "")
+
+	v, err := NewSharedFileProvider(credentialsFile, configFile, "").Retrieve()
+	assert.Nil(t, err)
+	assert.Equal(t, "default-ak", v.AccessKeyID)
+
+	v, err = NewSharedFileProvider(credentialsFile, configFile, "dev").Retrieve()
+	assert.Nil(t, err)
+	assert.Equal(t, "dev-ak", v.AccessKeyID)
+	assert.Equal(t, "dev-token", v.SessionToken)
+
+	_, err = NewSharedFileProvider(credentialsFile, configFile, "not-exist").Retrieve()
+	assert.NotNil(t, err)
+}
+
+func metadataCredentialsJSON(ak string, expiration time.Time) string {
+	return fmt.Sprintf(`{"Code":"Success","AccessKeyId":"%s","SecretAccessKey":"sk","Token":"token","Expiration":"%s"}`,
+		ak, expiration.UTC().Format(time.RFC3339))
+}
+
+func TestEC2RoleProvider(t *testing.T) {
+	fetched := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && r.URL.Path == "/latest/api/token" {
+			_, _ = w.Write([]byte("imds-token"))
+			return
+		}
+		if r.Header.Get("x-aws-ec2-metadata-token") != "imds-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/latest/meta-data/iam/security-credentials/":
+			_, _ = w.Write([]byte("my-role"))
+		case "/latest/meta-data/iam/security-credentials/my-role":
+			fetched++
+			// expires within the expiry window, so it's refreshed on every Get.
+			_, _ = w.Write([]byte(metadataCredentialsJSON(fmt.Sprintf("ec2-ak-%d", fetched), time.Now().Add(time.Minute))))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	creds := credentials.NewCredentials(NewEC2RoleProvider(server.URL, nil))
+	v, err := creds.Get()
+	assert.Nil(t, err)
+	assert.Equal(t, "ec2-ak-1", v.AccessKeyID)
+	assert.Equal(t, "token", v.SessionToken)
+
+	v, err = creds.Get()
+	assert.Nil(t, err)
+	assert.Equal(t, "ec2-ak-2", v.AccessKeyID)
+}
+
+func TestECSProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("authorization") != "auth-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(metadataCredentialsJSON("ecs-ak", time.Now().Add(time.Hour))))
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", server.URL+"/creds")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "auth-token")
+	p := newECSProviderFromEnv(nil)
+	v, err := p.Retrieve()
+	assert.Nil(t, err)
+	assert.Equal(t, "ecs-ak", v.AccessKeyID)
+	assert.False(t, p.IsExpired())
+}
+
+func TestWebIdentityProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Form.Get("Action") != "AssumeRoleWithWebIdentity" || r.Form.Get("WebIdentityToken") != "jwt" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <SessionToken>web-token</SessionToken>
+      <SecretAccessKey>web-sk</SecretAccessKey>
+      <Expiration>%s</Expiration>
+      <AccessKeyId>web-ak</AccessKeyId>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.Nil(t, os.WriteFile(tokenFile, []byte("jwt\n"), 0600))
+
+	p := NewWebIdentityProvider(server.URL, "arn:aws:iam::123456789012:role/test", "", tokenFile, nil)
+	v, err := p.Retrieve()
+	assert.Nil(t, err)
+	assert.Equal(t, "web-ak", v.AccessKeyID)
+	assert.Equal(t, "web-sk", v.SecretAccessKey)
+	assert.Equal(t, "web-token", v.SessionToken)
+	assert.False(t, p.IsExpired())
+}
+
+func TestDefaultCredentialChain(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "env-ak")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-sk")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	v, err := NewDefaultCredentialChain(Options{AccessKey: "ak", SecretKey: "sk"}, nil).Retrieve()
+	assert.Nil(t, err)
+	assert.Equal(t, "ak", v.AccessKeyID)
+
+	v, err = NewDefaultCredentialChain(Options{}, nil).Retrieve()
+	assert.Nil(t, err)
+	assert.Equal(t, "env-ak", v.AccessKeyID)
+}
+
+// recordingTransport records the hosts of the requests sent through it.
+type recordingTransport struct {
+	hosts []string
+}
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.hosts = append(r.hosts, req.URL.Host)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestDefaultCredentialChainClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(metadataCredentialsJSON("ecs-ak", time.Now().Add(time.Hour))))
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", server.URL+"/creds")
+
+	// the requests of the http based providers are sent by the client
+	transport := &recordingTransport{}
+	client := &http.Client{Transport: transport}
+	v, err := NewDefaultCredentialChain(Options{}, client).Retrieve()
+	assert.Nil(t, err)
+	assert.Equal(t, "ecs-ak", v.AccessKeyID)
+	assert.Equal(t, []string{strings.TrimPrefix(server.URL, "http://")}, transport.hosts)
+
+	// the timeouts of the providers are kept, and the client itself is not changed
+	assert.Equal(t, time.Second, withTimeout(client, time.Second).Timeout)
+	assert.Equal(t, transport, withTimeout(client, time.Second).Transport)
+	assert.Equal(t, time.Duration(0), client.Timeout)
+	assert.Equal(t, time.Millisecond, withTimeout(&http.Client{Timeout: time.Millisecond}, time.Second).Timeout)
+	assert.Nil(t, withTimeout(nil, time.Second))
+}
+
+func TestSignerWithSessionToken(t *testing.T) {
+	s := NewSignerWithCredentials("s3", "us-east-1", NewStaticProvider("ak", "sk", "session-token"), true)
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:9000/bucket/key", nil)
+	assert.Nil(t, s.Sign(req, nil))
+	assert.Equal(t, "session-token", req.Header.Get("X-Amz-Security-Token"))
+	assert.NotEmpty(t, req.Header.Get("Authorization"))
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	s = NewSignerWithCredentials("s3", "us-east-1", &credentials.ChainProvider{Providers: []credentials.Provider{NewEnvProvider()}}, true)
+	req, _ = http.NewRequest(http.MethodGet, "http://127.0.0.1:9000/bucket/key", nil)
+	assert.Nil(t, s.Sign(req, nil))
+	assert.Empty(t, req.Header.Get("Authorization"))
+}
+
+// flakyProvider fails until it's ready, the calls are counted.
+type flakyProvider struct {
+	ready bool
+	calls int
+}
+
+func (f *flakyProvider) Retrieve() (credentials.Value, error) {
+	f.calls++
+	if !f.ready {
+		return credentials.Value{}, fmt.Errorf("no credentials")
+	}
+	return credentials.Value{AccessKeyID: "ak", SecretAccessKey: "sk"}, nil
+}
+
+func (f *flakyProvider) IsExpired() bool {
+	return !f.ready
+}
+
+func TestSignerLazyCredentials(t *testing.T) {
+	interval := anonymousRetryInterval
+	t.Cleanup(func() {
+		anonymousRetryInterval = interval
+	})
+	anonymousRetryInterval = time.Hour
+
+	// the credentials are not retrieved until signing
+	p := &flakyProvider{}
+	s := NewSignerWithCredentials("s3", "us-east-1", p, true)
+	assert.Equal(t, 0, p.calls)
+
+	// the failure falls back to anonymous, and it's remembered for the interval
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:9000/bucket/key", nil)
+		assert.Nil(t, s.Sign(req, nil))
+		assert.Empty(t, req.Header.Get("Authorization"))
+	}
+	assert.Equal(t, 1, p.calls)
+
+	// the requests are signed once the credentials are available after the interval
+	s.(*signer).fallback.retryAt = time.Now()
+	p.ready = true
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:9000/bucket/key", nil)
+	assert.Nil(t, s.Sign(req, nil))
+	assert.NotEmpty(t, req.Header.Get("Authorization"))
+
+	// the failure is returned if anonymous is not allowed
+	s = NewSignerWithCredentials("s3", "us-east-1", &flakyProvider{}, false)
+	req, _ = http.NewRequest(http.MethodGet, "http://127.0.0.1:9000/bucket/key", nil)
+	err := s.Sign(req, nil)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "failed to retrieve credentials: no credentials")
+	assert.NotNil(t, s.PreSign(req, time.Minute))
+	assert.Empty(t, req.Header.Get("Authorization"))
+}
+
+func TestNewDriverAnonymous(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	for _, c := range []struct {
+		opt       Options
+		anonymous bool
+	}{
+		{opt: Options{}, anonymous: true},
+		{opt: Options{AccessKey: "ak", SecretKey: "sk"}},
+		{opt: Options{Credentials: &flakyProvider{}}},
+		{opt: Options{AssumeRole: &AssumeRoleOptions{RoleArn: "arn:aws:iam::123456789012:role/demo"}}},
+	} {
+		c.opt.Bucket, c.opt.Endpoint, c.opt.Region = "bucket", "http://127.0.0.1:9000", "us-east-1"
+		d, err := NewDriver(context.TODO(), c.opt)
+		assert.Nil(t, err)
+		assert.Equal(t, c.anonymous, d.(*Driver).signer.(*signer).fallback != nil)
+	}
+}
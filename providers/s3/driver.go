@@ -3,6 +3,8 @@ package s3
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/base64"
 	"encoding/xml"
 	"fmt"
 	"github.com/senrok/yadal/constants"
@@ -11,26 +13,83 @@ import (
 	"github.com/senrok/yadal/utils"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 )
 
-func (d *Driver) insertSseHeaders(req *http.Request, isWrite bool) {
+// resolveSse returns the settings of the request if any is set, otherwise the driver defaults.
+// The settings are not merged, since S3 rejects SSE-KMS and SSE-C in the same request.
+func (d *Driver) resolveSse(sse *options.ServerSideEncryption) options.ServerSideEncryption {
+	resolved := options.ServerSideEncryption{
+		Encryption:     d.SSEncryption,
+		AwsKmsKeyId:    d.SSEncryptionAwsKmsKeyId,
+		CustomerAlgo:   d.SSEncryptionCustomerAlgo,
+		CustomerKey:    d.SSEncryptionCustomerKey,
+		CustomerKeyMD5: d.SSEncryptionCustomerKeyMD5,
+	}
+	if sse != nil && (sse.Encryption != nil || sse.AwsKmsKeyId != nil || sse.CustomerAlgo != nil || sse.CustomerKey != nil || sse.CustomerKeyMD5 != nil) {
+		// the md5 of the default key doesn't match the overridden one either.
+		resolved = *sse
+	}
+	if resolved.CustomerKey != nil {
+		if resolved.CustomerAlgo == nil {
+			algo := "AES256"
+			resolved.CustomerAlgo = &algo
+		}
+		if resolved.CustomerKeyMD5 == nil {
+			resolved.CustomerKeyMD5 = customerKeyMD5(*resolved.CustomerKey)
+		}
+	}
+	return resolved
+}
+
+// customerKeyMD5 returns the base64 encoded md5 of the base64 encoded key.
+func customerKeyMD5(key string) *string {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		// let the server reject the invalid key.
+		raw = []byte(key)
+	}
+	sum := md5.Sum(raw)
+	encoded := base64.StdEncoding.EncodeToString(sum[:])
+	return &encoded
+}
+
+func (d *Driver) insertSseHeaders(req *http.Request, isWrite bool, override *options.ServerSideEncryption) {
+	sse := d.resolveSse(override)
 	if isWrite {
-		if d.SSEncryption != nil {
-			req.Header.Set(constants.XAmzServerSideEncryption, *d.SSEncryption)
+		if sse.Encryption != nil {
+			req.Header.Set(constants.XAmzServerSideEncryption, *sse.Encryption)
 		}
-		if d.SSEncryptionAwsKmsKeyId != nil {
-			req.Header.Set(constants.XAmzServerSideEncryptionAwsKmsKeyId, *d.SSEncryptionAwsKmsKeyId)
+		if sse.AwsKmsKeyId != nil {
+			req.Header.Set(constants.XAmzServerSideEncryptionAwsKmsKeyId, *sse.AwsKmsKeyId)
 		}
 	}
-	if d.SSEncryptionCustomerAlgo != nil {
-		req.Header.Set(constants.XAmzServerSideEncryptionCustomerAlgorithm, *d.SSEncryptionCustomerAlgo)
+	if sse.CustomerAlgo != nil {
+		req.Header.Set(constants.XAmzServerSideEncryptionCustomerAlgorithm, *sse.CustomerAlgo)
 	}
-	if d.SSEncryptionCustomerKey != nil {
-		req.Header.Set(constants.XAmzServerSideEncryptionCustomerKey, *d.SSEncryptionCustomerKey)
+	if sse.CustomerKey != nil {
+		req.Header.Set(constants.XAmzServerSideEncryptionCustomerKey, *sse.CustomerKey)
 	}
-	if d.SSEncryptionCustomerKeyMD5 != nil {
-		req.Header.Set(constants.XAmzServerSideEncryptionCustomerKeyMd5, *d.SSEncryptionCustomerKeyMD5)
+	if sse.CustomerKeyMD5 != nil {
+		req.Header.Set(constants.XAmzServerSideEncryptionCustomerKeyMd5, *sse.CustomerKeyMD5)
+	}
+}
+
+// insertObjectHeaders sets the storage class, canned ACL and tags of the object to write.
+func insertObjectHeaders(req *http.Request, storageClass, acl string, tags map[string]string) {
+	if storageClass != "" {
+		req.Header.Set(constants.XAmzStorageClass, storageClass)
+	}
+	if acl != "" {
+		req.Header.Set(constants.XAmzAcl, acl)
+	}
+	if len(tags) > 0 {
+		values := url.Values{}
+		for k, v := range tags {
+			values.Set(k, v)
+		}
+		req.Header.Set(constants.XAmzTagging, values.Encode())
 	}
 }
 
@@ -45,13 +104,21 @@ func (d *Driver) buildUrl(path string) (string, error) {
 	return url, nil
 }
 
-func (d *Driver) getObjectRequest(_ context.Context, path string, offset, size *uint64) (*http.Request, error) {
+// withVersionId selects the version of the object, the latest one is selected if versionId is empty.
+func withVersionId(u, versionId string) string {
+	if versionId == "" {
+		return u
+	}
+	return u + "?versionId=" + url.QueryEscape(versionId)
+}
+
+func (d *Driver) getObjectRequest(_ context.Context, path string, offset, size *uint64, versionId string, sse *options.ServerSideEncryption) (*http.Request, error) {
 	url, err := d.buildUrl(path)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequest(http.MethodGet, withVersionId(url, versionId), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -61,13 +128,13 @@ func (d *Driver) getObjectRequest(_ context.Context, path string, offset, size *
 	}
 
 	// SSE headers
-	d.insertSseHeaders(req, false)
+	d.insertSseHeaders(req, false, sse)
 
 	return req, nil
 }
 
-func (d *Driver) GetObject(ctx context.Context, path string, offset, size *uint64) (*http.Response, error) {
-	req, err := d.getObjectRequest(ctx, path, offset, size)
+func (d *Driver) GetObject(ctx context.Context, path string, offset, size *uint64, versionId string, sse *options.ServerSideEncryption) (*http.Response, error) {
+	req, err := d.getObjectRequest(ctx, path, offset, size, versionId, sse)
 	if err != nil {
 		return nil, err
 	}
@@ -79,7 +146,7 @@ func (d *Driver) GetObject(ctx context.Context, path string, offset, size *uint6
 	return d.client.Do(req)
 }
 
-func (d *Driver) putObjectRequest(_ context.Context, path string, size *uint64, body io.ReadSeeker) (*http.Request, error) {
+func (d *Driver) putObjectRequest(_ context.Context, path string, size *uint64, body io.Reader, sse *options.ServerSideEncryption) (*http.Request, error) {
 	url, err := d.buildUrl(path)
 	if err != nil {
 		return nil, err
@@ -96,24 +163,33 @@ func (d *Driver) putObjectRequest(_ context.Context, path string, size *uint64,
 	}
 
 	// SSE headers
-	d.insertSseHeaders(req, true)
+	d.insertSseHeaders(req, true, sse)
 
 	return req, nil
 }
 
-func (d *Driver) HeadObject(_ context.Context, path string) (*http.Response, error) {
+func (d *Driver) headObjectRequest(_ context.Context, path string, versionId string, sse *options.ServerSideEncryption) (*http.Request, error) {
 	url, err := d.buildUrl(path)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodHead, url, nil)
+	req, err := http.NewRequest(http.MethodHead, withVersionId(url, versionId), nil)
 	if err != nil {
 		return nil, err
 	}
 
 	// SSE headers
-	d.insertSseHeaders(req, false)
+	d.insertSseHeaders(req, false, sse)
+
+	return req, nil
+}
+
+func (d *Driver) HeadObject(ctx context.Context, path string, versionId string, sse *options.ServerSideEncryption) (*http.Response, error) {
+	req, err := d.headObjectRequest(ctx, path, versionId, sse)
+	if err != nil {
+		return nil, err
+	}
 
 	err = d.signer.Sign(req, nil)
 	if err != nil {
@@ -123,13 +199,17 @@ func (d *Driver) HeadObject(_ context.Context, path string) (*http.Response, err
 	return d.client.Do(req)
 }
 
-func (d *Driver) DeleteObject(_ context.Context, path string) (*http.Response, error) {
+func (d *Driver) deleteObjectRequest(_ context.Context, path string, versionId string) (*http.Request, error) {
 	url, err := d.buildUrl(path)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodDelete, url, nil)
+	return http.NewRequest(http.MethodDelete, withVersionId(url, versionId), nil)
+}
+
+func (d *Driver) DeleteObject(ctx context.Context, path string, versionId string) (*http.Response, error) {
+	req, err := d.deleteObjectRequest(ctx, path, versionId)
 	if err != nil {
 		return nil, err
 	}
@@ -165,7 +245,7 @@ func (d *Driver) ListObjects(_ context.Context, path string, continuationToken s
 	return d.client.Do(req)
 }
 
-func (d *Driver) S3InitiateMultipartUpload(_ context.Context, path string) (*http.Response, error) {
+func (d *Driver) S3InitiateMultipartUploadRequest(_ context.Context, path string, args options.CreateMultipart) (*http.Request, error) {
 	p, err := utils.BuildAbsPath(d.root, path)
 	if err != nil {
 		return nil, err
@@ -178,6 +258,20 @@ func (d *Driver) S3InitiateMultipartUpload(_ context.Context, path string) (*htt
 		return nil, err
 	}
 
+	insertObjectHeaders(req, args.StorageClass, args.ACL, args.Tags)
+
+	// SSE headers
+	d.insertSseHeaders(req, true, args.SSE)
+
+	return req, nil
+}
+
+func (d *Driver) S3InitiateMultipartUpload(ctx context.Context, path string, args options.CreateMultipart) (*http.Response, error) {
+	req, err := d.S3InitiateMultipartUploadRequest(ctx, path, args)
+	if err != nil {
+		return nil, err
+	}
+
 	if err = d.signer.Sign(req, nil); err != nil {
 		return nil, err
 	}
@@ -185,7 +279,7 @@ func (d *Driver) S3InitiateMultipartUpload(_ context.Context, path string) (*htt
 	return d.client.Do(req)
 }
 
-func (d *Driver) S3UploadPartRequest(ctx context.Context, path string, uploadId string, partNumber uint, size *uint64, body io.ReadCloser) (*http.Request, error) {
+func (d *Driver) S3UploadPartRequest(ctx context.Context, path string, uploadId string, partNumber uint, size *uint64, body io.ReadCloser, sse *options.ServerSideEncryption) (*http.Request, error) {
 	p, err := utils.BuildAbsPath(d.root, path)
 	if err != nil {
 		return nil, err
@@ -199,12 +293,17 @@ func (d *Driver) S3UploadPartRequest(ctx context.Context, path string, uploadId
 	}
 	if size != nil {
 		req.Header.Set(constants.ContentLength, strconv.FormatUint(*size, 10))
+		req.ContentLength = int64(*size)
 	}
 
+	// SSE-C headers, the parts MUST be encrypted with the key of the upload.
+	d.insertSseHeaders(req, false, sse)
+
 	return req, nil
 }
 
-func (d *Driver) S3CompleteMultipartUpload(_ context.Context, path, uploadId string, parts []interfaces.ObjectPart) (*http.Response, error) {
+// S3CompleteMultipartUploadRequest builds the request without a body, the body is set by the caller.
+func (d *Driver) S3CompleteMultipartUploadRequest(_ context.Context, path, uploadId string) (*http.Request, error) {
 	p, err := utils.BuildAbsPath(d.root, path)
 	if err != nil {
 		return nil, err
@@ -212,19 +311,30 @@ func (d *Driver) S3CompleteMultipartUpload(_ context.Context, path, uploadId str
 
 	url := fmt.Sprintf("%s/%s?uploadId=%s", d.endpoint, utils.EncodePath(p), uploadId)
 
-	body, err := xml.Marshal(NewCompleteMultipartUploadFromObjectParts(parts))
+	req, err := http.NewRequest(http.MethodPost, url, nil)
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest(http.MethodPost, url, io.NopCloser(bytes.NewReader(body)))
+
+	req.Header.Set(constants.ContentType, "application/xml")
+
+	return req, nil
+}
+
+func (d *Driver) S3CompleteMultipartUpload(ctx context.Context, path, uploadId string, parts []interfaces.ObjectPart) (*http.Response, error) {
+	req, err := d.S3CompleteMultipartUploadRequest(ctx, path, uploadId)
 	if err != nil {
 		return nil, err
 	}
 
+	body, err := xml.Marshal(NewCompleteMultipartUploadFromObjectParts(parts))
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
 	req.Header.Set(constants.ContentLength, strconv.FormatUint(uint64(len(body)), 10))
 
-	req.Header.Set(constants.ContentType, "application/xml")
-
 	if err = d.signer.Sign(req, nil); err != nil {
 		return nil, err
 	}
@@ -251,3 +361,120 @@ func (d *Driver) S3AbortMultipartUpload(_ context.Context, path, uploadId string
 
 	return d.client.Do(req)
 }
+
+func (d *Driver) S3ListMultipartUploads(_ context.Context, path, keyMarker, uploadIdMarker string) (*http.Response, error) {
+	p, err := utils.BuildAbsPath(d.root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s?uploads&prefix=%s", d.endpoint, utils.EncodePath(p))
+
+	if keyMarker != "" {
+		url += fmt.Sprintf("&key-marker=%s", utils.EncodePath(keyMarker))
+	}
+	if uploadIdMarker != "" {
+		url += fmt.Sprintf("&upload-id-marker=%s", uploadIdMarker)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = d.signer.Sign(req, nil); err != nil {
+		return nil, err
+	}
+
+	return d.client.Do(req)
+}
+
+func (d *Driver) S3ListParts(_ context.Context, key, uploadId string, partNumberMarker uint) (*http.Response, error) {
+	url := fmt.Sprintf("%s/%s?uploadId=%s", d.endpoint, utils.EncodePath(key), uploadId)
+
+	if partNumberMarker != 0 {
+		url += fmt.Sprintf("&part-number-marker=%d", partNumberMarker)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = d.signer.Sign(req, nil); err != nil {
+		return nil, err
+	}
+
+	return d.client.Do(req)
+}
+
+func (d *Driver) S3GetObjectTagging(_ context.Context, path string) (*http.Response, error) {
+	url, err := d.buildUrl(path)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url+"?tagging", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = d.signer.Sign(req, nil); err != nil {
+		return nil, err
+	}
+
+	return d.client.Do(req)
+}
+
+func (d *Driver) S3PutObjectTagging(_ context.Context, path string, tags map[string]string) (*http.Response, error) {
+	url, err := d.buildUrl(path)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := xml.Marshal(NewTaggingFromMap(tags))
+	if err != nil {
+		return nil, err
+	}
+	reader := bytes.NewReader(body)
+	req, err := http.NewRequest(http.MethodPut, url+"?tagging", reader)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := md5.Sum(body)
+	req.Header.Set(constants.ContentMD5, base64.StdEncoding.EncodeToString(sum[:]))
+	req.Header.Set(constants.ContentType, "application/xml")
+
+	if err = d.signer.Sign(req, reader); err != nil {
+		return nil, err
+	}
+
+	return d.client.Do(req)
+}
+
+func (d *Driver) S3ListObjectVersions(_ context.Context, path, keyMarker, versionIdMarker string) (*http.Response, error) {
+	p, err := utils.BuildAbsPath(d.root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s?versions&delimiter=/&prefix=%s", d.endpoint, utils.EncodePath(p))
+	if keyMarker != "" {
+		url += fmt.Sprintf("&key-marker=%s", utils.EncodePath(keyMarker))
+	}
+	if versionIdMarker != "" {
+		url += fmt.Sprintf("&version-id-marker=%s", utils.EncodePath(versionIdMarker))
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = d.signer.Sign(req, nil); err != nil {
+		return nil, err
+	}
+
+	return d.client.Do(req)
+}
@@ -0,0 +1,84 @@
+package s3
+
+import (
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type countingTransport struct {
+	count int
+}
+
+func (c *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	c.count++
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestNewHTTPClientCustomCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewHTTPClient(HTTPOptions{})
+	assert.Nil(t, err)
+	_, err = client.Get(server.URL)
+	assert.NotNil(t, err)
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	client, err = NewHTTPClient(HTTPOptions{CACert: ca})
+	assert.Nil(t, err)
+	resp, err := client.Get(server.URL)
+	assert.Nilf(t, err, "%s", err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	client, err = NewHTTPClient(HTTPOptions{InsecureSkipVerify: true, DisableHTTP2: true})
+	assert.Nil(t, err)
+	resp, err = client.Get(server.URL)
+	assert.Nilf(t, err, "%s", err)
+	_ = resp.Body.Close()
+
+	_, err = NewHTTPClient(HTTPOptions{CACert: []byte("not a pem")})
+	assert.NotNil(t, err)
+}
+
+func TestNewHTTPClientTransportAndProxy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	client, err := NewHTTPClient(HTTPOptions{Transport: transport})
+	assert.Nil(t, err)
+	resp, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, 1, transport.count)
+
+	proxied := ""
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+	client, err = NewHTTPClient(HTTPOptions{ProxyURL: proxy.URL})
+	assert.Nil(t, err)
+	resp, err = client.Get("http://bucket.s3.example.com/key")
+	assert.Nil(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "http://bucket.s3.example.com/key", proxied)
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	client, err = NewHTTPClient(HTTPOptions{Proxy: http.ProxyURL(proxyURL)})
+	assert.Nil(t, err)
+	resp, err = client.Get("http://other.example.com/")
+	assert.Nil(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "http://other.example.com/", proxied)
+}
@@ -0,0 +1,51 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"github.com/senrok/yadal/options"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestListMultipartInitiatedBefore(t *testing.T) {
+	var listed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Has("uploads"):
+			_, _ = fmt.Fprint(w, `<ListMultipartUploadsResult>
+  <IsTruncated>false</IsTruncated>
+  <Upload><Key>stale</Key><UploadId>stale-id</UploadId><Initiated>2010-11-10T20:48:33.000Z</Initiated></Upload>
+  <Upload><Key>fresh</Key><UploadId>fresh-id</UploadId><Initiated>`+time.Now().UTC().Format(time.RFC3339)+`</Initiated></Upload>
+</ListMultipartUploadsResult>`)
+		case r.URL.Query().Has("uploadId"):
+			listed = append(listed, r.URL.Query().Get("uploadId"))
+			_, _ = fmt.Fprint(w, `<ListPartsResult><IsTruncated>false</IsTruncated><Part><PartNumber>1</PartNumber><Size>1024</Size></Part></ListPartsResult>`)
+		}
+	}))
+	defer server.Close()
+
+	d := &Driver{
+		endpoint: server.URL + "/bucket",
+		root:     "/",
+		client:   http.DefaultClient,
+		signer:   NewSigner("s3", "us-east-1", "ak", "sk", false),
+	}
+	ctx := context.TODO()
+
+	uploads, err := d.ListMultipart(ctx, "/", options.ListMultipart{})
+	assert.Nil(t, err)
+	assert.Len(t, uploads, 2)
+	assert.Empty(t, listed)
+
+	// the parts of the fresh upload are not listed
+	uploads, err = d.ListMultipart(ctx, "/", options.ListMultipart{WithSize: true, InitiatedBefore: time.Now().Add(-time.Hour)})
+	assert.Nil(t, err)
+	assert.Len(t, uploads, 1)
+	assert.Equal(t, "stale-id", uploads[0].GetUploadId())
+	assert.Equal(t, uint64(1024), *uploads[0].GetSize())
+	assert.Equal(t, []string{"stale-id"}, listed)
+}
@@ -1,5 +1,7 @@
 package s3
 
+import "github.com/aws/aws-sdk-go/aws/credentials"
+
 type Options struct {
 	Bucket   string
 	Endpoint string
@@ -8,6 +10,14 @@ type Options struct {
 
 	AccessKey string
 	SecretKey string
+	// SessionToken the session token of temporary AccessKey and SecretKey
+	SessionToken string
+	// Profile the profile in shared credentials and config files, defaults to `AWS_PROFILE` env or `default`.
+	Profile string
+	// Credentials overrides the credential chain if set, see NewDefaultCredentialChain.
+	Credentials credentials.Provider
+	// AssumeRole assumes the role with the credentials above if set, the temporary credentials are used to access the bucket.
+	AssumeRole *AssumeRoleOptions
 
 	SSEncryption               *string
 	SSEncryptionAwsKmsKeyId    *string
@@ -16,4 +26,10 @@ type Options struct {
 	SSEncryptionCustomerKeyMD5 *string
 
 	EnableVirtualHostStyle bool
+
+	// PayloadSigning how the bodies of Write and WriteMultipart are signed, defaults to PayloadSigningAuto.
+	PayloadSigning PayloadSigningMode
+
+	// HTTP configures the http client, e.g. transport, connection pool, TLS, proxy and timeouts.
+	HTTP HTTPOptions
 }
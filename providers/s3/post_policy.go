@@ -0,0 +1,130 @@
+package s3
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/utils"
+	"strings"
+	"time"
+)
+
+const (
+	amzAlgorithm  = "AWS4-HMAC-SHA256"
+	amzDateFormat = "20060102T150405Z"
+	// filenameVariable is replaced with the name of the uploaded file by S3.
+	filenameVariable = "${filename}"
+)
+
+// PostPolicyOptions the conditions of a presigned POST policy.
+type PostPolicyOptions struct {
+	// Expire defaults to an hour.
+	Expire time.Duration
+	// KeyPrefix allows any key starting with the path, the key field defaults to `<path>${filename}`.
+	KeyPrefix bool
+	// MinContentLength and MaxContentLength limit the size of the uploaded file if MaxContentLength is set.
+	MinContentLength uint64
+	MaxContentLength uint64
+	// ContentType requires the exact Content-Type field, it takes precedence over ContentTypePrefix.
+	ContentType string
+	// ContentTypePrefix requires the Content-Type field to start with it, e.g. `image/`.
+	ContentTypePrefix string
+	// Fields extra form fields which are required to be posted as is, e.g. `success_action_status`.
+	Fields map[string]string
+}
+
+// PostForm the presigned HTML form, all Fields MUST be posted along with the `file` field, which MUST be the last one.
+type PostForm struct {
+	URL    string
+	Fields map[string]string
+}
+
+// PostPolicy the policy document, see https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-HTTPPOSTConstructPolicy.html
+type PostPolicy struct {
+	Expiration string        `json:"expiration"`
+	Conditions []interface{} `json:"conditions"`
+}
+
+// PreSignPost returns a presigned HTML form uploading the object to path.
+func (d *Driver) PreSignPost(_ context.Context, path string, opt PostPolicyOptions) (*PostForm, error) {
+	key, err := utils.BuildAbsPath(d.root, path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+	}
+	expire := opt.Expire
+	if expire == 0 {
+		expire = defaultPreSignExpire
+	}
+	now := time.Now().UTC()
+
+	fields := map[string]string{}
+	for k, v := range opt.Fields {
+		fields[k] = v
+	}
+	policy := &PostPolicy{
+		Expiration: now.Add(expire).Format("2006-01-02T15:04:05.000Z"),
+		Conditions: []interface{}{map[string]string{"bucket": d.bucket}},
+	}
+	if opt.KeyPrefix {
+		policy.Conditions = append(policy.Conditions, []string{"starts-with", "$key", key})
+		fields["key"] = key + filenameVariable
+	} else {
+		policy.Conditions = append(policy.Conditions, map[string]string{"key": key})
+		fields["key"] = key
+	}
+	if opt.MaxContentLength > 0 {
+		policy.Conditions = append(policy.Conditions, []interface{}{"content-length-range", opt.MinContentLength, opt.MaxContentLength})
+	}
+	if opt.ContentType != "" {
+		policy.Conditions = append(policy.Conditions, map[string]string{"Content-Type": opt.ContentType})
+		fields["Content-Type"] = opt.ContentType
+	} else if opt.ContentTypePrefix != "" {
+		policy.Conditions = append(policy.Conditions, []string{"starts-with", "$Content-Type", opt.ContentTypePrefix})
+	}
+	for k, v := range opt.Fields {
+		policy.Conditions = append(policy.Conditions, map[string]string{k: v})
+	}
+
+	signed, err := d.signer.SignPostPolicy(policy, now)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+	}
+	for k, v := range signed {
+		fields[k] = v
+	}
+	return &PostForm{URL: d.endpoint, Fields: fields}, nil
+}
+
+func (s signer) SignPostPolicy(policy *PostPolicy, now time.Time) (map[string]string, error) {
+	creds, err := s.retrieve()
+	if err != nil {
+		return nil, err
+	}
+	date := now.UTC().Format(amzDateFormat)
+	credential := strings.Join([]string{creds.AccessKeyID, date[:8], s.Region, s.Service, "aws4_request"}, "/")
+	fields := map[string]string{
+		"x-amz-algorithm":  amzAlgorithm,
+		"x-amz-credential": credential,
+		"x-amz-date":       date,
+	}
+	if creds.SessionToken != "" {
+		fields["x-amz-security-token"] = creds.SessionToken
+	}
+	for _, k := range []string{"x-amz-algorithm", "x-amz-credential", "x-amz-date", "x-amz-security-token"} {
+		if v, ok := fields[k]; ok {
+			policy.Conditions = append(policy.Conditions, map[string]string{k: v})
+		}
+	}
+
+	document, err := json.Marshal(policy)
+	if err != nil {
+		return nil, fmt.Errorf("marshal post policy: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(document)
+	fields["policy"] = encoded
+	fields["x-amz-signature"] = hex.EncodeToString(hmacSHA256(signingKey(creds.SecretAccessKey, date[:8], s.Region, s.Service), encoded))
+	return fields, nil
+}
@@ -0,0 +1,64 @@
+package s3
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreSignPost(t *testing.T) {
+	d := &Driver{
+		bucket:   "bucket",
+		endpoint: "https://s3.us-east-1.amazonaws.com/bucket",
+		root:     "/uploads/",
+		signer:   NewSignerWithCredentials("s3", "us-east-1", NewStaticProvider("ak", "sk", "token"), false),
+	}
+	form, err := d.PreSignPost(context.TODO(), "avatars/", PostPolicyOptions{
+		Expire:            time.Minute,
+		KeyPrefix:         true,
+		MaxContentLength:  1 << 20,
+		ContentTypePrefix: "image/",
+		Fields:            map[string]string{"success_action_status": "201"},
+	})
+	assert.Nilf(t, err, "%s", err)
+	assert.Equal(t, "https://s3.us-east-1.amazonaws.com/bucket", form.URL)
+	assert.Equal(t, "uploads/avatars/${filename}", form.Fields["key"])
+	assert.Equal(t, "201", form.Fields["success_action_status"])
+	assert.Equal(t, "AWS4-HMAC-SHA256", form.Fields["x-amz-algorithm"])
+	assert.Equal(t, "token", form.Fields["x-amz-security-token"])
+	date := form.Fields["x-amz-date"]
+	assert.Equal(t, "ak/"+date[:8]+"/us-east-1/s3/aws4_request", form.Fields["x-amz-credential"])
+
+	expected := hex.EncodeToString(hmacSHA256(signingKey("sk", date[:8], "us-east-1", "s3"), form.Fields["policy"]))
+	assert.Equal(t, expected, form.Fields["x-amz-signature"])
+
+	document, err := base64.StdEncoding.DecodeString(form.Fields["policy"])
+	assert.Nil(t, err)
+	var policy struct {
+		Expiration time.Time     `json:"expiration"`
+		Conditions []interface{} `json:"conditions"`
+	}
+	assert.Nil(t, json.Unmarshal(document, &policy))
+	assert.WithinDuration(t, time.Now().Add(time.Minute), policy.Expiration, 5*time.Second)
+	conditions := string(document)
+	for _, c := range []string{
+		`{"bucket":"bucket"}`,
+		`["starts-with","$key","uploads/avatars/"]`,
+		`["content-length-range",0,1048576]`,
+		`["starts-with","$Content-Type","image/"]`,
+		`{"success_action_status":"201"}`,
+		`{"x-amz-date":"` + date + `"}`,
+	} {
+		assert.True(t, strings.Contains(conditions, c), c)
+	}
+
+	form, err = d.PreSignPost(context.TODO(), "a.txt", PostPolicyOptions{ContentType: "text/plain"})
+	assert.Nil(t, err)
+	assert.Equal(t, "uploads/a.txt", form.Fields["key"])
+	assert.Equal(t, "text/plain", form.Fields["Content-Type"])
+}
@@ -0,0 +1,89 @@
+package s3
+
+import (
+	"context"
+	"github.com/senrok/yadal/constants"
+	"github.com/senrok/yadal/options"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreSign(t *testing.T) {
+	d := &Driver{
+		endpoint: "https://s3.us-east-1.amazonaws.com/bucket",
+		root:     "/",
+		signer:   NewSigner("s3", "us-east-1", "ak", "sk", false),
+	}
+	ctx := context.TODO()
+
+	for op, method := range map[options.PreSignOperation]string{
+		options.StatOp:            http.MethodHead,
+		options.DeleteOp:          http.MethodDelete,
+		options.CreateMultipartOp: http.MethodPost,
+	} {
+		req, err := d.PreSign(ctx, "a.txt", options.PreSignOptions{Op: op, Expire: time.Minute})
+		assert.Nilf(t, err, "%s", err)
+		assert.Equal(t, method, req.Method, op.String())
+		assert.NotEmpty(t, req.URL.Query().Get("X-Amz-Signature"))
+	}
+
+	// the options of stat and create multipart are kept
+	sse := options.NewSSECustomerKey([]byte(strings.Repeat("k", 32)))
+	req, err := d.PreSign(ctx, "a.txt", options.PreSignOptions{
+		Op:          options.StatOp,
+		StatOptions: &options.StatOptions{VersionId: "v1", SSE: sse},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", req.URL.Query().Get("versionId"))
+	assert.Equal(t, *sse.CustomerKey, req.Header.Get(constants.XAmzServerSideEncryptionCustomerKey))
+	signed := req.URL.Query().Get("X-Amz-SignedHeaders")
+	assert.True(t, strings.Contains(signed, strings.ToLower(constants.XAmzServerSideEncryptionCustomerKey)), signed)
+
+	req, err = d.PreSign(ctx, "a.txt", options.PreSignOptions{
+		Op: options.CreateMultipartOp,
+		CreateMultipart: &options.CreateMultipart{
+			StorageClass: "STANDARD_IA",
+			ACL:          "private",
+			Tags:         map[string]string{"team": "data"},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "STANDARD_IA", req.Header.Get(constants.XAmzStorageClass))
+	assert.Equal(t, "private", req.Header.Get(constants.XAmzAcl))
+	assert.Equal(t, "team=data", req.Header.Get(constants.XAmzTagging))
+
+	req, err = d.PreSign(ctx, "a.txt", options.PreSignOptions{
+		Op:                options.CompleteMultipartOp,
+		CompleteMultipart: &options.CompleteMultipart{UploadId: "upload-id"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "upload-id", req.URL.Query().Get("uploadId"))
+	assert.Equal(t, "3600", req.URL.Query().Get("X-Amz-Expires"))
+
+	_, err = d.PreSign(ctx, "a.txt", options.PreSignOptions{Op: options.CompleteMultipartOp})
+	assert.NotNil(t, err)
+
+	req, err = d.PreSign(ctx, "a.txt", options.PreSignOptions{
+		Op: options.WriteOp,
+		Headers: http.Header{
+			"Content-Type": []string{"text/plain"},
+			"Content-Md5":  []string{"1B2M2Y8AsgTpgAmY7PhCfg=="},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "text/plain", req.Header.Get("Content-Type"))
+	signed = req.URL.Query().Get("X-Amz-SignedHeaders")
+	assert.True(t, strings.Contains(signed, "content-type"), signed)
+	assert.True(t, strings.Contains(signed, "content-md5"), signed)
+
+	req, err = d.PreSign(ctx, "a.txt", options.PreSignOptions{
+		Op:       options.ReadOp,
+		Response: &options.ResponseOverrides{ContentDisposition: `attachment; filename="b.txt"`},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `attachment; filename="b.txt"`, req.URL.Query().Get("response-content-disposition"))
+	assert.NotEmpty(t, req.URL.Query().Get("X-Amz-Signature"))
+}
@@ -15,15 +15,19 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 )
 
+const defaultPreSignExpire = time.Hour
+
 type Driver struct {
 	bucket                     string
 	endpoint                   string
 	region                     string
 	root                       string
-	client                     http.Client
+	client                     *http.Client
 	signer                     Signer
+	payloadSigning             PayloadSigningMode
 	SSEncryption               *string
 	SSEncryptionAwsKmsKeyId    *string
 	SSEncryptionCustomerAlgo   *string
@@ -33,11 +37,11 @@ type Driver struct {
 }
 
 func (d *Driver) Metadata() interfaces.Metadata {
-	return providers.NewMetadata(interfaces.S3, d.root, d.bucket, interfaces.Read|interfaces.Write|interfaces.List|interfaces.PreSign|interfaces.Multipart)
+	return providers.NewMetadata(interfaces.S3, d.root, d.bucket, interfaces.Read|interfaces.Write|interfaces.List|interfaces.PreSign|interfaces.Multipart|interfaces.Tagging|interfaces.Versioning)
 }
 
 func (d *Driver) Create(ctx context.Context, path string, _ options.CreateOptions) error {
-	req, err := d.putObjectRequest(ctx, path, nil, nil)
+	req, err := d.putObjectRequest(ctx, path, nil, nil, nil)
 	if err != nil {
 		return errors.Wrap(errors.ErrCreateFailed, err)
 	}
@@ -60,7 +64,7 @@ func (d *Driver) Create(ctx context.Context, path string, _ options.CreateOption
 }
 
 func (d *Driver) Read(ctx context.Context, path string, args options.ReadOptions) (io.ReadCloser, error) {
-	resp, err := d.GetObject(ctx, path, args.Offset, args.Size)
+	resp, err := d.GetObject(ctx, path, args.Offset, args.Size, args.VersionId, args.SSE)
 	if err != nil {
 		return nil, errors.Wrap(errors.ErrReadFailed, err)
 	}
@@ -72,25 +76,33 @@ func (d *Driver) Read(ctx context.Context, path string, args options.ReadOptions
 	}
 }
 
-func (d *Driver) Write(ctx context.Context, path string, args options.WriteOptions, reader io.ReadSeeker) (uint64, error) {
-	req, err := d.putObjectRequest(ctx, path, &args.Size, reader)
+func (d *Driver) Write(ctx context.Context, path string, args options.WriteOptions, reader io.Reader) (interfaces.WriteResult, error) {
+	req, err := d.putObjectRequest(ctx, path, &args.Size, reader, args.SSE)
 	if err != nil {
-		return 0, errors.Wrap(errors.ErrWriteFailed, err)
+		return nil, errors.Wrap(errors.ErrWriteFailed, err)
 	}
-	if err = d.signer.Sign(req, reader); err != nil {
-		return 0, errors.Wrap(errors.ErrWriteFailed, err)
+	insertObjectHeaders(req, args.StorageClass, args.ACL, args.Tags)
+	if err = d.signBody(req, reader, args.Size); err != nil {
+		return nil, errors.Wrap(errors.ErrWriteFailed, err)
 	}
 
 	resp, err := d.client.Do(req)
 	if err != nil {
-		return 0, errors.Wrap(errors.ErrWriteFailed, err)
+		return nil, errors.Wrap(errors.ErrWriteFailed, err)
 	}
 
 	switch resp.StatusCode {
 	case http.StatusCreated, http.StatusOK:
-		return args.Size, nil
+		result := object.WriteResult{Size: args.Size}
+		if etag := resp.Header.Get(constants.ETag); etag != "" {
+			result.ETag = &etag
+		}
+		if versionId := resp.Header.Get(constants.XAmzVersionId); versionId != "" {
+			result.VersionId = &versionId
+		}
+		return result, nil
 	default:
-		return 0, errors.ParseS3Error(errors.ErrWriteFailed, path, resp)
+		return nil, errors.ParseS3Error(errors.ErrWriteFailed, path, resp)
 	}
 }
 
@@ -99,7 +111,7 @@ func (d *Driver) Stat(ctx context.Context, path string, args options.StatOptions
 		return object.Metadata{ObjectMode: interfaces.DIR}, nil
 	}
 
-	resp, err := d.HeadObject(ctx, path)
+	resp, err := d.HeadObject(ctx, path, args.VersionId, args.SSE)
 	if err != nil {
 		return nil, errors.Wrap(errors.ErrStatFailed, err)
 	}
@@ -126,7 +138,7 @@ func (d *Driver) Stat(ctx context.Context, path string, args options.StatOptions
 }
 
 func (d *Driver) Delete(ctx context.Context, path string, args options.DeleteOptions) error {
-	resp, err := d.DeleteObject(ctx, path)
+	resp, err := d.DeleteObject(ctx, path, args.VersionId)
 	if err != nil {
 		return errors.Wrap(errors.ErrDeleteFailed, err)
 	}
@@ -146,33 +158,136 @@ func (d *Driver) List(ctx context.Context, path string, args options.ListOptions
 	}), nil
 }
 
+func (d *Driver) ListVersions(ctx context.Context, path string, args options.ListVersions) (interfaces.ObjectStream, error) {
+	return object.NewObjectStream(NewVersionStream(d, d.root, path)), nil
+}
+
 func (d *Driver) PreSign(ctx context.Context, path string, args options.PreSignOptions) (req *http.Request, err error) {
 
 	switch args.Op {
 	case options.ReadOp:
-		if req, err = d.getObjectRequest(ctx, path, args.Offset, args.ReadOptions.Size); err != nil {
+		read := options.ReadOptions{}
+		if args.ReadOptions != nil {
+			read = *args.ReadOptions
+		}
+		if req, err = d.getObjectRequest(ctx, path, read.Offset, read.Size, read.VersionId, read.SSE); err != nil {
 			return nil, errors.Wrap(errors.ErrPreSignFailed, err)
 		}
 	case options.WriteOp:
-		if req, err = d.putObjectRequest(ctx, path, nil, nil); err != nil {
+		var sse *options.ServerSideEncryption
+		if args.WriteOptions != nil {
+			sse = args.WriteOptions.SSE
+		}
+		if req, err = d.putObjectRequest(ctx, path, nil, nil, sse); err != nil {
 			return nil, errors.Wrap(errors.ErrPreSignFailed, err)
 		}
 	case options.WriteMultipartOp:
-		if req, err = d.S3UploadPartRequest(ctx, path, args.UploadId, args.PartNumber, nil, nil); err != nil {
+		if args.WriteMultipart == nil {
+			return nil, errors.Wrap(errors.ErrPreSignFailed, errors.ErrUploadIdRequired)
+		}
+		if req, err = d.S3UploadPartRequest(ctx, path, args.UploadId, args.PartNumber, nil, nil, args.WriteMultipart.SSE); err != nil {
+			return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+		}
+	case options.StatOp:
+		stat := options.StatOptions{}
+		if args.StatOptions != nil {
+			stat = *args.StatOptions
+		}
+		if req, err = d.headObjectRequest(ctx, path, stat.VersionId, stat.SSE); err != nil {
+			return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+		}
+	case options.DeleteOp:
+		if req, err = d.deleteObjectRequest(ctx, path, ""); err != nil {
+			return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+		}
+	case options.CreateMultipartOp:
+		create := options.CreateMultipart{}
+		if args.CreateMultipart != nil {
+			create = *args.CreateMultipart
+		}
+		if req, err = d.S3InitiateMultipartUploadRequest(ctx, path, create); err != nil {
+			return nil, errors.Wrap(errors.ErrPreSignFailed, err)
+		}
+	case options.CompleteMultipartOp:
+		if args.CompleteMultipart == nil {
+			return nil, errors.Wrap(errors.ErrPreSignFailed, errors.ErrUploadIdRequired)
+		}
+		if req, err = d.S3CompleteMultipartUploadRequest(ctx, path, args.CompleteMultipart.UploadId); err != nil {
 			return nil, errors.Wrap(errors.ErrPreSignFailed, err)
 		}
 	default:
 		return nil, errors.Wrap(errors.ErrPreSignFailed, errors.ErrUnknownPreSignOperation)
 	}
 
-	if err = d.signer.Sign(req, nil); err != nil {
+	for k, values := range args.Headers {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	if args.Op == options.ReadOp && args.Response != nil {
+		setResponseOverrides(req, args.Response)
+	}
+
+	expire := args.Expire
+	if expire == 0 {
+		expire = defaultPreSignExpire
+	}
+	if err = d.signer.PreSign(req, expire); err != nil {
 		return nil, errors.Wrap(errors.ErrPreSignFailed, err)
 	}
 	return
 }
 
+func (d *Driver) GetTags(ctx context.Context, path string, _ options.GetTags) (map[string]string, error) {
+	resp, err := d.S3GetObjectTagging(ctx, path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrGetTagsFailed, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		output := Tagging{}
+		if err = xml.NewDecoder(resp.Body).Decode(&output); err != nil {
+			return nil, errors.Wrap(errors.ErrGetTagsFailed, err)
+		}
+		return output.Map(), nil
+	default:
+		return nil, errors.ParseS3Error(errors.ErrGetTagsFailed, path, resp)
+	}
+}
+
+func (d *Driver) SetTags(ctx context.Context, path string, args options.SetTags) error {
+	resp, err := d.S3PutObjectTagging(ctx, path, args.Tags)
+	if err != nil {
+		return errors.Wrap(errors.ErrSetTagsFailed, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	default:
+		return errors.ParseS3Error(errors.ErrSetTagsFailed, path, resp)
+	}
+}
+
+// setResponseOverrides sets the `response-*` query of a GET request.
+func setResponseOverrides(req *http.Request, o *options.ResponseOverrides) {
+	query := req.URL.Query()
+	for k, v := range map[string]string{
+		"response-content-type":        o.ContentType,
+		"response-content-disposition": o.ContentDisposition,
+		"response-content-encoding":    o.ContentEncoding,
+		"response-content-language":    o.ContentLanguage,
+		"response-cache-control":       o.CacheControl,
+		"response-expires":             o.Expires,
+	} {
+		if v != "" {
+			query.Set(k, v)
+		}
+	}
+	req.URL.RawQuery = query.Encode()
+}
+
 func (d *Driver) CreateMultipart(ctx context.Context, path string, args options.CreateMultipart) (string, error) {
-	resp, err := d.S3InitiateMultipartUpload(ctx, path)
+	resp, err := d.S3InitiateMultipartUpload(ctx, path, args)
 	if err != nil {
 		return "", errors.Wrap(errors.ErrCreateMultipartFailed, err)
 	}
@@ -188,13 +303,13 @@ func (d *Driver) CreateMultipart(ctx context.Context, path string, args options.
 	}
 }
 
-func (d *Driver) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.ReadSeeker) (interfaces.ObjectPart, error) {
-	req, err := d.S3UploadPartRequest(ctx, path, args.UploadId, args.PartNumber, &args.Size, io.NopCloser(reader))
+func (d *Driver) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.Reader) (interfaces.ObjectPart, error) {
+	req, err := d.S3UploadPartRequest(ctx, path, args.UploadId, args.PartNumber, &args.Size, io.NopCloser(reader), args.SSE)
 	if err != nil {
 		return nil, errors.Wrap(errors.ErrWriteMultipartFailed, err)
 	}
-	if err = d.signer.Sign(req, reader); err != nil {
-		return nil, err
+	if err = d.signBody(req, reader, args.Size); err != nil {
+		return nil, errors.Wrap(errors.ErrWriteMultipartFailed, err)
 	}
 	resp, err := d.client.Do(req)
 	if err != nil {
@@ -238,13 +353,109 @@ func (d *Driver) AbortMultipart(ctx context.Context, path string, args options.A
 	}
 
 	switch resp.StatusCode {
-	case http.StatusOK:
+	case http.StatusOK, http.StatusNoContent:
 		return nil
 	default:
 		return errors.ParseS3Error(errors.ErrAbortMultipartFailed, path, resp)
 	}
 }
 
+func (d *Driver) ListMultipart(ctx context.Context, path string, args options.ListMultipart) ([]interfaces.MultipartUpload, error) {
+	var (
+		uploads                   []interfaces.MultipartUpload
+		keyMarker, uploadIdMarker string
+	)
+	for {
+		resp, err := d.S3ListMultipartUploads(ctx, path, keyMarker, uploadIdMarker)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrListMultipartFailed, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.ParseS3Error(errors.ErrListMultipartFailed, path, resp)
+		}
+		output := ListMultipartUploadsResult{}
+		err = xml.NewDecoder(resp.Body).Decode(&output)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrListMultipartFailed, err)
+		}
+
+		for _, upload := range output.Uploads {
+			if !args.Includes(upload.Initiated) {
+				continue
+			}
+			p, err := utils.BuildRealPath(d.root, upload.Key)
+			if err != nil {
+				return nil, errors.Wrap(errors.ErrListMultipartFailed, err)
+			}
+			u := object.MultipartUpload{
+				Path:      p,
+				UploadId:  upload.UploadId,
+				Initiated: upload.Initiated,
+			}
+			if args.WithSize {
+				size, err := d.partsSize(ctx, upload.Key, upload.UploadId)
+				if err != nil {
+					return nil, errors.Wrap(errors.ErrListMultipartFailed, err)
+				}
+				u.Size = &size
+			}
+			uploads = append(uploads, u)
+		}
+
+		if !output.IsTruncated {
+			return uploads, nil
+		}
+		keyMarker, uploadIdMarker = output.NextKeyMarker, output.NextUploadIdMarker
+	}
+}
+
+// partsSize sums up the size of uploaded parts, the key MUST be an absolute key.
+func (d *Driver) partsSize(ctx context.Context, key, uploadId string) (uint64, error) {
+	var (
+		size   uint64
+		marker uint
+	)
+	for {
+		resp, err := d.S3ListParts(ctx, key, uploadId, marker)
+		if err != nil {
+			return 0, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return 0, errors.ParseS3Error(errors.ErrListMultipartFailed, key, resp)
+		}
+		output := ListPartsResult{}
+		err = xml.NewDecoder(resp.Body).Decode(&output)
+		_ = resp.Body.Close()
+		if err != nil {
+			return 0, err
+		}
+		for _, part := range output.Parts {
+			size += part.Size
+		}
+		if !output.IsTruncated {
+			return size, nil
+		}
+		marker = output.NextPartNumberMarker
+	}
+}
+
+// signBody signs the request with the body of size according to the PayloadSigningMode.
+func (d *Driver) signBody(req *http.Request, body io.Reader, size uint64) error {
+	switch d.payloadSigning {
+	case PayloadSigningUnsigned:
+		return d.signer.SignUnsignedPayload(req, body, int64(size))
+	case PayloadSigningStreaming:
+		return d.signer.SignStreaming(req, body, int64(size))
+	default:
+		if seeker, ok := body.(io.ReadSeeker); ok {
+			req.ContentLength = int64(size)
+			return d.signer.Sign(req, seeker)
+		}
+		return d.signer.SignStreaming(req, body, int64(size))
+	}
+}
+
 func (d *Driver) detectRegion(ctx context.Context, bucket string) (endpoint string, region string, err error) {
 	endpoint = d.endpoint
 	if !strings.HasPrefix(endpoint, "http") {
@@ -281,11 +492,17 @@ func (d *Driver) detectRegion(ctx context.Context, bucket string) (endpoint stri
 func NewDriver(ctx context.Context, opt Options) (interfaces.Accessor, error) {
 	region := opt.Region
 
+	client, err := NewHTTPClient(opt.HTTP)
+	if err != nil {
+		return nil, err
+	}
+
 	d := &Driver{
 		bucket:                     opt.Bucket,
 		endpoint:                   opt.Endpoint,
 		root:                       utils.NormalizeRoot(opt.Root),
-		client:                     http.Client{},
+		client:                     client,
+		payloadSigning:             opt.PayloadSigning,
 		region:                     region,
 		SSEncryption:               opt.SSEncryption,
 		SSEncryptionAwsKmsKeyId:    opt.SSEncryptionAwsKmsKeyId,
@@ -295,7 +512,6 @@ func NewDriver(ctx context.Context, opt Options) (interfaces.Accessor, error) {
 	}
 
 	if opt.Region == "" {
-		var err error
 		if d.endpoint, d.region, err = d.detectRegion(ctx, d.bucket); err != nil {
 			return nil, err
 		}
@@ -307,7 +523,17 @@ func NewDriver(ctx context.Context, opt Options) (interfaces.Accessor, error) {
 		d.endpoint = d.endpoint + "/" + opt.Bucket
 	}
 
-	d.signer = NewSigner("s3", d.region, opt.AccessKey, opt.SecretKey, true)
+	// falls back to anonymous only if no credentials are configured, e.g. the public buckets,
+	// otherwise the failure of the configured credentials is returned instead of the unsigned requests.
+	anonymous := opt.AccessKey == "" && opt.SecretKey == "" && opt.Credentials == nil && opt.AssumeRole == nil
+	provider := opt.Credentials
+	if provider == nil {
+		provider = NewDefaultCredentialChain(opt, client)
+	}
+	if opt.AssumeRole != nil {
+		provider = NewAssumeRoleProvider(provider, *opt.AssumeRole, withTimeout(client, 10*time.Second))
+	}
+	d.signer = NewSignerWithCredentials("s3", d.region, provider, anonymous)
 
 	return d, nil
 }
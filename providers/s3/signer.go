@@ -5,28 +5,84 @@
 package s3
 
 import (
+	"fmt"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 )
 
 type Signer interface {
 	Sign(r *http.Request, reader io.ReadSeeker) error
+	// PreSign signs the request into its query string, the request is valid within expire.
+	PreSign(r *http.Request, expire time.Duration) error
+	// SignStreaming signs the request with STREAMING-AWS4-HMAC-SHA256-PAYLOAD,
+	// the body of size is read once and sent as signed chunks.
+	SignStreaming(r *http.Request, body io.Reader, size int64) error
+	// SignUnsignedPayload signs the request with UNSIGNED-PAYLOAD, the body of size is sent as is.
+	SignUnsignedPayload(r *http.Request, body io.Reader, size int64) error
+	// SignPostPolicy appends the credential conditions to the policy and returns the signed form fields.
+	SignPostPolicy(policy *PostPolicy, now time.Time) (map[string]string, error)
 }
 
 type signer struct {
 	*v4.Signer
 	Anonymous bool
-	Service   string
-	Region    string
+	// fallback sends the requests anonymously while no credentials can be retrieved, it's nil if not allowed.
+	fallback *anonymousFallback
+	Service  string
+	Region   string
 }
 
-func (s signer) Sign(r *http.Request, reader io.ReadSeeker) error {
+// anonymousRetryInterval the interval of retrying to retrieve the credentials after the failure.
+var anonymousRetryInterval = time.Minute
+
+type anonymousFallback struct {
+	mu      sync.Mutex
+	retryAt time.Time
+}
+
+// anonymous returns true if the credentials can't be retrieved, the failure is remembered for
+// anonymousRetryInterval, so that the unavailable providers, e.g. EC2 metadata, are not probed by every request.
+func (f *anonymousFallback) anonymous(creds *credentials.Credentials) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if time.Now().Before(f.retryAt) {
+		return true
+	}
+	if _, err := creds.Get(); err != nil {
+		f.retryAt = time.Now().Add(anonymousRetryInterval)
+		return true
+	}
+	return false
+}
+
+// isAnonymous returns true if the request is sent without signing.
+func (s signer) isAnonymous() bool {
 	if s.Anonymous {
+		return true
+	}
+	return s.fallback != nil && s.fallback.anonymous(s.Signer.Credentials)
+}
+
+// retrieve returns the credentials of the provider, the failure is wrapped with the cause.
+func (s signer) retrieve() (credentials.Value, error) {
+	creds, err := s.Signer.Credentials.Get()
+	if err != nil {
+		return credentials.Value{}, fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+	return creds, nil
+}
+
+func (s signer) Sign(r *http.Request, reader io.ReadSeeker) error {
+	if s.isAnonymous() {
 		return nil
 	}
+	if _, err := s.retrieve(); err != nil {
+		return err
+	}
 	_, err := s.Signer.Sign(r, reader, s.Service, s.Region, time.Now())
 	if err != nil {
 		return err
@@ -34,6 +90,17 @@ func (s signer) Sign(r *http.Request, reader io.ReadSeeker) error {
 	return nil
 }
 
+func (s signer) PreSign(r *http.Request, expire time.Duration) error {
+	if s.isAnonymous() {
+		return nil
+	}
+	if _, err := s.retrieve(); err != nil {
+		return err
+	}
+	_, err := s.Signer.Presign(r, nil, s.Service, s.Region, expire, time.Now())
+	return err
+}
+
 type naiveSignerProvider struct {
 	AccessKey string
 	SecretKey string
@@ -69,3 +136,24 @@ func NewSigner(service, region, accessKey, secretKey string, allow_anonymous boo
 		Region:  region,
 	}
 }
+
+// NewSignerWithCredentials returns a Signer retrieving credentials from the provider when signing,
+// the credentials are refreshed once the provider reports they are expired.
+//
+// If allowAnonymous is set, the requests are sent anonymously while no credentials can be retrieved,
+// the retrieval is retried after anonymousRetryInterval. Otherwise, the failure of the retrieval is returned.
+func NewSignerWithCredentials(service, region string, provider credentials.Provider, allowAnonymous bool) Signer {
+	s := &signer{
+		Signer: v4.NewSigner(
+			credentials.NewCredentials(provider), func(s *v4.Signer) {
+				s.DisableURIPathEscaping = true
+			},
+		),
+		Service: service,
+		Region:  region,
+	}
+	if allowAnonymous {
+		s.fallback = &anonymousFallback{}
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"github.com/senrok/yadal/constants"
+	"github.com/senrok/yadal/options"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestInsertSseHeaders(t *testing.T) {
+	kms, keyId := "aws:kms", "key-id"
+	d := &Driver{
+		endpoint:                "https://s3.us-east-1.amazonaws.com/bucket",
+		root:                    "/",
+		SSEncryption:            &kms,
+		SSEncryptionAwsKmsKeyId: &keyId,
+	}
+	ctx := context.TODO()
+
+	// driver defaults
+	req, err := d.putObjectRequest(ctx, "a.txt", nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "aws:kms", req.Header.Get(constants.XAmzServerSideEncryption))
+	assert.Equal(t, "key-id", req.Header.Get(constants.XAmzServerSideEncryptionAwsKmsKeyId))
+	assert.Empty(t, req.Header.Get(constants.XAmzServerSideEncryptionCustomerKey))
+
+	// the override replaces the KMS defaults as a whole
+	raw := bytes.Repeat([]byte{'k'}, 32)
+	sse := options.NewSSECustomerKey(raw)
+	req, err = d.putObjectRequest(ctx, "a.txt", nil, nil, sse)
+	assert.Nil(t, err)
+	assert.Empty(t, req.Header.Get(constants.XAmzServerSideEncryption))
+	assert.Empty(t, req.Header.Get(constants.XAmzServerSideEncryptionAwsKmsKeyId))
+	assert.Equal(t, "AES256", req.Header.Get(constants.XAmzServerSideEncryptionCustomerAlgorithm))
+	assert.Equal(t, *sse.CustomerKey, req.Header.Get(constants.XAmzServerSideEncryptionCustomerKey))
+	assert.Equal(t, *sse.CustomerKeyMD5, req.Header.Get(constants.XAmzServerSideEncryptionCustomerKeyMd5))
+	assert.Equal(t, *sse.CustomerKeyMD5, *customerKeyMD5(*sse.CustomerKey))
+
+	// the override with SSE-S3 drops the KMS key id
+	aes := "AES256"
+	req, err = d.putObjectRequest(ctx, "a.txt", nil, nil, &options.ServerSideEncryption{Encryption: &aes})
+	assert.Nil(t, err)
+	assert.Equal(t, "AES256", req.Header.Get(constants.XAmzServerSideEncryption))
+	assert.Empty(t, req.Header.Get(constants.XAmzServerSideEncryptionAwsKmsKeyId))
+
+	// the SSE-C defaults, the md5 is derived from the key
+	defaultKey := "ZGVmYXVsdC1rZXk="
+	d = &Driver{
+		endpoint:                "https://s3.us-east-1.amazonaws.com/bucket",
+		root:                    "/",
+		SSEncryptionCustomerKey: &defaultKey,
+	}
+	req, err = d.putObjectRequest(ctx, "a.txt", nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "AES256", req.Header.Get(constants.XAmzServerSideEncryptionCustomerAlgorithm))
+	assert.Equal(t, defaultKey, req.Header.Get(constants.XAmzServerSideEncryptionCustomerKey))
+	assert.Equal(t, *customerKeyMD5(defaultKey), req.Header.Get(constants.XAmzServerSideEncryptionCustomerKeyMd5))
+
+	// the md5 of the default key is not reused for an overridden key
+	req, err = d.getObjectRequest(ctx, "a.txt", nil, nil, "", &options.ServerSideEncryption{CustomerKey: sse.CustomerKey})
+	assert.Nil(t, err)
+	assert.Equal(t, *sse.CustomerKeyMD5, req.Header.Get(constants.XAmzServerSideEncryptionCustomerKeyMd5))
+	assert.Empty(t, req.Header.Get(constants.XAmzServerSideEncryption))
+
+	// only SSE-C headers on part uploads
+	req, err = d.S3UploadPartRequest(ctx, "a.txt", "upload-id", 1, nil, io.NopCloser(bytes.NewReader(nil)), sse)
+	assert.Nil(t, err)
+	assert.Equal(t, *sse.CustomerKey, req.Header.Get(constants.XAmzServerSideEncryptionCustomerKey))
+	assert.Empty(t, req.Header.Get(constants.XAmzServerSideEncryption))
+
+	req, err = d.S3InitiateMultipartUploadRequest(ctx, "a.txt", options.CreateMultipart{SSE: sse})
+	assert.Nil(t, err)
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Empty(t, req.Header.Get(constants.XAmzServerSideEncryption))
+	assert.Equal(t, *sse.CustomerKey, req.Header.Get(constants.XAmzServerSideEncryptionCustomerKey))
+}
@@ -0,0 +1,197 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	streamingPayload   = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	unsignedPayload    = "UNSIGNED-PAYLOAD"
+	streamingAlgorithm = "AWS4-HMAC-SHA256-PAYLOAD"
+	// emptySHA256 hex encoded sha256 of the empty string.
+	emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	// DefaultStreamingChunkSize the size of signed chunks, MUST be at least 8KB except the last one.
+	DefaultStreamingChunkSize = 64 * 1024
+
+	amzDateHeader            = "X-Amz-Date"
+	amzContentSha256Header   = "X-Amz-Content-Sha256"
+	amzDecodedContentLength  = "X-Amz-Decoded-Content-Length"
+	contentEncodingHeader    = "Content-Encoding"
+	awsChunkedContentEncoded = "aws-chunked"
+)
+
+// PayloadSigningMode how the request bodies of Write and WriteMultipart are signed.
+type PayloadSigningMode int
+
+const (
+	// PayloadSigningAuto signs the sha256 of the body if it's seekable, which reads the body twice,
+	// otherwise the body is streamed as signed chunks.
+	PayloadSigningAuto PayloadSigningMode = iota
+	// PayloadSigningStreaming always streams the body as signed chunks (STREAMING-AWS4-HMAC-SHA256-PAYLOAD).
+	PayloadSigningStreaming
+	// PayloadSigningUnsigned only signs the headers (UNSIGNED-PAYLOAD), the body is streamed as is.
+	// The integrity of the body relies on TLS.
+	PayloadSigningUnsigned
+)
+
+// setBody sets the body of size to the request.
+func setBody(r *http.Request, body io.Reader, size int64) {
+	r.Body = io.NopCloser(io.LimitReader(body, size))
+	r.ContentLength = size
+	r.Header.Set("Content-Length", strconv.FormatInt(size, 10))
+}
+
+func (s signer) SignUnsignedPayload(r *http.Request, body io.Reader, size int64) error {
+	if !s.isAnonymous() {
+		r.Header.Set(amzContentSha256Header, unsignedPayload)
+		// the body is detached from the request by signing without it, so it's set afterwards.
+		if err := s.Sign(r, nil); err != nil {
+			return err
+		}
+	}
+	setBody(r, body, size)
+	return nil
+}
+
+func (s signer) SignStreaming(r *http.Request, body io.Reader, size int64) error {
+	if s.isAnonymous() {
+		setBody(r, body, size)
+		return nil
+	}
+	creds, err := s.retrieve()
+	if err != nil {
+		return err
+	}
+	length := chunkedContentLength(size, DefaultStreamingChunkSize)
+	r.Header.Set(amzContentSha256Header, streamingPayload)
+	r.Header.Set(contentEncodingHeader, awsChunkedContentEncoded)
+	r.Header.Set(amzDecodedContentLength, strconv.FormatInt(size, 10))
+	r.Header.Set("Content-Length", strconv.FormatInt(length, 10))
+	if err = s.Sign(r, nil); err != nil {
+		return err
+	}
+
+	seed, err := seedSignature(r.Header.Get("Authorization"))
+	if err != nil {
+		return err
+	}
+	amzDate := r.Header.Get(amzDateHeader)
+	if len(amzDate) < 8 {
+		return fmt.Errorf("invalid %s: %s", amzDateHeader, amzDate)
+	}
+	date := amzDate[:8]
+	r.ContentLength = length
+	r.Body = io.NopCloser(&chunkedReader{
+		body:      io.LimitReader(body, size),
+		chunk:     make([]byte, DefaultStreamingChunkSize),
+		key:       signingKey(creds.SecretAccessKey, date, s.Region, s.Service),
+		amzDate:   amzDate,
+		scope:     strings.Join([]string{date, s.Region, s.Service, "aws4_request"}, "/"),
+		signature: seed,
+	})
+	return nil
+}
+
+// seedSignature extracts the signature of the headers, which seeds the signature of the first chunk.
+func seedSignature(authorization string) (string, error) {
+	i := strings.LastIndex(authorization, "Signature=")
+	if i < 0 {
+		return "", fmt.Errorf("no signature found in authorization header")
+	}
+	return authorization[i+len("Signature="):], nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// chunkLength the encoded length of a chunk of size n.
+func chunkLength(n int64) int64 {
+	// hex(n);chunk-signature=<64>\r\n<n>\r\n
+	return int64(len(strconv.FormatInt(n, 16))) + int64(len(";chunk-signature=")) + 64 + 2 + n + 2
+}
+
+// chunkedContentLength the encoded length of a body of size, including the final empty chunk.
+func chunkedContentLength(size, chunkSize int64) int64 {
+	length := size / chunkSize * chunkLength(chunkSize)
+	if rem := size % chunkSize; rem > 0 {
+		length += chunkLength(rem)
+	}
+	return length + chunkLength(0)
+}
+
+// chunkedReader encodes the body into signed aws-chunked chunks, each chunk signature is chained to the previous one.
+type chunkedReader struct {
+	body      io.Reader
+	chunk     []byte
+	buf       bytes.Buffer
+	key       []byte
+	amzDate   string
+	scope     string
+	signature string
+	done      bool
+}
+
+func (c *chunkedReader) sign(data []byte) string {
+	hash := sha256.Sum256(data)
+	stringToSign := strings.Join([]string{
+		streamingAlgorithm,
+		c.amzDate,
+		c.scope,
+		c.signature,
+		emptySHA256,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+	c.signature = hex.EncodeToString(hmacSHA256(c.key, stringToSign))
+	return c.signature
+}
+
+func (c *chunkedReader) writeChunk(data []byte) {
+	signature := c.sign(data)
+	c.buf.WriteString(strconv.FormatInt(int64(len(data)), 16))
+	c.buf.WriteString(";chunk-signature=")
+	c.buf.WriteString(signature)
+	c.buf.WriteString("\r\n")
+	c.buf.Write(data)
+	c.buf.WriteString("\r\n")
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for c.buf.Len() == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(c.body, c.chunk)
+		switch err {
+		case nil:
+			c.writeChunk(c.chunk[:n])
+		case io.EOF, io.ErrUnexpectedEOF:
+			if n > 0 {
+				c.writeChunk(c.chunk[:n])
+			}
+			c.writeChunk(nil)
+			c.done = true
+		default:
+			return 0, err
+		}
+	}
+	return c.buf.Read(p)
+}
@@ -0,0 +1,109 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// the example from https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming.html
+func TestChunkedReader(t *testing.T) {
+	body := bytes.Repeat([]byte{'a'}, 65*1024)
+	assert.Equal(t, int64(66824), chunkedContentLength(int64(len(body)), DefaultStreamingChunkSize))
+
+	r := &chunkedReader{
+		body:      bytes.NewReader(body),
+		chunk:     make([]byte, DefaultStreamingChunkSize),
+		key:       signingKey("wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY", "20130524", "us-east-1", "s3"),
+		amzDate:   "20130524T000000Z",
+		scope:     "20130524/us-east-1/s3/aws4_request",
+		signature: "4f232c4386841ef735655705268965c44a0e4690baa4adea153f7db9fa80a0a9",
+	}
+	encoded, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, 66824, len(encoded))
+
+	signatures, decoded := decodeChunked(t, encoded)
+	assert.Equal(t, []string{
+		"ad80c730a21e5b8d04586a2213dd63b9a0e99e0e2307b0ade35a65485a288648",
+		"0055627c9e194cb4542bae2aa5492e3c1575bbb81b612b7d234b86a503ef5497",
+		"b6c6ea8a5354eaf15b3cb7646744f4275b71ea724fed81ceb9323e279d449df9",
+	}, signatures)
+	assert.Equal(t, body, decoded)
+}
+
+func decodeChunked(t *testing.T, encoded []byte) (signatures []string, decoded []byte) {
+	r := bufio.NewReader(bytes.NewReader(encoded))
+	for {
+		line, err := r.ReadString('\n')
+		assert.Nil(t, err)
+		parts := strings.SplitN(strings.TrimSuffix(line, "\r\n"), ";chunk-signature=", 2)
+		assert.Equal(t, 2, len(parts))
+		size, err := strconv.ParseInt(parts[0], 16, 64)
+		assert.Nil(t, err)
+		signatures = append(signatures, parts[1])
+		data := make([]byte, size+2)
+		_, err = io.ReadFull(r, data)
+		assert.Nil(t, err)
+		decoded = append(decoded, data[:size]...)
+		if size == 0 {
+			return
+		}
+	}
+}
+
+func TestSignBody(t *testing.T) {
+	var header http.Header
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		received, _ = io.ReadAll(r.Body)
+		assert.Equal(t, int64(len(received)), r.ContentLength)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	content := bytes.Repeat([]byte("yadal"), 30*1024)
+	s := NewSigner("s3", "us-east-1", "ak", "sk", false)
+	d := &Driver{client: http.DefaultClient, signer: s}
+
+	for _, mode := range []PayloadSigningMode{PayloadSigningAuto, PayloadSigningStreaming, PayloadSigningUnsigned} {
+		d.payloadSigning = mode
+		// a plain io.Reader, which is not seekable
+		req, err := http.NewRequest(http.MethodPut, server.URL+"/bucket/key", nil)
+		assert.Nil(t, err)
+		assert.Nil(t, d.signBody(req, io.MultiReader(bytes.NewReader(content)), uint64(len(content))))
+		resp, err := d.client.Do(req)
+		assert.Nil(t, err)
+		_ = resp.Body.Close()
+
+		if mode == PayloadSigningUnsigned {
+			assert.Equal(t, unsignedPayload, header.Get(amzContentSha256Header))
+			assert.Equal(t, content, received)
+			continue
+		}
+		assert.Equal(t, streamingPayload, header.Get(amzContentSha256Header))
+		assert.Equal(t, awsChunkedContentEncoded, header.Get(contentEncodingHeader))
+		assert.Equal(t, strconv.Itoa(len(content)), header.Get(amzDecodedContentLength))
+		signatures, decoded := decodeChunked(t, received)
+		assert.Equal(t, 4, len(signatures))
+		assert.Equal(t, content, decoded)
+	}
+
+	// a seekable body is signed as a whole
+	d.payloadSigning = PayloadSigningAuto
+	req, err := http.NewRequest(http.MethodPut, server.URL+"/bucket/key", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, d.signBody(req, bytes.NewReader(content), uint64(len(content))))
+	resp, err := d.client.Do(req)
+	assert.Nil(t, err)
+	_ = resp.Body.Close()
+	assert.Len(t, header.Get(amzContentSha256Header), 64)
+	assert.Equal(t, content, received)
+}
@@ -0,0 +1,112 @@
+package s3
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type AssumeRoleOptions struct {
+	RoleArn string
+	// ExternalId the external id required by the trust policy of the role, optional.
+	ExternalId string
+	// SessionName defaults to a generated name.
+	SessionName string
+	// Duration the lifetime of the temporary credentials, defaults to 1 hour.
+	Duration time.Duration
+	// Endpoint the STS endpoint, defaults to DefaultSTSEndpoint.
+	Endpoint string
+	// Region the signing region of STS, defaults to `us-east-1`.
+	Region string
+}
+
+type AssumeRoleResponse struct {
+	Credentials stsCredentials `xml:"AssumeRoleResult>Credentials"`
+}
+
+type assumeRoleProvider struct {
+	credentials.Expiry
+	AssumeRoleOptions
+	signer *v4.Signer
+	client *http.Client
+}
+
+func (a *assumeRoleProvider) Retrieve() (credentials.Value, error) {
+	sessionName := a.SessionName
+	if sessionName == "" {
+		sessionName = "yadal-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+	form := url.Values{}
+	form.Set("Action", "AssumeRole")
+	form.Set("Version", "2011-06-15")
+	form.Set("RoleArn", a.RoleArn)
+	form.Set("RoleSessionName", sessionName)
+	form.Set("DurationSeconds", strconv.FormatInt(int64(a.Duration/time.Second), 10))
+	if a.ExternalId != "" {
+		form.Set("ExternalId", a.ExternalId)
+	}
+	body := []byte(form.Encode())
+
+	req, err := http.NewRequest(http.MethodPost, a.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	req.Header.Set("content-type", "application/x-www-form-urlencoded")
+	if _, err = a.signer.Sign(req, bytes.NewReader(body), "sts", a.Region, time.Now()); err != nil {
+		return credentials.Value{}, err
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return credentials.Value{}, fmt.Errorf("failed to assume role: %s %s", resp.Status, string(b))
+	}
+	output := AssumeRoleResponse{}
+	if err = xml.NewDecoder(resp.Body).Decode(&output); err != nil {
+		return credentials.Value{}, err
+	}
+	a.SetExpiration(output.Credentials.Expiration, expiryWindow)
+	return credentials.Value{
+		AccessKeyID:     output.Credentials.AccessKeyId,
+		SecretAccessKey: output.Credentials.SecretAccessKey,
+		SessionToken:    output.Credentials.SessionToken,
+		ProviderName:    "AssumeRoleProvider",
+	}, nil
+}
+
+// NewAssumeRoleProvider returns a provider assuming the role via STS `AssumeRole`,
+// the request is signed with the credentials of source.
+//
+// The temporary credentials are cached, and refreshed before they are expired.
+func NewAssumeRoleProvider(source credentials.Provider, opt AssumeRoleOptions, client *http.Client) credentials.Provider {
+	if opt.Endpoint == "" {
+		opt.Endpoint = DefaultSTSEndpoint
+	}
+	if opt.Region == "" {
+		opt.Region = "us-east-1"
+	}
+	if opt.Duration == 0 {
+		opt.Duration = time.Hour
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &assumeRoleProvider{
+		AssumeRoleOptions: opt,
+		signer:            v4.NewSigner(credentials.NewCredentials(source)),
+		client:            client,
+	}
+}
@@ -0,0 +1,67 @@
+package s3
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAssumeRoleProvider(t *testing.T) {
+	assumed := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=source-ak/") ||
+			r.Form.Get("Action") != "AssumeRole" ||
+			r.Form.Get("RoleArn") != "arn:aws:iam::123456789012:role/test" ||
+			r.Form.Get("ExternalId") != "external" ||
+			r.Form.Get("DurationSeconds") != "900" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		assumed++
+		_, _ = fmt.Fprintf(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>role-ak-%d</AccessKeyId>
+      <SecretAccessKey>role-sk</SecretAccessKey>
+      <SessionToken>role-token</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`, assumed, time.Now().Add(15*time.Minute).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	p := NewAssumeRoleProvider(NewStaticProvider("source-ak", "source-sk", ""), AssumeRoleOptions{
+		RoleArn:    "arn:aws:iam::123456789012:role/test",
+		ExternalId: "external",
+		Duration:   15 * time.Minute,
+		Endpoint:   server.URL,
+	}, nil)
+	creds := credentials.NewCredentials(p)
+
+	v, err := creds.Get()
+	assert.Nil(t, err)
+	assert.Equal(t, "role-ak-1", v.AccessKeyID)
+	assert.Equal(t, "role-token", v.SessionToken)
+
+	// cached
+	v, err = creds.Get()
+	assert.Nil(t, err)
+	assert.Equal(t, "role-ak-1", v.AccessKeyID)
+
+	s := NewSignerWithCredentials("s3", "us-east-1", p, false)
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:9000/bucket/key", nil)
+	assert.Nil(t, s.Sign(req, nil))
+	assert.Equal(t, "role-token", req.Header.Get("X-Amz-Security-Token"))
+
+	req, _ = http.NewRequest(http.MethodGet, "http://127.0.0.1:9000/bucket/key", nil)
+	assert.Nil(t, s.PreSign(req, time.Minute))
+	assert.Equal(t, "role-token", req.URL.Query().Get("X-Amz-Security-Token"))
+	assert.Equal(t, "60", req.URL.Query().Get("X-Amz-Expires"))
+}
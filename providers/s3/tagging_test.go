@@ -0,0 +1,75 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"github.com/senrok/yadal/constants"
+	"github.com/senrok/yadal/options"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestTagging(t *testing.T) {
+	var tagging []byte
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		switch {
+		case r.URL.Query().Has("tagging") && r.Method == http.MethodPut:
+			tagging, _ = io.ReadAll(r.Body)
+		case r.URL.Query().Has("tagging"):
+			_, _ = w.Write(tagging)
+		case r.Method == http.MethodHead:
+			w.Header().Set(constants.XAmzStorageClass, "STANDARD_IA")
+		case r.Method == http.MethodPut:
+			_, _ = io.Copy(io.Discard, r.Body)
+		}
+	}))
+	defer server.Close()
+
+	d := &Driver{
+		endpoint: server.URL + "/bucket",
+		root:     "/",
+		client:   http.DefaultClient,
+		signer:   NewSigner("s3", "us-east-1", "ak", "sk", false),
+	}
+	ctx := context.TODO()
+
+	tags := map[string]string{"team": "data", "tier": "cold & old"}
+	assert.Nil(t, d.SetTags(ctx, "a.txt", options.SetTags{Tags: tags}))
+	assert.NotEmpty(t, header.Get(constants.ContentMD5))
+	decoded := Tagging{}
+	assert.Nil(t, xml.Unmarshal(tagging, &decoded))
+	assert.Equal(t, []Tag{{Key: "team", Value: "data"}, {Key: "tier", Value: "cold & old"}}, decoded.TagSet)
+
+	got, err := d.GetTags(ctx, "a.txt", options.GetTags{})
+	assert.Nilf(t, err, "%s", err)
+	assert.Equal(t, tags, got)
+
+	content := []byte("Hello,World!")
+	_, err = d.Write(ctx, "a.txt", options.WriteOptions{
+		Size:         uint64(len(content)),
+		StorageClass: "GLACIER_IR",
+		ACL:          "private",
+		Tags:         tags,
+	}, bytes.NewReader(content))
+	assert.Nilf(t, err, "%s", err)
+	assert.Equal(t, "GLACIER_IR", header.Get(constants.XAmzStorageClass))
+	assert.Equal(t, "private", header.Get(constants.XAmzAcl))
+	values, err := url.ParseQuery(header.Get(constants.XAmzTagging))
+	assert.Nil(t, err)
+	assert.Equal(t, "cold & old", values.Get("tier"))
+
+	meta, err := d.Stat(ctx, "a.txt", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "STANDARD_IA", *meta.StorageClass())
+
+	req, err := d.S3InitiateMultipartUploadRequest(ctx, "a.txt", options.CreateMultipart{StorageClass: "STANDARD_IA"})
+	assert.Nil(t, err)
+	assert.Equal(t, "STANDARD_IA", req.Header.Get(constants.XAmzStorageClass))
+}
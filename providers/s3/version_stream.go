@@ -0,0 +1,134 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/utils"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type VersionStream struct {
+	*Driver
+	root            string
+	path            string
+	keyMarker       string
+	versionIdMarker string
+
+	done bool
+}
+
+func (d *VersionStream) NextPage(ctx context.Context) ([]interfaces.Entry, error) {
+	if d.done {
+		return nil, nil
+	}
+	resp, err := d.S3ListObjectVersions(ctx, d.path, d.keyMarker, d.versionIdMarker)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrListVersionsFailed, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.ParseS3Error(errors.ErrListVersionsFailed, d.path, resp)
+	}
+	output := ListVersionsResult{}
+	err = xml.NewDecoder(resp.Body).Decode(&output)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrListVersionsFailed, err)
+	}
+
+	d.done = !output.IsTruncated
+	d.keyMarker = output.NextKeyMarker
+	d.versionIdMarker = output.NextVersionIdMarker
+
+	entries := make([]interfaces.Entry, 0, len(output.CommonPrefixes)+len(output.Versions))
+	for _, prefix := range output.CommonPrefixes {
+		path, err := utils.BuildRealPath(d.root, prefix.Prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries,
+			object.NewEntry(
+				d.Driver,
+				path,
+				object.Metadata{
+					ObjectMode: interfaces.DIR,
+				},
+				false),
+		)
+	}
+
+	for _, version := range output.Versions {
+		// the other elements not named by ListVersionsResult are decoded as versions as well.
+		if version.XMLName.Local != "Version" && version.XMLName.Local != "DeleteMarker" {
+			continue
+		}
+		// the dir itself, see DirStream.
+		if strings.HasSuffix(version.Key, "/") {
+			continue
+		}
+		isDeleteMarker := version.XMLName.Local == "DeleteMarker"
+		meta, err := object.NewMetadata(
+			object.SetMode(interfaces.FILE),
+			object.SetMetadata(version.Size, version.LastModified, version.ETag),
+			object.SetVersion(version.VersionId, version.IsLatest, isDeleteMarker),
+		)
+		if err != nil {
+			return nil, err
+		}
+		path, err := utils.BuildRealPath(d.root, version.Key)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries,
+			object.NewEntry(
+				d.Driver,
+				path,
+				meta,
+				true),
+		)
+	}
+	return entries, nil
+}
+
+func NewVersionStream(d *Driver, root, path string) interfaces.ObjectPageStream {
+	return &VersionStream{
+		Driver: d,
+		root:   root,
+		path:   path,
+	}
+}
+
+// ListVersionsResult see https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListObjectVersions.html
+type ListVersionsResult struct {
+	Name                string               `xml:"Name"`
+	Prefix              string               `xml:"Prefix"`
+	Delimiter           string               `xml:"Delimiter"`
+	MaxKeys             int                  `xml:"MaxKeys"`
+	EncodingType        string               `xml:"EncodingType"`
+	KeyMarker           string               `xml:"KeyMarker"`
+	VersionIdMarker     string               `xml:"VersionIdMarker"`
+	IsTruncated         bool                 `xml:"IsTruncated"`
+	NextKeyMarker       string               `xml:"NextKeyMarker"`
+	NextVersionIdMarker string               `xml:"NextVersionIdMarker"`
+	CommonPrefixes      []OutputCommonPrefix `xml:"CommonPrefixes"`
+	// Versions both `Version` and `DeleteMarker` elements, in the order of the response,
+	// it holds the unknown elements as well, which are skipped by XMLName.
+	Versions []ObjectVersion `xml:",any"`
+}
+
+type ObjectVersion struct {
+	// XMLName is either `Version` or `DeleteMarker`.
+	XMLName      xml.Name
+	Key          string    `xml:"Key"`
+	VersionId    string    `xml:"VersionId"`
+	IsLatest     bool      `xml:"IsLatest"`
+	LastModified time.Time `xml:"LastModified"`
+	ETag         string    `xml:"ETag"`
+	Size         uint64    `xml:"Size"`
+	StorageClass string    `xml:"StorageClass"`
+}
@@ -0,0 +1,135 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"github.com/senrok/yadal/constants"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/options"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const listVersionsOutput = `<?xml version="1.0" encoding="UTF-8"?>
+<ListVersionsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>bucket</Name>
+  <Prefix>dir/</Prefix>
+  <KeyMarker></KeyMarker>
+  <VersionIdMarker></VersionIdMarker>
+  <MaxKeys>1000</MaxKeys>
+  <Delimiter>/</Delimiter>
+  <IsTruncated>false</IsTruncated>
+  <DeleteMarker>
+    <Key>dir/a.txt</Key>
+    <VersionId>v3</VersionId>
+    <IsLatest>true</IsLatest>
+    <LastModified>2022-10-10T10:10:10.000Z</LastModified>
+  </DeleteMarker>
+  <Version>
+    <Key>dir/a.txt</Key>
+    <VersionId>v2</VersionId>
+    <IsLatest>false</IsLatest>
+    <LastModified>2022-10-09T10:10:10.000Z</LastModified>
+    <ETag>"etag-2"</ETag>
+    <Size>12</Size>
+    <StorageClass>STANDARD</StorageClass>
+  </Version>
+  <RequestCharged>requester</RequestCharged>
+  <Version>
+    <Key>dir/b.txt</Key>
+    <VersionId>v1</VersionId>
+    <IsLatest>true</IsLatest>
+    <LastModified>2022-10-08T10:10:10.000Z</LastModified>
+    <ETag>"etag-1"</ETag>
+    <Size>5</Size>
+    <StorageClass>STANDARD</StorageClass>
+  </Version>
+  <CommonPrefixes>
+    <Prefix>dir/sub/</Prefix>
+  </CommonPrefixes>
+</ListVersionsResult>`
+
+func TestVersioning(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		switch {
+		case r.URL.Query().Has("versions"):
+			_, _ = w.Write([]byte(listVersionsOutput))
+		case r.Method == http.MethodPut:
+			_, _ = io.Copy(io.Discard, r.Body)
+			w.Header().Set(constants.ETag, `"etag"`)
+			w.Header().Set(constants.XAmzVersionId, "v4")
+		case r.Method == http.MethodHead:
+			w.Header().Set(constants.XAmzVersionId, r.URL.Query().Get("versionId"))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	d := &Driver{
+		endpoint: server.URL + "/bucket",
+		root:     "/",
+		client:   http.DefaultClient,
+		signer:   NewSigner("s3", "us-east-1", "ak", "sk", false),
+	}
+	ctx := context.TODO()
+
+	content := []byte("Hello,World!")
+	result, err := d.Write(ctx, "dir/a.txt", options.WriteOptions{Size: uint64(len(content))}, bytes.NewReader(content))
+	assert.Nilf(t, err, "%s", err)
+	assert.Equal(t, uint64(len(content)), result.GetSize())
+	assert.Equal(t, "v4", *result.GetVersionId())
+	assert.Equal(t, `"etag"`, *result.GetETag())
+
+	meta, err := d.Stat(ctx, "dir/a.txt", options.StatOptions{VersionId: "v2"})
+	assert.Nil(t, err)
+	assert.Equal(t, "versionId=v2", query)
+	assert.Equal(t, "v2", *meta.VersionId())
+
+	assert.Nil(t, d.Delete(ctx, "dir/a.txt", options.DeleteOptions{VersionId: "v2"}))
+	assert.Equal(t, "versionId=v2", query)
+
+	stream, err := d.ListVersions(ctx, "dir/", options.ListVersions{})
+	assert.Nil(t, err)
+	var entries []interfaces.Entry
+	for stream.HasNext() {
+		entry, err := stream.Next(ctx)
+		assert.Nilf(t, err, "%s", err)
+		entries = append(entries, entry)
+	}
+	assert.Equal(t, 4, len(entries))
+	assert.Equal(t, "dir/sub/", entries[0].Path())
+	assert.Equal(t, interfaces.DIR, entries[0].Metadata().Mode())
+
+	marker := entries[1].Metadata()
+	assert.Equal(t, "dir/a.txt", entries[1].Path())
+	assert.True(t, marker.IsDeleteMarker())
+	assert.True(t, *marker.IsLatest())
+	assert.Equal(t, "v3", *marker.VersionId())
+
+	version := entries[2].Metadata()
+	assert.Equal(t, "dir/a.txt", entries[2].Path())
+	assert.False(t, version.IsDeleteMarker())
+	assert.False(t, *version.IsLatest())
+	assert.Equal(t, "v2", *version.VersionId())
+	assert.Equal(t, uint64(12), *version.ContentLength())
+	assert.Equal(t, "v1", *entries[3].Metadata().VersionId())
+
+	// the unknown elements are not listed, even if the root is not `/`
+	d.root = "/dir/"
+	stream, err = d.ListVersions(ctx, "/", options.ListVersions{})
+	assert.Nil(t, err)
+	entries = nil
+	for stream.HasNext() {
+		entry, err := stream.Next(ctx)
+		assert.Nilf(t, err, "%s", err)
+		entries = append(entries, entry)
+	}
+	assert.Equal(t, 4, len(entries))
+	assert.Equal(t, "b.txt", entries[3].Path())
+}
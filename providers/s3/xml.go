@@ -3,6 +3,8 @@ package s3
 import (
 	"encoding/xml"
 	"github.com/senrok/yadal/interfaces"
+	"sort"
+	"time"
 )
 
 type Part struct {
@@ -26,3 +28,57 @@ func NewCompleteMultipartUploadFromObjectParts(input []interfaces.ObjectPart) Co
 
 	return CompleteMultipartUpload{Parts: parts}
 }
+
+type ListMultipartUploadsResult struct {
+	IsTruncated        bool     `xml:"IsTruncated"`
+	NextKeyMarker      string   `xml:"NextKeyMarker"`
+	NextUploadIdMarker string   `xml:"NextUploadIdMarker"`
+	Uploads            []Upload `xml:"Upload"`
+}
+
+type Upload struct {
+	Key       string    `xml:"Key"`
+	UploadId  string    `xml:"UploadId"`
+	Initiated time.Time `xml:"Initiated"`
+}
+
+type ListPartsResult struct {
+	IsTruncated          bool         `xml:"IsTruncated"`
+	NextPartNumberMarker uint         `xml:"NextPartNumberMarker"`
+	Parts                []ListedPart `xml:"Part"`
+}
+
+type ListedPart struct {
+	PartNumber uint   `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+	Size       uint64 `xml:"Size"`
+}
+
+type Tagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	TagSet  []Tag    `xml:"TagSet>Tag"`
+}
+
+type Tag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+func NewTaggingFromMap(tags map[string]string) Tagging {
+	tagging := Tagging{TagSet: make([]Tag, 0, len(tags))}
+	for k, v := range tags {
+		tagging.TagSet = append(tagging.TagSet, Tag{Key: k, Value: v})
+	}
+	sort.Slice(tagging.TagSet, func(i, j int) bool {
+		return tagging.TagSet[i].Key < tagging.TagSet[j].Key
+	})
+	return tagging
+}
+
+func (t Tagging) Map() map[string]string {
+	tags := make(map[string]string, len(t.TagSet))
+	for _, tag := range t.TagSet {
+		tags[tag.Key] = tag.Value
+	}
+	return tags
+}
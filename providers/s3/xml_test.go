@@ -4,6 +4,7 @@ import (
 	"encoding/xml"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestEncodeCompleteMultipartUpload(t *testing.T) {
@@ -24,3 +25,68 @@ func TestEncodeCompleteMultipartUpload(t *testing.T) {
 	expected := `<CompleteMultipartUpload><Part><PartNumber>1</PartNumber><ETag>a54357aff0632cce46d942af68356b38</ETag></Part><Part><PartNumber>2</PartNumber><ETag>0c78aef83f66abc1fa1e8477f296d394</ETag></Part></CompleteMultipartUpload>`
 	assert.Equal(t, expected, string(b))
 }
+
+func TestDecodeListMultipartUploadsResult(t *testing.T) {
+	input := `<ListMultipartUploadsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Bucket>bucket</Bucket>
+  <KeyMarker></KeyMarker>
+  <UploadIdMarker></UploadIdMarker>
+  <NextKeyMarker>my-movie.m2ts</NextKeyMarker>
+  <NextUploadIdMarker>YW55IGlkZWEgd2h5IGVsdmluZydzIHVwbG9hZCBmYWlsZWQ</NextUploadIdMarker>
+  <MaxUploads>3</MaxUploads>
+  <IsTruncated>true</IsTruncated>
+  <Upload>
+    <Key>my-divisor</Key>
+    <UploadId>XMgbGlrZSBlbHZpbmcncyBub3QgaGF2aW5nIG11Y2ggbHVjaw</UploadId>
+    <StorageClass>STANDARD</StorageClass>
+    <Initiated>2010-11-10T20:48:33.000Z</Initiated>
+  </Upload>
+  <Upload>
+    <Key>my-movie.m2ts</Key>
+    <UploadId>VXBsb2FkIElEIGZvciBlbHZpbmcncyBteS1tb3ZpZS5tMnRzIHVwbG9hZA</UploadId>
+    <StorageClass>STANDARD</StorageClass>
+    <Initiated>2010-11-10T20:48:33.000Z</Initiated>
+  </Upload>
+</ListMultipartUploadsResult>`
+	output := ListMultipartUploadsResult{}
+	err := xml.Unmarshal([]byte(input), &output)
+	assert.Nil(t, err)
+	assert.True(t, output.IsTruncated)
+	assert.Equal(t, "my-movie.m2ts", output.NextKeyMarker)
+	assert.Equal(t, "YW55IGlkZWEgd2h5IGVsdmluZydzIHVwbG9hZCBmYWlsZWQ", output.NextUploadIdMarker)
+	assert.Len(t, output.Uploads, 2)
+	assert.Equal(t, "my-divisor", output.Uploads[0].Key)
+	assert.Equal(t, "XMgbGlrZSBlbHZpbmcncyBub3QgaGF2aW5nIG11Y2ggbHVjaw", output.Uploads[0].UploadId)
+	assert.Equal(t, time.Date(2010, 11, 10, 20, 48, 33, 0, time.UTC), output.Uploads[0].Initiated)
+}
+
+func TestDecodeListPartsResult(t *testing.T) {
+	input := `<ListPartsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Bucket>example-bucket</Bucket>
+  <Key>example-object</Key>
+  <UploadId>XXBsb2FkIElEIGZvciBlbHZpbmcncyVcdS1tb3ZpZS5tMnRzEEEwbG9hZA</UploadId>
+  <PartNumberMarker>1</PartNumberMarker>
+  <NextPartNumberMarker>3</NextPartNumberMarker>
+  <MaxParts>2</MaxParts>
+  <IsTruncated>true</IsTruncated>
+  <Part>
+    <PartNumber>2</PartNumber>
+    <LastModified>2010-11-10T20:48:34.000Z</LastModified>
+    <ETag>"7778aef83f66abc1fa1e8477f296d394"</ETag>
+    <Size>10485760</Size>
+  </Part>
+  <Part>
+    <PartNumber>3</PartNumber>
+    <LastModified>2010-11-10T20:48:33.000Z</LastModified>
+    <ETag>"aaaa18db4cc2f85cedef654fccc4a4x8"</ETag>
+    <Size>10485760</Size>
+  </Part>
+</ListPartsResult>`
+	output := ListPartsResult{}
+	err := xml.Unmarshal([]byte(input), &output)
+	assert.Nil(t, err)
+	assert.True(t, output.IsTruncated)
+	assert.Equal(t, uint(3), output.NextPartNumberMarker)
+	assert.Len(t, output.Parts, 2)
+	assert.Equal(t, uint64(10485760), output.Parts[1].Size)
+}
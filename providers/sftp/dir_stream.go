@@ -0,0 +1,75 @@
+package sftp
+
+import (
+	"context"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/object"
+	"os"
+	"strings"
+)
+
+// DirStream lists the dir by SSH_FXP_READDIR, the entries are returned in a single page.
+type DirStream struct {
+	*Driver
+	root string
+	path string
+
+	done bool
+}
+
+func (d *DirStream) NextPage(ctx context.Context) ([]interfaces.Entry, error) {
+	if d.done {
+		return nil, nil
+	}
+	p, err := d.absPath(d.path)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrListFailed, err, d.path)
+	}
+	var infos []os.FileInfo
+	err = d.withConn(ctx, func(c *conn) (err error) {
+		infos, err = c.ReadDir(p)
+		return
+	})
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrListFailed, err, d.path)
+	}
+	d.done = true
+
+	prefix := d.path
+	if prefix == "/" {
+		prefix = ""
+	}
+	entries := make([]interfaces.Entry, 0, len(infos))
+	for _, info := range infos {
+		// skips the files being written
+		if strings.HasPrefix(info.Name(), tempFilePrefix) {
+			continue
+		}
+		meta, err := object.NewMetadata(object.SetFromFileInfo(info))
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrListFailed, err)
+		}
+		path := prefix + info.Name()
+		if info.IsDir() {
+			path += "/"
+		}
+		entries = append(entries,
+			object.NewEntry(
+				d.Driver,
+				path,
+				meta,
+				!info.IsDir()),
+		)
+	}
+	return entries, nil
+}
+
+func NewDirStream(d *Driver, root, path string) interfaces.ObjectPageStream {
+	return &DirStream{
+		Driver: d,
+		root:   root,
+		path:   path,
+		done:   false,
+	}
+}
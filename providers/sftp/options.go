@@ -0,0 +1,28 @@
+package sftp
+
+import "time"
+
+type Options struct {
+	// Endpoint the address of the server, e.g. `example.com:22`, the port defaults to 22.
+	Endpoint string
+	// Root the absolute path on the server, e.g. `/home/user/data/`
+	Root string
+
+	User     string
+	Password string
+	// Key the PEM encoded private key, KeyPath is read if it's not set.
+	// Both the key and the password are tried if they are set.
+	Key           []byte
+	KeyPath       string
+	KeyPassphrase string
+
+	// KnownHostsPath the known_hosts file verifying the host key, defaults to `~/.ssh/known_hosts`.
+	KnownHostsPath string
+	// InsecureIgnoreHostKey skips the host key verification, it MUST NOT be used in production.
+	InsecureIgnoreHostKey bool
+
+	// MaxConns the max number of connections opened at the same time, defaults to 4.
+	MaxConns int
+	// Timeout the timeout of dialing, defaults to 30s.
+	Timeout time.Duration
+}
@@ -0,0 +1,72 @@
+package sftp
+
+import (
+	"context"
+	"github.com/pkg/sftp"
+	"github.com/senrok/yadal/errors"
+	"golang.org/x/crypto/ssh"
+	"os"
+)
+
+// conn a sftp session over its own ssh connection.
+type conn struct {
+	ssh *ssh.Client
+	*sftp.Client
+}
+
+func (c *conn) close() {
+	_ = c.Client.Close()
+	_ = c.ssh.Close()
+}
+
+// pool keeps the idle connections for reuse, at most `cap(slots)` connections are opened at the same time.
+type pool struct {
+	dial  func(ctx context.Context) (*conn, error)
+	idle  chan *conn
+	slots chan struct{}
+}
+
+func newPool(size int, dial func(ctx context.Context) (*conn, error)) *pool {
+	return &pool{
+		dial:  dial,
+		idle:  make(chan *conn, size),
+		slots: make(chan struct{}, size),
+	}
+}
+
+// get returns an idle connection, or dials a new one if the pool is not full,
+// otherwise it waits for a connection returned.
+func (p *pool) get(ctx context.Context) (*conn, error) {
+	select {
+	case c := <-p.idle:
+		return c, nil
+	default:
+	}
+
+	select {
+	case c := <-p.idle:
+		return c, nil
+	case p.slots <- struct{}{}:
+		c, err := p.dial(ctx)
+		if err != nil {
+			<-p.slots
+			return nil, err
+		}
+		return c, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// put returns the connection used, it's closed unless the error is replied by the server,
+// since the state of the session is unknown after the other errors.
+// The sftp client converts the not found and permission denied statuses into os.ErrNotExist and os.ErrPermission.
+func (p *pool) put(c *conn, err error) {
+	var statusErr *sftp.StatusError
+	if err != nil && !errors.As(err, &statusErr) && !os.IsNotExist(err) && !os.IsPermission(err) {
+		c.close()
+		<-p.slots
+		return
+	}
+	p.idle <- c
+}
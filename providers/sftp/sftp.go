@@ -0,0 +1,385 @@
+package sftp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/pkg/sftp"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/logger"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/providers"
+	"github.com/senrok/yadal/utils"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"time"
+)
+
+const (
+	defaultMaxConns = 4
+	defaultTimeout  = 30 * time.Second
+	// tempFilePrefix the prefix of the files being written, they are renamed to the target once written.
+	tempFilePrefix = ".yadal-tmp-"
+	posixRename    = "posix-rename@openssh.com"
+)
+
+type Driver struct {
+	endpoint string
+	root     string
+	pool     *pool
+	logger.Logger
+}
+
+func (d *Driver) Metadata() interfaces.Metadata {
+	return providers.NewMetadata(interfaces.Sftp, d.root, d.endpoint, interfaces.Read|interfaces.Write|interfaces.List)
+}
+
+// absPath returns the absolute path on the server, e.g. `/path/to/root/dir/`.
+func (d *Driver) absPath(path string) (string, error) {
+	p, err := utils.BuildAbsPath(d.root, path)
+	if err != nil {
+		return "", err
+	}
+	return "/" + p, nil
+}
+
+// withConn runs fn with a connection of the pool.
+func (d *Driver) withConn(ctx context.Context, fn func(c *conn) error) error {
+	c, err := d.pool.get(ctx)
+	if err != nil {
+		return err
+	}
+	err = fn(c)
+	d.pool.put(c, err)
+	return err
+}
+
+func (d *Driver) Create(ctx context.Context, path string, args options.CreateOptions) error {
+	if interfaces.ObjectMode(args.Mode) == interfaces.DIR {
+		p, err := d.absPath(path)
+		if err != nil {
+			return errors.ParseFsError(errors.ErrCreateFailed, err, path)
+		}
+		err = d.withConn(ctx, func(c *conn) error {
+			return c.MkdirAll(p)
+		})
+		if err != nil {
+			return errors.ParseFsError(errors.ErrCreateFailed, err, path)
+		}
+		return nil
+	}
+	if _, err := d.write(ctx, path, bytes.NewReader(nil)); err != nil {
+		return errors.ParseFsError(errors.ErrCreateFailed, err, path)
+	}
+	return nil
+}
+
+// reader streams the remote file, the connection is returned to the pool once it's closed.
+type reader struct {
+	io.Reader
+	file    *sftp.File
+	release func(err error)
+	closed  bool
+}
+
+func (r *reader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	err := r.file.Close()
+	r.release(err)
+	return err
+}
+
+func (d *Driver) Read(ctx context.Context, path string, args options.ReadOptions) (io.ReadCloser, error) {
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	p, err := d.absPath(path)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrReadFailed, err, path)
+	}
+	c, err := d.pool.get(ctx)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrReadFailed, err, path)
+	}
+	file, err := c.Open(p)
+	if err != nil {
+		d.pool.put(c, err)
+		return nil, errors.ParseFsError(errors.ErrReadFailed, err, path)
+	}
+	r := &reader{
+		Reader: file,
+		file:   file,
+		release: func(err error) {
+			d.pool.put(c, err)
+		},
+	}
+	if args.Offset != nil {
+		if _, err = file.Seek(int64(*args.Offset), io.SeekStart); err != nil {
+			_ = r.Close()
+			return nil, errors.ParseFsError(errors.ErrReadFailed, err, path)
+		}
+	}
+	if args.Size != nil {
+		r.Reader = io.LimitReader(file, int64(*args.Size))
+	}
+	return r, nil
+}
+
+func (d *Driver) Write(ctx context.Context, path string, args options.WriteOptions, reader io.Reader) (interfaces.WriteResult, error) {
+	size, err := d.write(ctx, path, reader)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrWriteFailed, err, path)
+	}
+	return object.WriteResult{Size: size}, nil
+}
+
+// write uploads the reader into a temp file next to the target, and renames it to the target once written,
+// so that the readers never see a partial file.
+func (d *Driver) write(ctx context.Context, path string, reader io.Reader) (uint64, error) {
+	p, err := d.absPath(path)
+	if err != nil {
+		return 0, err
+	}
+	var written int64
+	err = d.withConn(ctx, func(c *conn) error {
+		parent := pathpkg.Dir(p)
+		if err := c.MkdirAll(parent); err != nil {
+			return err
+		}
+		tmp := pathpkg.Join(parent, tempFilePrefix+uuid.New().String())
+		file, err := c.Create(tmp)
+		if err != nil {
+			return err
+		}
+		written, err = io.Copy(file, reader)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = rename(c, tmp, p)
+		}
+		if err != nil {
+			_ = c.Remove(tmp)
+		}
+		return err
+	})
+	return uint64(written), err
+}
+
+// rename replaces the target atomically if the server supports the posix-rename extension,
+// otherwise the target is removed first since SSH_FXP_RENAME fails on existing targets.
+func rename(c *conn, old, new string) error {
+	if _, ok := c.HasExtension(posixRename); ok {
+		return c.PosixRename(old, new)
+	}
+	if err := c.Remove(new); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return c.Rename(old, new)
+}
+
+func (d *Driver) Stat(ctx context.Context, path string, args options.StatOptions) (interfaces.ObjectMetadata, error) {
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	p, err := d.absPath(path)
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrStatFailed, err, path)
+	}
+	var info os.FileInfo
+	err = d.withConn(ctx, func(c *conn) (err error) {
+		info, err = c.Stat(p)
+		return
+	})
+	if err != nil {
+		return nil, errors.ParseFsError(errors.ErrStatFailed, err, path)
+	}
+	return object.NewMetadata(object.SetFromFileInfo(info))
+}
+
+// Delete removes the file, or the dir with all of its children.
+func (d *Driver) Delete(ctx context.Context, path string, args options.DeleteOptions) error {
+	if args.VersionId != "" {
+		return errors.ErrUnsupportedMethod
+	}
+	p, err := d.absPath(path)
+	if err != nil {
+		return errors.ParseFsError(errors.ErrDeleteFailed, err, path)
+	}
+	err = d.withConn(ctx, func(c *conn) error {
+		info, err := c.Lstat(p)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return c.RemoveAll(p)
+		}
+		return c.Remove(p)
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return errors.ParseFsError(errors.ErrDeleteFailed, err, path)
+	}
+	return nil
+}
+
+func (d *Driver) List(ctx context.Context, path string, args options.ListOptions) (interfaces.ObjectStream, error) {
+	return object.NewObjectStream(NewDirStream(d, d.root, path)), nil
+}
+
+func (d *Driver) ListVersions(ctx context.Context, path string, args options.ListVersions) (interfaces.ObjectStream, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) PreSign(ctx context.Context, path string, args options.PreSignOptions) (*http.Request, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) GetTags(ctx context.Context, path string, args options.GetTags) (map[string]string, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) SetTags(ctx context.Context, path string, args options.SetTags) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) CreateMultipart(ctx context.Context, path string, args options.CreateMultipart) (string, error) {
+	return "", errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.Reader) (interfaces.ObjectPart, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) CompleteMultipart(ctx context.Context, path string, args options.CompleteMultipart) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) AbortMultipart(ctx context.Context, path string, args options.AbortMultipart) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) ListMultipart(ctx context.Context, path string, args options.ListMultipart) ([]interfaces.MultipartUpload, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+// authMethods returns the public key auth if the key is set, and the password auth if the password is set.
+func authMethods(opt Options) ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+	key := opt.Key
+	if key == nil && opt.KeyPath != "" {
+		var err error
+		if key, err = os.ReadFile(opt.KeyPath); err != nil {
+			return nil, err
+		}
+	}
+	if key != nil {
+		var signer ssh.Signer
+		var err error
+		if opt.KeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(opt.KeyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+	if opt.Password != "" {
+		methods = append(methods, ssh.Password(opt.Password))
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("either password or key is required")
+	}
+	return methods, nil
+}
+
+// hostKeyCallback verifies the host key by the known_hosts file.
+func hostKeyCallback(opt Options) (ssh.HostKeyCallback, error) {
+	if opt.InsecureIgnoreHostKey {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	path := opt.KnownHostsPath
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	return knownhosts.New(path)
+}
+
+// NewDriver returns a driver of the SFTP server, the connections are dialed on demand and reused.
+func NewDriver(ctx context.Context, opt Options) (interfaces.Accessor, error) {
+	if opt.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required")
+	}
+	endpoint := opt.Endpoint
+	if _, _, err := net.SplitHostPort(endpoint); err != nil {
+		endpoint = net.JoinHostPort(endpoint, "22")
+	}
+	auth, err := authMethods(opt)
+	if err != nil {
+		return nil, err
+	}
+	callback, err := hostKeyCallback(opt)
+	if err != nil {
+		return nil, err
+	}
+	timeout := opt.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	config := &ssh.ClientConfig{
+		User:            opt.User,
+		Auth:            auth,
+		HostKeyCallback: callback,
+		Timeout:         timeout,
+	}
+	maxConns := opt.MaxConns
+	if maxConns <= 0 {
+		maxConns = defaultMaxConns
+	}
+
+	dial := func(ctx context.Context) (*conn, error) {
+		dialer := net.Dialer{Timeout: timeout}
+		netConn, err := dialer.DialContext(ctx, "tcp", endpoint)
+		if err != nil {
+			return nil, err
+		}
+		// the handshake is bounded by the timeout as well
+		_ = netConn.SetDeadline(time.Now().Add(timeout))
+		c, chans, reqs, err := ssh.NewClientConn(netConn, endpoint, config)
+		if err != nil {
+			_ = netConn.Close()
+			return nil, err
+		}
+		_ = netConn.SetDeadline(time.Time{})
+		client := ssh.NewClient(c, chans, reqs)
+		sftpClient, err := sftp.NewClient(client)
+		if err != nil {
+			_ = client.Close()
+			return nil, err
+		}
+		return &conn{ssh: client, Client: sftpClient}, nil
+	}
+
+	return &Driver{
+		endpoint: endpoint,
+		root:     utils.NormalizeRoot(opt.Root),
+		pool:     newPool(maxConns, dial),
+	}, nil
+}
@@ -0,0 +1,252 @@
+package sftp
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/pkg/sftp"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/providers/sftp/sftptest"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newServer(t *testing.T, authorizedKeys ...ssh.PublicKey) (*sftptest.Server, string) {
+	server, err := sftptest.NewServer("user", "pass", authorizedKeys...)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	assert.Nil(t, os.WriteFile(knownHosts, []byte(server.KnownHosts()+"\n"), 0600))
+	return server, knownHosts
+}
+
+func setupDriver(t *testing.T, server *sftptest.Server, knownHosts, root string) *Driver {
+	d, err := NewDriver(context.Background(), Options{
+		Endpoint:       server.Addr,
+		Root:           root,
+		User:           "user",
+		Password:       "pass",
+		KnownHostsPath: knownHosts,
+	})
+	assert.Nil(t, err)
+	return d.(*Driver)
+}
+
+func TestDriver(t *testing.T) {
+	server, knownHosts := newServer(t)
+	root := t.TempDir()
+	d := setupDriver(t, server, knownHosts, root)
+	ctx := context.Background()
+	assert.Equal(t, interfaces.Sftp, d.Metadata().Provider())
+	assert.True(t, d.Metadata().Capability().Has(interfaces.Read, interfaces.Write, interfaces.List))
+
+	content := []byte("Hello,World!")
+	result, err := d.Write(ctx, "dir/sub/a b.txt", options.WriteOptions{Size: uint64(len(content))}, bytes.NewReader(content))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(len(content)), result.GetSize())
+	b, err := os.ReadFile(filepath.Join(root, "dir/sub/a b.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, content, b)
+
+	// overwrites the existing file
+	content = []byte("Hello,SFTP!")
+	_, err = d.Write(ctx, "dir/sub/a b.txt", options.WriteOptions{Size: uint64(len(content))}, bytes.NewReader(content))
+	assert.Nil(t, err)
+	entries, err := os.ReadDir(filepath.Join(root, "dir/sub"))
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+
+	meta, err := d.Stat(ctx, "dir/sub/a b.txt", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.FILE, meta.Mode())
+	assert.Equal(t, uint64(len(content)), *meta.ContentLength())
+
+	offset, size := uint64(6), uint64(4)
+	reader, err := d.Read(ctx, "dir/sub/a b.txt", options.ReadOptions{Offset: &offset, Size: &size})
+	assert.Nil(t, err)
+	b, _ = io.ReadAll(reader)
+	assert.Nil(t, reader.Close())
+	assert.Equal(t, "SFTP", string(b))
+
+	meta, err = d.Stat(ctx, "dir/", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.DIR, meta.Mode())
+
+	assert.Nil(t, d.Create(ctx, "empty/", options.CreateOptions{Mode: int8(interfaces.DIR)}))
+	assert.Nil(t, d.Create(ctx, "empty/", options.CreateOptions{Mode: int8(interfaces.DIR)}))
+	assert.Nil(t, d.Create(ctx, "file", options.CreateOptions{Mode: int8(interfaces.FILE)}))
+	meta, err = d.Stat(ctx, "file", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), *meta.ContentLength())
+
+	_, err = d.Stat(ctx, "not-exist", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+	_, err = d.Read(ctx, "not-exist", options.ReadOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+	// the children are deleted with the dir
+	assert.Nil(t, d.Delete(ctx, "dir/", options.DeleteOptions{}))
+	assert.Nil(t, d.Delete(ctx, "dir/", options.DeleteOptions{}))
+	_, err = os.Stat(filepath.Join(root, "dir"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestList(t *testing.T) {
+	server, knownHosts := newServer(t)
+	root := t.TempDir()
+	d := setupDriver(t, server, knownHosts, root)
+	ctx := context.Background()
+
+	for _, path := range []string{"dir/a", "dir/b c", "dir/sub/d"} {
+		_, err := d.Write(ctx, path, options.WriteOptions{Size: 1}, bytes.NewReader([]byte("x")))
+		assert.Nil(t, err)
+	}
+	// the files being written are not listed
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "dir", tempFilePrefix+"e"), nil, 0644))
+
+	for path, expected := range map[string]map[string]interfaces.ObjectMode{
+		"dir/": {
+			"dir/a":    interfaces.FILE,
+			"dir/b c":  interfaces.FILE,
+			"dir/sub/": interfaces.DIR,
+		},
+		"/": {
+			"dir/": interfaces.DIR,
+		},
+	} {
+		stream, err := d.List(ctx, path, options.ListOptions{})
+		assert.Nil(t, err)
+		entries := map[string]interfaces.ObjectMode{}
+		for stream.HasNext() {
+			entry, err := stream.Next(ctx)
+			assert.Nil(t, err)
+			entries[entry.Path()] = entry.Metadata().Mode()
+		}
+		assert.Equal(t, expected, entries)
+	}
+
+	_, err := NewDirStream(d, d.root, "not-exist/").NextPage(ctx)
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+}
+
+func TestAuth(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	sshPublic, err := ssh.NewPublicKey(public)
+	assert.Nil(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	assert.Nil(t, err)
+	block := &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+
+	server, knownHosts := newServer(t, sshPublic)
+	ctx := context.Background()
+	stat := func(opt Options) error {
+		opt.Endpoint = server.Addr
+		opt.Root = t.TempDir()
+		d, err := NewDriver(ctx, opt)
+		if err != nil {
+			return err
+		}
+		_, err = d.Stat(ctx, "/", options.StatOptions{})
+		return err
+	}
+
+	assert.Nil(t, stat(Options{User: "user", Key: pem.EncodeToMemory(block), KnownHostsPath: knownHosts}))
+	err = stat(Options{User: "user", Password: "wrong", KnownHostsPath: knownHosts})
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "unable to authenticate"))
+
+	// the host key is not known
+	other := filepath.Join(t.TempDir(), "known_hosts")
+	assert.Nil(t, os.WriteFile(other, nil, 0600))
+	err = stat(Options{User: "user", Password: "pass", KnownHostsPath: other})
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "key is unknown"))
+	assert.Nil(t, stat(Options{User: "user", Password: "pass", InsecureIgnoreHostKey: true}))
+
+	_, err = NewDriver(ctx, Options{Endpoint: server.Addr, User: "user", KnownHostsPath: knownHosts})
+	assert.NotNil(t, err)
+}
+
+func TestPool(t *testing.T) {
+	server, knownHosts := newServer(t)
+	d, err := NewDriver(context.Background(), Options{
+		Endpoint:       server.Addr,
+		Root:           t.TempDir(),
+		User:           "user",
+		Password:       "pass",
+		KnownHostsPath: knownHosts,
+		MaxConns:       2,
+	})
+	assert.Nil(t, err)
+	p := d.(*Driver).pool
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := d.Stat(ctx, "/", options.StatOptions{})
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, len(p.idle), 2)
+	assert.Equal(t, len(p.slots), len(p.idle))
+
+	// the reader holds the connection until it's closed
+	_, err = d.Write(ctx, "a", options.WriteOptions{Size: 1}, bytes.NewReader([]byte("x")))
+	assert.Nil(t, err)
+	reader, err := d.Read(ctx, "a", options.ReadOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, len(p.slots)-1, len(p.idle))
+	assert.Nil(t, reader.Close())
+	assert.Nil(t, reader.Close())
+	assert.Equal(t, len(p.slots), len(p.idle))
+
+	// the lost connections are dropped
+	opened := len(p.slots)
+	c, err := p.get(ctx)
+	assert.Nil(t, err)
+	c.close()
+	_, err = c.Stat("/")
+	assert.True(t, errors.Is(err, sftp.ErrSSHFxConnectionLost))
+	p.put(c, err)
+	assert.Equal(t, opened-1, len(p.slots))
+	assert.Equal(t, len(p.slots), len(p.idle))
+	_, err = d.Stat(ctx, "a", options.StatOptions{})
+	assert.Nil(t, err)
+
+	// the connections are kept after the errors replied by the server
+	opened = len(p.slots)
+	c, err = p.get(ctx)
+	assert.Nil(t, err)
+	_, err = c.Stat("/not-exist")
+	assert.True(t, os.IsNotExist(err))
+	p.put(c, err)
+	c, err = p.get(ctx)
+	assert.Nil(t, err)
+	p.put(c, &sftp.StatusError{Code: 4})
+	assert.Equal(t, opened, len(p.slots))
+
+	// the other errors drop the connection
+	c, err = p.get(ctx)
+	assert.Nil(t, err)
+	p.put(c, io.ErrUnexpectedEOF)
+	assert.Equal(t, opened-1, len(p.slots))
+	assert.Equal(t, len(p.slots), len(p.idle))
+}
@@ -0,0 +1,128 @@
+// Package sftptest provides an in-process SFTP server for testing.
+package sftptest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"net"
+	"sync"
+)
+
+// Server serves the local file system over SFTP on a random port of 127.0.0.1,
+// the users are authorized by the password or the public key.
+type Server struct {
+	// Addr the address like `127.0.0.1:port`
+	Addr    string
+	HostKey ssh.PublicKey
+
+	listener net.Listener
+	config   *ssh.ServerConfig
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a server accepting the user with the password, or one of the authorized keys.
+func NewServer(user, password string, authorizedKeys ...ssh.PublicKey) (*Server, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	hostKey, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		return nil, err
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, p []byte) (*ssh.Permissions, error) {
+			if meta.User() == user && password != "" && string(p) == password {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("password rejected for %s", meta.User())
+		},
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			for _, authorized := range authorizedKeys {
+				if meta.User() == user && bytes.Equal(key.Marshal(), authorized.Marshal()) {
+					return nil, nil
+				}
+			}
+			return nil, fmt.Errorf("unknown public key for %s", meta.User())
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		Addr:     listener.Addr().String(),
+		HostKey:  hostKey.PublicKey(),
+		listener: listener,
+		config:   config,
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// KnownHosts returns the line of the known_hosts file for the server.
+func (s *Server) KnownHosts() string {
+	return knownhosts.Line([]string{knownhosts.Normalize(s.Addr)}, s.HostKey)
+}
+
+// Close stops accepting connections, the accepted ones are closed by the clients.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	_, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(ok, nil)
+				if !ok {
+					continue
+				}
+				server, err := sftp.NewServer(channel)
+				if err != nil {
+					_ = channel.Close()
+					return
+				}
+				_ = server.Serve()
+				_ = server.Close()
+			}
+		}()
+	}
+}
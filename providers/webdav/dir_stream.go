@@ -0,0 +1,93 @@
+package webdav
+
+import (
+	"context"
+	"fmt"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/utils"
+	"net/url"
+	"strings"
+)
+
+// DirStream lists the members of a collection by PROPFIND with depth 1, they are returned in a single page.
+type DirStream struct {
+	*Driver
+	root string
+	path string
+
+	done bool
+}
+
+func (d *DirStream) NextPage(ctx context.Context) ([]interfaces.Entry, error) {
+	if d.done {
+		return nil, nil
+	}
+	abs, err := d.absPath(d.path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrListFailed, err)
+	}
+	responses, err := d.propfind(ctx, d.path, abs, "1", errors.ErrListFailed)
+	if err != nil {
+		return nil, err
+	}
+	d.done = true
+
+	entries := make([]interfaces.Entry, 0, len(responses))
+	for _, response := range responses {
+		abs, err := d.hrefPath(response.Href)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrListFailed, err)
+		}
+		meta, err := metadata(response)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrListFailed, err)
+		}
+		if meta.Mode() == interfaces.DIR && !strings.HasSuffix(abs, "/") {
+			abs += "/"
+		}
+		// the collection itself is listed as well.
+		if abs == d.root || strings.TrimPrefix(abs, d.root) == d.path {
+			continue
+		}
+		path, err := utils.BuildRealPath(d.root, abs)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrListFailed, err)
+		}
+		entries = append(entries,
+			object.NewEntry(
+				d.Driver,
+				path,
+				meta,
+				meta.Mode() == interfaces.FILE),
+		)
+	}
+	return entries, nil
+}
+
+// hrefPath returns the absolute path of the href like `/path/to/root/dir/a`,
+// the href could be either an url or a path, the path of the endpoint is trimmed.
+func (d *DirStream) hrefPath(href string) (string, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+	p := strings.TrimPrefix(u.Path, d.prefix)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	if !strings.HasPrefix(p, d.root) {
+		return "", fmt.Errorf("%s is out of the root %s", href, d.root)
+	}
+	return p, nil
+}
+
+func NewDirStream(d *Driver, root, path string) interfaces.ObjectPageStream {
+	return &DirStream{
+		Driver: d,
+		root:   root,
+		path:   path,
+		done:   false,
+	}
+}
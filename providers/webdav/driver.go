@@ -0,0 +1,98 @@
+package webdav
+
+import (
+	"context"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/utils"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	methodPropfind = "PROPFIND"
+	methodMkcol    = "MKCOL"
+)
+
+// absPath returns the path towards the endpoint, e.g. `path/to/root/dir/`.
+func (d *Driver) absPath(path string) (string, error) {
+	return utils.BuildAbsPath(d.root, path)
+}
+
+func (d *Driver) buildUrl(abs string) string {
+	return d.endpoint + "/" + utils.EncodePath(abs)
+}
+
+func (d *Driver) newRequest(ctx context.Context, method, abs string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, d.buildUrl(abs), body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case d.username != "":
+		req.SetBasicAuth(d.username, d.password)
+	case d.token != "":
+		req.Header.Set("Authorization", "Bearer "+d.token)
+	}
+	return req, nil
+}
+
+func (d *Driver) GetFile(ctx context.Context, abs string, offset, size *uint64) (*http.Response, error) {
+	req, err := d.newRequest(ctx, http.MethodGet, abs, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset != nil || size != nil {
+		req.Header.Set("Range", options.NewBytesRange(offset, size).String())
+	}
+	return d.client.Do(req)
+}
+
+func (d *Driver) PutFile(ctx context.Context, abs string, size uint64, body io.Reader) (*http.Response, error) {
+	if size == 0 {
+		body = http.NoBody
+	}
+	req, err := d.newRequest(ctx, http.MethodPut, abs, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(size)
+	return d.client.Do(req)
+}
+
+func (d *Driver) MakeCollection(ctx context.Context, abs string) (*http.Response, error) {
+	req, err := d.newRequest(ctx, methodMkcol, abs, nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.client.Do(req)
+}
+
+func (d *Driver) DeleteResource(ctx context.Context, abs string) (*http.Response, error) {
+	req, err := d.newRequest(ctx, http.MethodDelete, abs, nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.client.Do(req)
+}
+
+// Propfind requests the properties of the resource with depth 0, or of its members as well with depth 1.
+func (d *Driver) Propfind(ctx context.Context, abs string, depth string) (*http.Response, error) {
+	req, err := d.newRequest(ctx, methodPropfind, abs, strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Depth", depth)
+	return d.client.Do(req)
+}
+
+// parent returns the parent collection of the path, e.g. `a/b/` of `a/b/c` and `a/b/c/`.
+func parent(abs string) string {
+	abs = strings.TrimSuffix(abs, "/")
+	idx := strings.LastIndex(abs, "/")
+	if idx < 0 {
+		return ""
+	}
+	return abs[:idx+1]
+}
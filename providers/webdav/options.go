@@ -0,0 +1,18 @@
+package webdav
+
+import "net/http"
+
+type Options struct {
+	// Endpoint the url of the WebDAV server, e.g. `https://example.com/remote.php/dav/files/user/`
+	Endpoint string
+	Root     string
+
+	// Username and Password are sent with Basic authentication if Username is set.
+	Username string
+	Password string
+	// Token is sent as the Bearer token if Username is not set.
+	Token string
+
+	// Client the http client, defaults to http.DefaultClient.
+	Client *http.Client
+}
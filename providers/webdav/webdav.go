@@ -0,0 +1,281 @@
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"github.com/senrok/yadal/constants"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/logger"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/providers"
+	"github.com/senrok/yadal/utils"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type Driver struct {
+	endpoint string
+	// prefix the path of the endpoint, which is trimmed from the hrefs of PROPFIND.
+	prefix   string
+	root     string
+	username string
+	password string
+	token    string
+	client   *http.Client
+	logger.Logger
+}
+
+func (d *Driver) Metadata() interfaces.Metadata {
+	return providers.NewMetadata(interfaces.Webdav, d.root, d.endpoint, interfaces.Read|interfaces.Write|interfaces.List)
+}
+
+// mkcol creates the collection, conflict is true if its parent is missing.
+func (d *Driver) mkcol(ctx context.Context, abs string, kind error) (conflict bool, err error) {
+	resp, err := d.MakeCollection(ctx, abs)
+	if err != nil {
+		return false, errors.Wrap(kind, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	// 405 if the collection exists
+	case http.StatusCreated, http.StatusOK, http.StatusMethodNotAllowed:
+		return false, nil
+	case http.StatusConflict:
+		return true, nil
+	default:
+		return false, errors.ParseHttpError(kind, abs, resp)
+	}
+}
+
+// createDir creates the collection and its missing parents, the endpoint itself is assumed to exist.
+func (d *Driver) createDir(ctx context.Context, abs string, kind error) error {
+	if abs == "" {
+		return nil
+	}
+	conflict, err := d.mkcol(ctx, abs, kind)
+	if err != nil || !conflict {
+		return err
+	}
+	if err = d.createDir(ctx, parent(abs), kind); err != nil {
+		return err
+	}
+	if conflict, err = d.mkcol(ctx, abs, kind); conflict {
+		return errors.Wrap(kind, fmt.Errorf("failed to create the parent of %s", abs))
+	}
+	return err
+}
+
+func (d *Driver) Create(ctx context.Context, path string, args options.CreateOptions) error {
+	abs, err := d.absPath(path)
+	if err != nil {
+		return errors.Wrap(errors.ErrCreateFailed, err)
+	}
+	if interfaces.ObjectMode(args.Mode) == interfaces.DIR {
+		return d.createDir(ctx, abs, errors.ErrCreateFailed)
+	}
+	_, err = d.put(ctx, path, abs, 0, nil, errors.ErrCreateFailed)
+	return err
+}
+
+func (d *Driver) Read(ctx context.Context, path string, args options.ReadOptions) (io.ReadCloser, error) {
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	abs, err := d.absPath(path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrReadFailed, err)
+	}
+	resp, err := d.GetFile(ctx, abs, args.Offset, args.Size)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrReadFailed, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent, http.StatusOK:
+		return resp.Body, nil
+	default:
+		defer resp.Body.Close()
+		return nil, errors.ParseHttpError(errors.ErrReadFailed, path, resp)
+	}
+}
+
+// put uploads the file, the parents are created first since PUT doesn't create the missing collections.
+func (d *Driver) put(ctx context.Context, path, abs string, size uint64, reader io.Reader, kind error) (interfaces.WriteResult, error) {
+	if err := d.createDir(ctx, parent(abs), kind); err != nil {
+		return nil, err
+	}
+	resp, err := d.PutFile(ctx, abs, size, reader)
+	if err != nil {
+		return nil, errors.Wrap(kind, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusNoContent, http.StatusOK:
+		result := object.WriteResult{Size: size}
+		if etag := resp.Header.Get(constants.ETag); etag != "" {
+			result.ETag = &etag
+		}
+		return result, nil
+	default:
+		return nil, errors.ParseHttpError(kind, path, resp)
+	}
+}
+
+func (d *Driver) Write(ctx context.Context, path string, args options.WriteOptions, reader io.Reader) (interfaces.WriteResult, error) {
+	abs, err := d.absPath(path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrWriteFailed, err)
+	}
+	return d.put(ctx, path, abs, args.Size, reader, errors.ErrWriteFailed)
+}
+
+// propfind returns the responses of the resource and its members if depth is 1.
+func (d *Driver) propfind(ctx context.Context, path, abs, depth string, kind error) ([]Response, error) {
+	resp, err := d.Propfind(ctx, abs, depth)
+	if err != nil {
+		return nil, errors.Wrap(kind, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, errors.ParseHttpError(kind, path, resp)
+	}
+	output := Multistatus{}
+	if err = xml.NewDecoder(resp.Body).Decode(&output); err != nil {
+		return nil, errors.Wrap(kind, err)
+	}
+	return output.Responses, nil
+}
+
+// metadata maps the properties of the response, the etag of WebDAV is not the md5.
+func metadata(r Response) (interfaces.ObjectMetadata, error) {
+	prop := r.prop()
+	mode := interfaces.FILE
+	if prop.ResourceType.Collection != nil {
+		mode = interfaces.DIR
+	}
+	return object.NewMetadata(
+		object.SetMode(mode),
+		object.SetMetadataFromHeader(prop.header()),
+		object.SetETag(prop.GetETag),
+	)
+}
+
+func (d *Driver) Stat(ctx context.Context, path string, args options.StatOptions) (interfaces.ObjectMetadata, error) {
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	if path == "/" {
+		return object.Metadata{ObjectMode: interfaces.DIR}, nil
+	}
+	abs, err := d.absPath(path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrStatFailed, err)
+	}
+	responses, err := d.propfind(ctx, path, abs, "0", errors.ErrStatFailed)
+	if err != nil {
+		return nil, err
+	}
+	if len(responses) == 0 {
+		return nil, errors.Wrap(errors.ErrStatFailed, fmt.Errorf("no properties of %s returned", path))
+	}
+	meta, err := metadata(responses[0])
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrStatFailed, err)
+	}
+	return meta, nil
+}
+
+// Delete removes the resource, the members of a collection are removed as well.
+func (d *Driver) Delete(ctx context.Context, path string, args options.DeleteOptions) error {
+	if args.VersionId != "" {
+		return errors.ErrUnsupportedMethod
+	}
+	abs, err := d.absPath(path)
+	if err != nil {
+		return errors.Wrap(errors.ErrDeleteFailed, err)
+	}
+	resp, err := d.DeleteResource(ctx, abs)
+	if err != nil {
+		return errors.Wrap(errors.ErrDeleteFailed, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusOK, http.StatusNotFound:
+		return nil
+	default:
+		return errors.ParseHttpError(errors.ErrDeleteFailed, path, resp)
+	}
+}
+
+func (d *Driver) List(ctx context.Context, path string, args options.ListOptions) (interfaces.ObjectStream, error) {
+	return object.NewObjectStream(NewDirStream(d, d.root, path)), nil
+}
+
+func (d *Driver) ListVersions(ctx context.Context, path string, args options.ListVersions) (interfaces.ObjectStream, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) PreSign(ctx context.Context, path string, args options.PreSignOptions) (*http.Request, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) GetTags(ctx context.Context, path string, args options.GetTags) (map[string]string, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) SetTags(ctx context.Context, path string, args options.SetTags) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) CreateMultipart(ctx context.Context, path string, args options.CreateMultipart) (string, error) {
+	return "", errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.Reader) (interfaces.ObjectPart, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) CompleteMultipart(ctx context.Context, path string, args options.CompleteMultipart) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) AbortMultipart(ctx context.Context, path string, args options.AbortMultipart) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) ListMultipart(ctx context.Context, path string, args options.ListMultipart) ([]interfaces.MultipartUpload, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+// NewDriver returns a driver of the WebDAV server, the requests are authorized with Basic authentication
+// if the username is set, or with the Bearer token, otherwise they are anonymous.
+func NewDriver(ctx context.Context, opt Options) (interfaces.Accessor, error) {
+	if opt.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required")
+	}
+	u, err := url.Parse(strings.TrimSuffix(opt.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	client := opt.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Driver{
+		endpoint: u.String(),
+		prefix:   u.Path,
+		root:     utils.NormalizeRoot(opt.Root),
+		username: opt.Username,
+		password: opt.Password,
+		token:    opt.Token,
+		client:   client,
+	}, nil
+}
@@ -0,0 +1,176 @@
+package webdav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/options"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/webdav"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newServer serves an in-memory WebDAV server under `/dav`, the requests are authorized with Basic authentication.
+func newServer(t *testing.T) *httptest.Server {
+	handler := &webdav.Handler{
+		Prefix:     "/dav",
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func setupDriver(t *testing.T, server *httptest.Server, root string) interfaces.Accessor {
+	d, err := NewDriver(context.Background(), Options{
+		Endpoint: server.URL + "/dav/",
+		Root:     root,
+		Username: "user",
+		Password: "pass",
+	})
+	assert.Nil(t, err)
+	return d
+}
+
+func TestDriver(t *testing.T) {
+	server := newServer(t)
+	d := setupDriver(t, server, "/root/")
+	ctx := context.Background()
+	assert.Equal(t, interfaces.Webdav, d.Metadata().Provider())
+	assert.True(t, d.Metadata().Capability().Has(interfaces.Read, interfaces.Write, interfaces.List))
+	assert.False(t, d.Metadata().Capability().Has(interfaces.Multipart))
+
+	// the missing parents are created
+	content := []byte("Hello,World!")
+	result, err := d.Write(ctx, "dir/sub/a b.txt", options.WriteOptions{Size: uint64(len(content))}, bytes.NewReader(content))
+	assert.Nil(t, err)
+	assert.NotNil(t, result.GetETag())
+
+	meta, err := d.Stat(ctx, "dir/sub/a b.txt", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.FILE, meta.Mode())
+	assert.Equal(t, uint64(len(content)), *meta.ContentLength())
+	assert.Equal(t, *result.GetETag(), *meta.ETag())
+	assert.Nil(t, meta.ContentMD5())
+	assert.NotNil(t, meta.LastModified())
+
+	offset, size := uint64(6), uint64(5)
+	reader, err := d.Read(ctx, "dir/sub/a b.txt", options.ReadOptions{Offset: &offset, Size: &size})
+	assert.Nil(t, err)
+	b, _ := io.ReadAll(reader)
+	assert.Equal(t, "World", string(b))
+
+	meta, err = d.Stat(ctx, "dir/", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.DIR, meta.Mode())
+
+	assert.Nil(t, d.Create(ctx, "empty/", options.CreateOptions{Mode: int8(interfaces.DIR)}))
+	assert.Nil(t, d.Create(ctx, "empty/", options.CreateOptions{Mode: int8(interfaces.DIR)}))
+	assert.Nil(t, d.Create(ctx, "file", options.CreateOptions{Mode: int8(interfaces.FILE)}))
+	meta, err = d.Stat(ctx, "file", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), *meta.ContentLength())
+
+	_, err = d.Stat(ctx, "not-exist", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+	_, err = d.Read(ctx, "not-exist", options.ReadOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+	// the members are deleted with the collection
+	assert.Nil(t, d.Delete(ctx, "dir/", options.DeleteOptions{}))
+	assert.Nil(t, d.Delete(ctx, "dir/", options.DeleteOptions{}))
+	_, err = d.Stat(ctx, "dir/sub/a b.txt", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+	// a wrong password is rejected
+	wrong, err := NewDriver(ctx, Options{Endpoint: server.URL + "/dav", Username: "user", Password: "wrong"})
+	assert.Nil(t, err)
+	_, err = wrong.Stat(ctx, "root/file", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrPermissionDenied))
+}
+
+func TestList(t *testing.T) {
+	server := newServer(t)
+	d := setupDriver(t, server, "/root/")
+	ctx := context.Background()
+
+	for _, path := range []string{"dir/a", "dir/b c", "dir/sub/d"} {
+		_, err := d.Write(ctx, path, options.WriteOptions{Size: 1}, bytes.NewReader([]byte("x")))
+		assert.Nil(t, err)
+	}
+
+	for path, expected := range map[string]map[string]interfaces.ObjectMode{
+		"dir/": {
+			"dir/a":    interfaces.FILE,
+			"dir/b c":  interfaces.FILE,
+			"dir/sub/": interfaces.DIR,
+		},
+		"/": {
+			"dir/": interfaces.DIR,
+		},
+	} {
+		stream, err := d.List(ctx, path, options.ListOptions{})
+		assert.Nil(t, err)
+		entries := map[string]interfaces.ObjectMode{}
+		for stream.HasNext() {
+			entry, err := stream.Next(ctx)
+			assert.Nil(t, err)
+			entries[entry.Path()] = entry.Metadata().Mode()
+		}
+		assert.Equal(t, expected, entries)
+	}
+
+	_, err := NewDirStream(d.(*Driver), "/root/", "not-exist/").NextPage(ctx)
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+}
+
+func TestMultistatus(t *testing.T) {
+	// the hrefs are urls and the properties not found are in another propstat
+	body := `<?xml version="1.0"?>
+<d:multistatus xmlns:d="DAV:">
+  <d:response>
+    <d:href>https://example.com/remote.php/dav/files/user/root/a%20b.txt</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:resourcetype/>
+        <d:getcontentlength>12</d:getcontentlength>
+        <d:getlastmodified>Mon, 02 Jan 2006 15:04:05 GMT</d:getlastmodified>
+        <d:getetag>"5f0a"</d:getetag>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+    <d:propstat>
+      <d:prop><d:getcontenttype/></d:prop>
+      <d:status>HTTP/1.1 404 Not Found</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`
+	output := Multistatus{}
+	assert.Nil(t, xml.Unmarshal([]byte(body), &output))
+	assert.Len(t, output.Responses, 1)
+
+	meta, err := metadata(output.Responses[0])
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.FILE, meta.Mode())
+	assert.Equal(t, uint64(12), *meta.ContentLength())
+	assert.Equal(t, "\"5f0a\"", *meta.ETag())
+	assert.Equal(t, int64(1136214245), meta.LastModified().Unix())
+
+	d, err := NewDriver(context.Background(), Options{Endpoint: "https://example.com/remote.php/dav/files/user/", Root: "/root"})
+	assert.Nil(t, err)
+	path, err := NewDirStream(d.(*Driver), "/root/", "/").(*DirStream).hrefPath(output.Responses[0].Href)
+	assert.Nil(t, err)
+	assert.Equal(t, "/root/a b.txt", path)
+}
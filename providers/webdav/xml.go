@@ -0,0 +1,64 @@
+package webdav
+
+import (
+	"encoding/xml"
+	"github.com/senrok/yadal/constants"
+	"net/http"
+	"strings"
+)
+
+// propfindBody requests the properties mapped into object.Metadata.
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>` +
+	`<D:propfind xmlns:D="DAV:"><D:prop>` +
+	`<D:resourcetype/><D:getcontentlength/><D:getlastmodified/><D:getetag/>` +
+	`</D:prop></D:propfind>`
+
+// Multistatus the response of PROPFIND
+type Multistatus struct {
+	XMLName   xml.Name   `xml:"DAV: multistatus"`
+	Responses []Response `xml:"DAV: response"`
+}
+
+type Response struct {
+	Href     string     `xml:"DAV: href"`
+	Propstat []Propstat `xml:"DAV: propstat"`
+}
+
+type Propstat struct {
+	Prop   Prop   `xml:"DAV: prop"`
+	Status string `xml:"DAV: status"`
+}
+
+type Prop struct {
+	ResourceType     ResourceType `xml:"DAV: resourcetype"`
+	GetContentLength string       `xml:"DAV: getcontentlength"`
+	GetLastModified  string       `xml:"DAV: getlastmodified"`
+	GetETag          string       `xml:"DAV: getetag"`
+}
+
+type ResourceType struct {
+	Collection *struct{} `xml:"DAV: collection"`
+}
+
+// prop returns the properties found, the ones not found are listed in another propstat with 404.
+func (r Response) prop() Prop {
+	for _, propstat := range r.Propstat {
+		// e.g. `HTTP/1.1 200 OK`
+		if fields := strings.Fields(propstat.Status); len(fields) > 1 && fields[1] == "200" {
+			return propstat.Prop
+		}
+	}
+	return Prop{}
+}
+
+// header returns the properties as the headers of the resource.
+func (p Prop) header() http.Header {
+	header := http.Header{}
+	if p.GetContentLength != "" {
+		header.Set(constants.ContentLength, p.GetContentLength)
+	}
+	if p.GetLastModified != "" {
+		header.Set(constants.LastModified, p.GetLastModified)
+	}
+	return header
+}
@@ -0,0 +1,108 @@
+package webhdfs
+
+import (
+	"context"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/object"
+	"net/http"
+)
+
+// DirStream lists the dir by LISTSTATUS_BATCH page by page, or by LISTSTATUS in a single page if the batch is disabled.
+type DirStream struct {
+	*Driver
+	root string
+	path string
+
+	// startAfter the last child listed
+	startAfter string
+	done       bool
+}
+
+// listStatus returns the page of the children and the number of the remaining ones.
+func (d *DirStream) listStatus(ctx context.Context, abs string) ([]FileStatus, int, error) {
+	var resp *http.Response
+	var err error
+	if d.disableListBatch {
+		resp, err = d.ListStatus(ctx, abs)
+	} else {
+		resp, err = d.ListStatusBatch(ctx, abs, d.startAfter)
+	}
+	if err != nil {
+		return nil, 0, errors.Wrap(errors.ErrListFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, errors.ParseWebhdfsError(errors.ErrListFailed, d.path, resp)
+	}
+	if d.disableListBatch {
+		output := ListStatusResponse{}
+		if err = decode(resp, errors.ErrListFailed, &output); err != nil {
+			return nil, 0, err
+		}
+		return output.FileStatuses.FileStatus, 0, nil
+	}
+	output := ListStatusBatchResponse{}
+	if err = decode(resp, errors.ErrListFailed, &output); err != nil {
+		return nil, 0, err
+	}
+	listing := output.DirectoryListing
+	return listing.PartialListing.FileStatuses.FileStatus, listing.RemainingEntries, nil
+}
+
+func (d *DirStream) NextPage(ctx context.Context) ([]interfaces.Entry, error) {
+	if d.done {
+		return nil, nil
+	}
+	abs, err := d.absPath(d.path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrListFailed, err)
+	}
+	statuses, remaining, err := d.listStatus(ctx, abs)
+	if err != nil {
+		return nil, err
+	}
+	if remaining == 0 || len(statuses) == 0 {
+		d.done = true
+	} else {
+		d.startAfter = statuses[len(statuses)-1].PathSuffix
+	}
+
+	prefix := d.path
+	if prefix == "/" {
+		prefix = ""
+	}
+	entries := make([]interfaces.Entry, 0, len(statuses))
+	for _, status := range statuses {
+		// the file itself is listed with the empty suffix if the path is a file
+		if status.PathSuffix == "" {
+			continue
+		}
+		meta, err := metadata(status)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrListFailed, err)
+		}
+		path := prefix + status.PathSuffix
+		if status.Type == typeDirectory {
+			path += "/"
+		}
+		entries = append(entries,
+			object.NewEntry(
+				d.Driver,
+				path,
+				meta,
+				status.Type != typeDirectory),
+		)
+	}
+	return entries, nil
+}
+
+func NewDirStream(d *Driver, root, path string) interfaces.ObjectPageStream {
+	return &DirStream{
+		Driver: d,
+		root:   root,
+		path:   path,
+		done:   false,
+	}
+}
@@ -0,0 +1,135 @@
+package webhdfs
+
+import (
+	"context"
+	"github.com/senrok/yadal/utils"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	opOpen            = "OPEN"
+	opCreate          = "CREATE"
+	opGetFileStatus   = "GETFILESTATUS"
+	opListStatus      = "LISTSTATUS"
+	opListStatusBatch = "LISTSTATUS_BATCH"
+	opMkdirs          = "MKDIRS"
+	opDelete          = "DELETE"
+)
+
+// absPath returns the absolute path on HDFS without the trailing slash, e.g. `/path/to/root/dir`.
+func (d *Driver) absPath(path string) (string, error) {
+	p, err := utils.BuildAbsPath(d.root, path)
+	if err != nil {
+		return "", err
+	}
+	return "/" + strings.TrimSuffix(p, "/"), nil
+}
+
+// buildUrl returns the url of the operation on the path, the auth is set in the query.
+func (d *Driver) buildUrl(abs, op string, query url.Values) string {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("op", op)
+	switch {
+	case d.delegationToken != "":
+		query.Set("delegation", d.delegationToken)
+	case d.user != "":
+		query.Set("user.name", d.user)
+	}
+	return d.endpoint + "/webhdfs/v1" + utils.EncodePath(abs) + "?" + query.Encode()
+}
+
+func (d *Driver) newRequest(ctx context.Context, method, abs, op string, query url.Values) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, method, d.buildUrl(abs, op, query), nil)
+}
+
+// Open reads the file, the namenode redirects the request to a datanode which is followed by the client.
+func (d *Driver) Open(ctx context.Context, abs string, offset, size *uint64) (*http.Response, error) {
+	query := url.Values{}
+	if offset != nil {
+		query.Set("offset", strconv.FormatUint(*offset, 10))
+	}
+	if size != nil {
+		query.Set("length", strconv.FormatUint(*size, 10))
+	}
+	req, err := d.newRequest(ctx, http.MethodGet, abs, opOpen, query)
+	if err != nil {
+		return nil, err
+	}
+	return d.client.Do(req)
+}
+
+// CreateLocation asks the namenode for the datanode to write the file, the redirect is not followed
+// since the body can't be resent.
+func (d *Driver) CreateLocation(ctx context.Context, abs string) (*http.Response, error) {
+	req, err := d.newRequest(ctx, http.MethodPut, abs, opCreate, url.Values{"overwrite": {"true"}})
+	if err != nil {
+		return nil, err
+	}
+	return d.noRedirectClient.Do(req)
+}
+
+// CreateFile writes the file to the location replied by CreateLocation.
+func (d *Driver) CreateFile(ctx context.Context, location string, size uint64, body io.Reader) (*http.Response, error) {
+	if size == 0 {
+		body = http.NoBody
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, location, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(size)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	return d.client.Do(req)
+}
+
+func (d *Driver) GetFileStatus(ctx context.Context, abs string) (*http.Response, error) {
+	req, err := d.newRequest(ctx, http.MethodGet, abs, opGetFileStatus, nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.client.Do(req)
+}
+
+func (d *Driver) ListStatus(ctx context.Context, abs string) (*http.Response, error) {
+	req, err := d.newRequest(ctx, http.MethodGet, abs, opListStatus, nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.client.Do(req)
+}
+
+// ListStatusBatch lists a page of the dir after the child, the first page is listed if startAfter is empty.
+func (d *Driver) ListStatusBatch(ctx context.Context, abs, startAfter string) (*http.Response, error) {
+	query := url.Values{}
+	if startAfter != "" {
+		query.Set("startAfter", startAfter)
+	}
+	req, err := d.newRequest(ctx, http.MethodGet, abs, opListStatusBatch, query)
+	if err != nil {
+		return nil, err
+	}
+	return d.client.Do(req)
+}
+
+func (d *Driver) Mkdirs(ctx context.Context, abs string) (*http.Response, error) {
+	req, err := d.newRequest(ctx, http.MethodPut, abs, opMkdirs, nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.client.Do(req)
+}
+
+// DeleteFile deletes the file, or the dir with all of its children.
+func (d *Driver) DeleteFile(ctx context.Context, abs string) (*http.Response, error) {
+	req, err := d.newRequest(ctx, http.MethodDelete, abs, opDelete, url.Values{"recursive": {"true"}})
+	if err != nil {
+		return nil, err
+	}
+	return d.client.Do(req)
+}
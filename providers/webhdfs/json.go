@@ -0,0 +1,56 @@
+package webhdfs
+
+// FileStatus see https://hadoop.apache.org/docs/stable/hadoop-project-dist/hadoop-hdfs/WebHDFS.html#FileStatus_JSON_Schema
+type FileStatus struct {
+	AccessTime int64  `json:"accessTime"`
+	BlockSize  int64  `json:"blockSize"`
+	Group      string `json:"group"`
+	Length     int64  `json:"length"`
+	// ModificationTime the milliseconds since the epoch.
+	ModificationTime int64  `json:"modificationTime"`
+	Owner            string `json:"owner"`
+	// PathSuffix the name of the child in the listings, it's empty in GETFILESTATUS.
+	PathSuffix  string `json:"pathSuffix"`
+	Permission  string `json:"permission"`
+	Replication int    `json:"replication"`
+	// Type either `FILE`, `DIRECTORY` or `SYMLINK`.
+	Type string `json:"type"`
+}
+
+const (
+	typeFile      = "FILE"
+	typeDirectory = "DIRECTORY"
+)
+
+type FileStatusResponse struct {
+	FileStatus FileStatus `json:"FileStatus"`
+}
+
+type FileStatuses struct {
+	FileStatus []FileStatus `json:"FileStatus"`
+}
+
+type ListStatusResponse struct {
+	FileStatuses FileStatuses `json:"FileStatuses"`
+}
+
+// DirectoryListing see https://hadoop.apache.org/docs/stable/hadoop-project-dist/hadoop-hdfs/WebHDFS.html#DirectoryListing_JSON_Schema
+type DirectoryListing struct {
+	PartialListing struct {
+		FileStatuses FileStatuses `json:"FileStatuses"`
+	} `json:"partialListing"`
+	RemainingEntries int `json:"remainingEntries"`
+}
+
+type ListStatusBatchResponse struct {
+	DirectoryListing DirectoryListing `json:"DirectoryListing"`
+}
+
+type BooleanResponse struct {
+	Boolean bool `json:"boolean"`
+}
+
+// LocationResponse the datanode to write, replied if `noredirect=true` is set.
+type LocationResponse struct {
+	Location string `json:"Location"`
+}
@@ -0,0 +1,21 @@
+package webhdfs
+
+import "net/http"
+
+type Options struct {
+	// Endpoint the http address of the namenode, e.g. `http://namenode:9870`
+	Endpoint string
+	// Root the absolute path on HDFS, e.g. `/user/hadoop/data/`
+	Root string
+
+	// DelegationToken is sent as `delegation`, User is sent as `user.name` if the token is not set.
+	DelegationToken string
+	User            string
+
+	// DisableListBatch lists the dirs by `LISTSTATUS` in a single page instead of `LISTSTATUS_BATCH`,
+	// for the clusters older than Hadoop 2.8.
+	DisableListBatch bool
+
+	// Client the http client, defaults to http.DefaultClient.
+	Client *http.Client
+}
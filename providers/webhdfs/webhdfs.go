@@ -0,0 +1,289 @@
+package webhdfs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/logger"
+	"github.com/senrok/yadal/object"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/providers"
+	"github.com/senrok/yadal/utils"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type Driver struct {
+	endpoint         string
+	root             string
+	delegationToken  string
+	user             string
+	disableListBatch bool
+	client           *http.Client
+	// noRedirectClient the client returning the redirects of CREATE instead of following them.
+	noRedirectClient *http.Client
+	logger.Logger
+}
+
+func (d *Driver) Metadata() interfaces.Metadata {
+	return providers.NewMetadata(interfaces.Webhdfs, d.root, d.endpoint, interfaces.Read|interfaces.Write|interfaces.List)
+}
+
+// decode decodes the json body of the successful response.
+func decode(resp *http.Response, kind error, output interface{}) error {
+	if err := json.NewDecoder(resp.Body).Decode(output); err != nil {
+		return errors.Wrap(kind, err)
+	}
+	return nil
+}
+
+func (d *Driver) mkdirs(ctx context.Context, path, abs string, kind error) error {
+	resp, err := d.Mkdirs(ctx, abs)
+	if err != nil {
+		return errors.Wrap(kind, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.ParseWebhdfsError(kind, path, resp)
+	}
+	output := BooleanResponse{}
+	if err = decode(resp, kind, &output); err != nil {
+		return err
+	}
+	if !output.Boolean {
+		return errors.Wrap(kind, fmt.Errorf("failed to create %s", path))
+	}
+	return nil
+}
+
+func (d *Driver) Create(ctx context.Context, path string, args options.CreateOptions) error {
+	abs, err := d.absPath(path)
+	if err != nil {
+		return errors.Wrap(errors.ErrCreateFailed, err)
+	}
+	if interfaces.ObjectMode(args.Mode) == interfaces.DIR {
+		return d.mkdirs(ctx, path, abs, errors.ErrCreateFailed)
+	}
+	_, err = d.create(ctx, path, abs, 0, nil, errors.ErrCreateFailed)
+	return err
+}
+
+func (d *Driver) Read(ctx context.Context, path string, args options.ReadOptions) (io.ReadCloser, error) {
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	abs, err := d.absPath(path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrReadFailed, err)
+	}
+	resp, err := d.Open(ctx, abs, args.Offset, args.Size)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrReadFailed, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, errors.ParseWebhdfsError(errors.ErrReadFailed, path, resp)
+	}
+	return resp.Body, nil
+}
+
+// location returns the datanode to write, it's either redirected or replied in the body if `noredirect` is set.
+func (d *Driver) location(ctx context.Context, path, abs string, kind error) (string, error) {
+	resp, err := d.CreateLocation(ctx, abs)
+	if err != nil {
+		return "", errors.Wrap(kind, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusTemporaryRedirect, http.StatusFound, http.StatusSeeOther:
+		location, err := resp.Location()
+		if err != nil {
+			return "", errors.Wrap(kind, err)
+		}
+		return location.String(), nil
+	case http.StatusOK:
+		output := LocationResponse{}
+		if err = decode(resp, kind, &output); err != nil {
+			return "", err
+		}
+		if output.Location == "" {
+			return "", errors.Wrap(kind, fmt.Errorf("no location of %s replied", path))
+		}
+		return output.Location, nil
+	default:
+		return "", errors.ParseWebhdfsError(kind, path, resp)
+	}
+}
+
+// create writes the file in two steps, the namenode redirects CREATE to a datanode without the body,
+// then the body is sent to the datanode. The missing parents are created by HDFS.
+func (d *Driver) create(ctx context.Context, path, abs string, size uint64, reader io.Reader, kind error) (interfaces.WriteResult, error) {
+	location, err := d.location(ctx, path, abs, kind)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.CreateFile(ctx, location, size, reader)
+	if err != nil {
+		return nil, errors.Wrap(kind, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, errors.ParseWebhdfsError(kind, path, resp)
+	}
+	return object.WriteResult{Size: size}, nil
+}
+
+func (d *Driver) Write(ctx context.Context, path string, args options.WriteOptions, reader io.Reader) (interfaces.WriteResult, error) {
+	abs, err := d.absPath(path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrWriteFailed, err)
+	}
+	return d.create(ctx, path, abs, args.Size, reader, errors.ErrWriteFailed)
+}
+
+// metadata maps the file status, HDFS has no etag.
+func metadata(status FileStatus) (interfaces.ObjectMetadata, error) {
+	mode := interfaces.Unknown
+	switch status.Type {
+	case typeFile:
+		mode = interfaces.FILE
+	case typeDirectory:
+		mode = interfaces.DIR
+	}
+	return object.NewMetadata(
+		object.SetMode(mode),
+		object.SetMetadata(uint64(status.Length), time.UnixMilli(status.ModificationTime), ""),
+	)
+}
+
+func (d *Driver) Stat(ctx context.Context, path string, args options.StatOptions) (interfaces.ObjectMetadata, error) {
+	if args.VersionId != "" {
+		return nil, errors.ErrUnsupportedMethod
+	}
+	if path == "/" {
+		return object.Metadata{ObjectMode: interfaces.DIR}, nil
+	}
+	abs, err := d.absPath(path)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrStatFailed, err)
+	}
+	resp, err := d.GetFileStatus(ctx, abs)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrStatFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.ParseWebhdfsError(errors.ErrStatFailed, path, resp)
+	}
+	output := FileStatusResponse{}
+	if err = decode(resp, errors.ErrStatFailed, &output); err != nil {
+		return nil, err
+	}
+	meta, err := metadata(output.FileStatus)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrStatFailed, err)
+	}
+	return meta, nil
+}
+
+// Delete removes the file, or the dir with all of its children.
+func (d *Driver) Delete(ctx context.Context, path string, args options.DeleteOptions) error {
+	if args.VersionId != "" {
+		return errors.ErrUnsupportedMethod
+	}
+	abs, err := d.absPath(path)
+	if err != nil {
+		return errors.Wrap(errors.ErrDeleteFailed, err)
+	}
+	resp, err := d.DeleteFile(ctx, abs)
+	if err != nil {
+		return errors.Wrap(errors.ErrDeleteFailed, err)
+	}
+	defer resp.Body.Close()
+
+	// the boolean is false if the path doesn't exist
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNotFound:
+		return nil
+	default:
+		return errors.ParseWebhdfsError(errors.ErrDeleteFailed, path, resp)
+	}
+}
+
+func (d *Driver) List(ctx context.Context, path string, args options.ListOptions) (interfaces.ObjectStream, error) {
+	return object.NewObjectStream(NewDirStream(d, d.root, path)), nil
+}
+
+func (d *Driver) ListVersions(ctx context.Context, path string, args options.ListVersions) (interfaces.ObjectStream, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) PreSign(ctx context.Context, path string, args options.PreSignOptions) (*http.Request, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) GetTags(ctx context.Context, path string, args options.GetTags) (map[string]string, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) SetTags(ctx context.Context, path string, args options.SetTags) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) CreateMultipart(ctx context.Context, path string, args options.CreateMultipart) (string, error) {
+	return "", errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) WriteMultipart(ctx context.Context, path string, args options.WriteMultipart, reader io.Reader) (interfaces.ObjectPart, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) CompleteMultipart(ctx context.Context, path string, args options.CompleteMultipart) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) AbortMultipart(ctx context.Context, path string, args options.AbortMultipart) error {
+	return errors.ErrUnsupportedMethod
+}
+
+func (d *Driver) ListMultipart(ctx context.Context, path string, args options.ListMultipart) ([]interfaces.MultipartUpload, error) {
+	return nil, errors.ErrUnsupportedMethod
+}
+
+// NewDriver returns a driver of HDFS over the WebHDFS REST API, the requests are authorized with the delegation token
+// if it's set, or with the pseudo authentication of `user.name`, otherwise they are anonymous.
+func NewDriver(ctx context.Context, opt Options) (interfaces.Accessor, error) {
+	if opt.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required")
+	}
+	u, err := url.Parse(strings.TrimSuffix(opt.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	client := opt.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	noRedirectClient := *client
+	noRedirectClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &Driver{
+		endpoint:         u.String(),
+		root:             utils.NormalizeRoot(opt.Root),
+		delegationToken:  opt.DelegationToken,
+		user:             opt.User,
+		disableListBatch: opt.DisableListBatch,
+		client:           client,
+		noRedirectClient: &noRedirectClient,
+	}, nil
+}
@@ -0,0 +1,175 @@
+package webhdfs
+
+import (
+	"bytes"
+	"context"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/options"
+	"github.com/senrok/yadal/providers/webhdfs/webhdfstest"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newServer(t *testing.T, opt webhdfstest.Options) (*httptest.Server, string) {
+	opt.Root = t.TempDir()
+	server := httptest.NewServer(webhdfstest.NewHandler(opt))
+	t.Cleanup(server.Close)
+	return server, opt.Root
+}
+
+func setupDriver(t *testing.T, opt Options) *Driver {
+	d, err := NewDriver(context.Background(), opt)
+	assert.Nil(t, err)
+	return d.(*Driver)
+}
+
+func TestDriver(t *testing.T) {
+	server, root := newServer(t, webhdfstest.Options{User: "hadoop"})
+	d := setupDriver(t, Options{Endpoint: server.URL, Root: "/data/", User: "hadoop"})
+	ctx := context.Background()
+	assert.Equal(t, interfaces.Webhdfs, d.Metadata().Provider())
+	assert.True(t, d.Metadata().Capability().Has(interfaces.Read, interfaces.Write, interfaces.List))
+
+	// the missing parents are created
+	content := []byte("Hello,World!")
+	result, err := d.Write(ctx, "dir/sub/a b.txt", options.WriteOptions{Size: uint64(len(content))}, bytes.NewReader(content))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(len(content)), result.GetSize())
+	b, err := os.ReadFile(filepath.Join(root, "data/dir/sub/a b.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, content, b)
+
+	// overwrites the existing file
+	content = []byte("Hello,HDFS!")
+	_, err = d.Write(ctx, "dir/sub/a b.txt", options.WriteOptions{Size: uint64(len(content))}, bytes.NewReader(content))
+	assert.Nil(t, err)
+
+	meta, err := d.Stat(ctx, "dir/sub/a b.txt", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.FILE, meta.Mode())
+	assert.Equal(t, uint64(len(content)), *meta.ContentLength())
+	assert.NotNil(t, meta.LastModified())
+
+	offset, size := uint64(6), uint64(4)
+	reader, err := d.Read(ctx, "dir/sub/a b.txt", options.ReadOptions{Offset: &offset, Size: &size})
+	assert.Nil(t, err)
+	b, _ = io.ReadAll(reader)
+	assert.Nil(t, reader.Close())
+	assert.Equal(t, "HDFS", string(b))
+
+	meta, err = d.Stat(ctx, "dir/", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, interfaces.DIR, meta.Mode())
+
+	assert.Nil(t, d.Create(ctx, "empty/", options.CreateOptions{Mode: int8(interfaces.DIR)}))
+	assert.Nil(t, d.Create(ctx, "empty/", options.CreateOptions{Mode: int8(interfaces.DIR)}))
+	assert.Nil(t, d.Create(ctx, "file", options.CreateOptions{Mode: int8(interfaces.FILE)}))
+	meta, err = d.Stat(ctx, "file", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), *meta.ContentLength())
+
+	_, err = d.Stat(ctx, "not-exist", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+	_, err = d.Read(ctx, "not-exist", options.ReadOptions{})
+	assert.True(t, errors.Is(err, errors.ErrNotFound))
+
+	// the children are deleted with the dir
+	assert.Nil(t, d.Delete(ctx, "dir/", options.DeleteOptions{}))
+	assert.Nil(t, d.Delete(ctx, "dir/", options.DeleteOptions{}))
+	_, err = os.Stat(filepath.Join(root, "data/dir"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestAuth(t *testing.T) {
+	server, _ := newServer(t, webhdfstest.Options{DelegationToken: "token"})
+	ctx := context.Background()
+
+	d := setupDriver(t, Options{Endpoint: server.URL, Root: "/", DelegationToken: "token"})
+	_, err := d.Write(ctx, "a", options.WriteOptions{Size: 1}, bytes.NewReader([]byte("a")))
+	assert.Nil(t, err)
+	_, err = d.Stat(ctx, "a", options.StatOptions{})
+	assert.Nil(t, err)
+
+	d = setupDriver(t, Options{Endpoint: server.URL, Root: "/", User: "hadoop"})
+	_, err = d.Stat(ctx, "a", options.StatOptions{})
+	assert.True(t, errors.Is(err, errors.ErrPermissionDenied))
+	var objectErr *errors.ObjectError
+	assert.True(t, errors.As(err, &objectErr))
+	assert.Equal(t, "SecurityException", objectErr.Code())
+}
+
+func TestCreateWithoutRedirect(t *testing.T) {
+	handler := webhdfstest.NewHandler(webhdfstest.Options{Root: t.TempDir()})
+	// replies the location in the body like `noredirect=true`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("op") == "CREATE" && strings.HasPrefix(r.URL.Path, "/webhdfs/") {
+			query.Set("noredirect", "true")
+			r.URL.RawQuery = query.Encode()
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	d := setupDriver(t, Options{Endpoint: server.URL, Root: "/"})
+	ctx := context.Background()
+
+	_, err := d.Write(ctx, "a", options.WriteOptions{Size: 1}, bytes.NewReader([]byte("a")))
+	assert.Nil(t, err)
+	meta, err := d.Stat(ctx, "a", options.StatOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), *meta.ContentLength())
+}
+
+func TestList(t *testing.T) {
+	for _, disableListBatch := range []bool{false, true} {
+		server, _ := newServer(t, webhdfstest.Options{BatchSize: 2, DisableListBatch: disableListBatch})
+		d := setupDriver(t, Options{Endpoint: server.URL, Root: "/data/", DisableListBatch: disableListBatch})
+		ctx := context.Background()
+
+		for _, path := range []string{"dir/a", "dir/b c", "dir/d", "dir/sub/e"} {
+			_, err := d.Write(ctx, path, options.WriteOptions{Size: 1}, bytes.NewReader([]byte("x")))
+			assert.Nil(t, err)
+		}
+
+		stream := NewDirStream(d, d.root, "dir/")
+		entries := map[string]interfaces.ObjectMode{}
+		pages := 0
+		for {
+			page, err := stream.NextPage(ctx)
+			assert.Nil(t, err)
+			if len(page) == 0 {
+				break
+			}
+			pages++
+			for _, entry := range page {
+				entries[entry.Path()] = entry.Metadata().Mode()
+			}
+		}
+		assert.Equal(t, map[string]interfaces.ObjectMode{
+			"dir/a":    interfaces.FILE,
+			"dir/b c":  interfaces.FILE,
+			"dir/d":    interfaces.FILE,
+			"dir/sub/": interfaces.DIR,
+		}, entries)
+		if disableListBatch {
+			assert.Equal(t, 1, pages)
+		} else {
+			assert.Equal(t, 2, pages)
+		}
+
+		// the file itself is not listed
+		page, err := NewDirStream(d, d.root, "dir/a").NextPage(ctx)
+		assert.Nil(t, err)
+		assert.Len(t, page, 0)
+
+		_, err = NewDirStream(d, d.root, "not-exist/").NextPage(ctx)
+		assert.True(t, errors.Is(err, errors.ErrNotFound))
+	}
+}
@@ -0,0 +1,330 @@
+// Package webhdfstest provides a stub WebHDFS server over a local dir for testing.
+package webhdfstest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	namenodePrefix = "/webhdfs/v1"
+	// datanodePrefix the path of the datanode, the namenode redirects the reads and writes to it.
+	datanodePrefix = "/datanode/v1"
+	defaultBatch   = 1000
+)
+
+type Options struct {
+	// Root the local dir served as the root of HDFS.
+	Root string
+	// DelegationToken and User are required if they are set, the requests are anonymous otherwise.
+	DelegationToken string
+	User            string
+	// BatchSize the max number of the children of a LISTSTATUS_BATCH page, defaults to 1000.
+	BatchSize int
+	// DisableListBatch refuses LISTSTATUS_BATCH like the clusters older than Hadoop 2.8.
+	DisableListBatch bool
+}
+
+// Handler serves the namenode under `/webhdfs/v1` and the datanode under `/datanode/v1`.
+type Handler struct {
+	opt Options
+}
+
+func NewHandler(opt Options) *Handler {
+	if opt.BatchSize <= 0 {
+		opt.BatchSize = defaultBatch
+	}
+	return &Handler{opt: opt}
+}
+
+type fileStatus struct {
+	AccessTime       int64  `json:"accessTime"`
+	BlockSize        int64  `json:"blockSize"`
+	Group            string `json:"group"`
+	Length           int64  `json:"length"`
+	ModificationTime int64  `json:"modificationTime"`
+	Owner            string `json:"owner"`
+	PathSuffix       string `json:"pathSuffix"`
+	Permission       string `json:"permission"`
+	Replication      int    `json:"replication"`
+	Type             string `json:"type"`
+}
+
+func newFileStatus(info os.FileInfo, suffix string) fileStatus {
+	status := fileStatus{
+		AccessTime:       info.ModTime().UnixMilli(),
+		BlockSize:        128 << 20,
+		Group:            "supergroup",
+		Length:           info.Size(),
+		ModificationTime: info.ModTime().UnixMilli(),
+		Owner:            "hdfs",
+		PathSuffix:       suffix,
+		Permission:       "644",
+		Replication:      1,
+		Type:             "FILE",
+	}
+	if info.IsDir() {
+		status.Length, status.BlockSize, status.Replication = 0, 0, 0
+		status.Permission, status.Type = "755", "DIRECTORY"
+	}
+	return status
+}
+
+func reply(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// remoteException replies the error like the namenode.
+func remoteException(w http.ResponseWriter, status int, exception, javaClassName, format string, args ...interface{}) {
+	reply(w, status, map[string]interface{}{
+		"RemoteException": map[string]string{
+			"exception":     exception,
+			"javaClassName": javaClassName,
+			"message":       fmt.Sprintf(format, args...),
+		},
+	})
+}
+
+func fileNotFound(w http.ResponseWriter, p string) {
+	remoteException(w, http.StatusNotFound, "FileNotFoundException", "java.io.FileNotFoundException", "File does not exist: %s", p)
+}
+
+func (h *Handler) authorized(query url.Values) bool {
+	if h.opt.DelegationToken != "" && query.Get("delegation") == h.opt.DelegationToken {
+		return true
+	}
+	if h.opt.User != "" && query.Get("user.name") == h.opt.User {
+		return true
+	}
+	return h.opt.DelegationToken == "" && h.opt.User == ""
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !h.authorized(query) {
+		remoteException(w, http.StatusUnauthorized, "SecurityException", "java.lang.SecurityException", "Failed to obtain user group information")
+		return
+	}
+	switch {
+	case strings.HasPrefix(r.URL.Path, namenodePrefix):
+		h.namenode(w, r, pathpkg.Clean("/"+strings.TrimPrefix(r.URL.Path, namenodePrefix)))
+	case strings.HasPrefix(r.URL.Path, datanodePrefix):
+		h.datanode(w, r, pathpkg.Clean("/"+strings.TrimPrefix(r.URL.Path, datanodePrefix)))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) localPath(p string) string {
+	return filepath.Join(h.opt.Root, filepath.FromSlash(p))
+}
+
+// redirect redirects the request to the datanode with the same query.
+func (h *Handler) redirect(w http.ResponseWriter, r *http.Request, p string) {
+	location := url.URL{Scheme: "http", Host: r.Host, Path: datanodePrefix + p, RawQuery: r.URL.RawQuery}
+	if r.URL.Query().Get("noredirect") == "true" {
+		reply(w, http.StatusOK, map[string]string{"Location": location.String()})
+		return
+	}
+	http.Redirect(w, r, location.String(), http.StatusTemporaryRedirect)
+}
+
+func (h *Handler) namenode(w http.ResponseWriter, r *http.Request, p string) {
+	query := r.URL.Query()
+	op := strings.ToUpper(query.Get("op"))
+	local := h.localPath(p)
+	switch {
+	case r.Method == http.MethodGet && op == "OPEN":
+		h.redirect(w, r, p)
+	case r.Method == http.MethodPut && op == "CREATE":
+		if info, err := os.Stat(local); err == nil && (info.IsDir() || query.Get("overwrite") != "true") {
+			remoteException(w, http.StatusForbidden, "FileAlreadyExistsException", "org.apache.hadoop.fs.FileAlreadyExistsException", "%s already exists", p)
+			return
+		}
+		h.redirect(w, r, p)
+	case r.Method == http.MethodGet && op == "GETFILESTATUS":
+		info, err := os.Stat(local)
+		if err != nil {
+			fileNotFound(w, p)
+			return
+		}
+		reply(w, http.StatusOK, map[string]interface{}{"FileStatus": newFileStatus(info, "")})
+	case r.Method == http.MethodGet && (op == "LISTSTATUS" || op == "LISTSTATUS_BATCH"):
+		if op == "LISTSTATUS_BATCH" && h.opt.DisableListBatch {
+			remoteException(w, http.StatusBadRequest, "IllegalArgumentException", "java.lang.IllegalArgumentException", "Invalid value for webhdfs parameter \"op\": No enum constant %s", op)
+			return
+		}
+		h.list(w, op, p, query.Get("startAfter"))
+	case r.Method == http.MethodPut && op == "MKDIRS":
+		if err := os.MkdirAll(local, 0755); err != nil {
+			remoteException(w, http.StatusForbidden, "ParentNotDirectoryException", "org.apache.hadoop.fs.ParentNotDirectoryException", "%s", err)
+			return
+		}
+		reply(w, http.StatusOK, map[string]bool{"boolean": true})
+	case r.Method == http.MethodDelete && op == "DELETE":
+		info, err := os.Stat(local)
+		if err != nil {
+			reply(w, http.StatusOK, map[string]bool{"boolean": false})
+			return
+		}
+		if info.IsDir() && query.Get("recursive") != "true" {
+			if entries, _ := os.ReadDir(local); len(entries) > 0 {
+				remoteException(w, http.StatusForbidden, "PathIsNotEmptyDirectoryException", "org.apache.hadoop.fs.PathIsNotEmptyDirectoryException", "%s is non empty': Directory is not empty", p)
+				return
+			}
+		}
+		if err = os.RemoveAll(local); err != nil {
+			remoteException(w, http.StatusInternalServerError, "IOException", "java.io.IOException", "%s", err)
+			return
+		}
+		reply(w, http.StatusOK, map[string]bool{"boolean": true})
+	default:
+		remoteException(w, http.StatusBadRequest, "IllegalArgumentException", "java.lang.IllegalArgumentException", "Invalid value for webhdfs parameter \"op\": %s %s", r.Method, op)
+	}
+}
+
+func (h *Handler) list(w http.ResponseWriter, op, p, startAfter string) {
+	local := h.localPath(p)
+	info, err := os.Stat(local)
+	if err != nil {
+		fileNotFound(w, p)
+		return
+	}
+	var statuses []fileStatus
+	if !info.IsDir() {
+		statuses = append(statuses, newFileStatus(info, ""))
+	} else {
+		entries, err := os.ReadDir(local)
+		if err != nil {
+			remoteException(w, http.StatusInternalServerError, "IOException", "java.io.IOException", "%s", err)
+			return
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Name() < entries[j].Name()
+		})
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			statuses = append(statuses, newFileStatus(info, entry.Name()))
+		}
+	}
+	if op == "LISTSTATUS" {
+		reply(w, http.StatusOK, map[string]interface{}{"FileStatuses": map[string]interface{}{"FileStatus": statuses}})
+		return
+	}
+
+	if startAfter != "" {
+		idx := sort.Search(len(statuses), func(i int) bool {
+			return statuses[i].PathSuffix > startAfter
+		})
+		statuses = statuses[idx:]
+	}
+	remaining := 0
+	if len(statuses) > h.opt.BatchSize {
+		remaining = len(statuses) - h.opt.BatchSize
+		statuses = statuses[:h.opt.BatchSize]
+	}
+	reply(w, http.StatusOK, map[string]interface{}{
+		"DirectoryListing": map[string]interface{}{
+			"partialListing":   map[string]interface{}{"FileStatuses": map[string]interface{}{"FileStatus": statuses}},
+			"remainingEntries": remaining,
+		},
+	})
+}
+
+func (h *Handler) datanode(w http.ResponseWriter, r *http.Request, p string) {
+	query := r.URL.Query()
+	local := h.localPath(p)
+	switch {
+	case r.Method == http.MethodGet && strings.ToUpper(query.Get("op")) == "OPEN":
+		h.open(w, query, p, local)
+	case r.Method == http.MethodPut && strings.ToUpper(query.Get("op")) == "CREATE":
+		if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+			remoteException(w, http.StatusForbidden, "ParentNotDirectoryException", "org.apache.hadoop.fs.ParentNotDirectoryException", "%s", err)
+			return
+		}
+		file, err := os.Create(local)
+		if err != nil {
+			remoteException(w, http.StatusForbidden, "IOException", "java.io.IOException", "%s", err)
+			return
+		}
+		_, err = io.Copy(file, r.Body)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			remoteException(w, http.StatusInternalServerError, "IOException", "java.io.IOException", "%s", err)
+			return
+		}
+		w.Header().Set("Location", "hdfs://"+r.Host+p)
+		w.WriteHeader(http.StatusCreated)
+	default:
+		remoteException(w, http.StatusBadRequest, "IllegalArgumentException", "java.lang.IllegalArgumentException", "Invalid operation %s", query.Get("op"))
+	}
+}
+
+func (h *Handler) open(w http.ResponseWriter, query url.Values, p, local string) {
+	file, err := os.Open(local)
+	if err != nil {
+		fileNotFound(w, p)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		remoteException(w, http.StatusNotFound, "FileNotFoundException", "java.io.FileNotFoundException", "Path is not a file: %s", p)
+		return
+	}
+	offset, length, err := parseRange(query, info.Size())
+	if err != nil {
+		remoteException(w, http.StatusBadRequest, "IllegalArgumentException", "java.lang.IllegalArgumentException", "%s", err)
+		return
+	}
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		remoteException(w, http.StatusInternalServerError, "IOException", "java.io.IOException", "%s", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.CopyN(w, file, length)
+}
+
+// parseRange returns the offset and the length within the file.
+func parseRange(query url.Values, size int64) (int64, int64, error) {
+	var offset int64
+	var err error
+	if v := query.Get("offset"); v != "" {
+		if offset, err = strconv.ParseInt(v, 10, 64); err != nil || offset < 0 {
+			return 0, 0, errors.New("invalid offset")
+		}
+	}
+	if offset > size {
+		return 0, 0, fmt.Errorf("offset=%d is beyond the size %d", offset, size)
+	}
+	length := size - offset
+	if v := query.Get("length"); v != "" {
+		l, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || l < 0 {
+			return 0, 0, errors.New("invalid length")
+		}
+		if l < length {
+			length = l
+		}
+	}
+	return offset, length, nil
+}
@@ -1,21 +1,41 @@
 package behavior
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
 	"fmt"
 	"github.com/joho/godotenv"
 	"github.com/senrok/yadal"
 	"github.com/senrok/yadal/interfaces"
 	"github.com/senrok/yadal/layers"
+	"github.com/senrok/yadal/providers/archive"
+	"github.com/senrok/yadal/providers/azblob"
+	"github.com/senrok/yadal/providers/bolt"
 	"github.com/senrok/yadal/providers/fs"
+	"github.com/senrok/yadal/providers/ftp"
+	"github.com/senrok/yadal/providers/ftp/ftptest"
+	"github.com/senrok/yadal/providers/gcs"
+	"github.com/senrok/yadal/providers/http"
+	"github.com/senrok/yadal/providers/iofs"
 	"github.com/senrok/yadal/providers/s3"
+	"github.com/senrok/yadal/providers/sftp"
+	"github.com/senrok/yadal/providers/sftp/sftptest"
+	"github.com/senrok/yadal/providers/webdav"
+	"github.com/senrok/yadal/providers/webhdfs"
+	"github.com/senrok/yadal/providers/webhdfs/webhdfstest"
 	"go.uber.org/zap"
+	xwebdav "golang.org/x/net/webdav"
 	"log"
+	nethttp "net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 type testFunc func(t *testing.T, op *yadal.Operator)
@@ -29,7 +49,7 @@ type testSet struct {
 }
 
 var (
-	providers = []string{"s3", "fs"}
+	providers = []string{"s3", "fs", "gcs", "azblob", "webdav", "sftp", "ftp", "http", "webhdfs", "bolt", "archive", "iofs"}
 	tests     = []testSet{
 		{
 			name: "basic",
@@ -45,13 +65,28 @@ var (
 			},
 			tests: readWriteTests,
 		},
+		{
+			name: "readOnly",
+			strategy: func(op *yadal.Operator) bool {
+				caps := op.Metadata().Capability()
+				return caps.Has(interfaces.Read, interfaces.List) && !caps.Has(interfaces.Write)
+			},
+			tests: readOnlyTests,
+		},
+		{
+			name: "multipart",
+			strategy: func(op *yadal.Operator) bool {
+				return op.Metadata().Capability().Has(interfaces.Read, interfaces.Write, interfaces.Multipart)
+			},
+			tests: multipartTests,
+		},
 	}
 )
 
-func getTests(name string) []testFunc {
-	for _, test := range tests {
-		if test.name == name {
-			return test.tests
+func getTestSet(name string) *testSet {
+	for i := range tests {
+		if tests[i].name == name {
+			return &tests[i]
 		}
 	}
 	log.Println("failed to find tests, please check the name")
@@ -60,6 +95,53 @@ func getTests(name string) []testFunc {
 
 type builderFunc func() interfaces.Accessor
 
+// fixtures the files served by the in-process read-only providers, the paths are relative to the root.
+var fixtures = map[string][]byte{
+	"hello.txt":           []byte("Hello,World!"),
+	"dir/nested/data.bin": bytes.Repeat([]byte("0123456789"), 1000),
+}
+
+// fixtureName returns the name of the fixture under the root, e.g. `dal/hello.txt`.
+func fixtureName(root, path string) string {
+	return strings.TrimPrefix(filepath.ToSlash(filepath.Join(strings.Trim(root, "/"), path)), "/")
+}
+
+// writeFixtures writes the fixtures under the root in the dir.
+func writeFixtures(dir, root string) {
+	for path, content := range fixtures {
+		name := filepath.Join(dir, filepath.FromSlash(fixtureName(root, path)))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(name, content, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// zipFixtures returns a zip of the fixtures under the root, the text files are stored and the others are deflated.
+func zipFixtures(root string) []byte {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for path, content := range fixtures {
+		method := zip.Deflate
+		if strings.HasSuffix(path, ".txt") {
+			method = zip.Store
+		}
+		fw, err := w.CreateHeader(&zip.FileHeader{Name: fixtureName(root, path), Method: method})
+		if err != nil {
+			log.Fatal(err)
+		}
+		if _, err = fw.Write(content); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
 var (
 	buildMap = map[string]builderFunc{
 		// NOTES: uses UPPER word
@@ -86,6 +168,218 @@ var (
 			}
 			return acc
 		},
+		"GCS": func() interfaces.Accessor {
+			acc, err := gcs.NewDriver(context.TODO(), gcs.Options{
+				Bucket:         os.Getenv("DAL_GCS_BUCKET"),
+				Endpoint:       os.Getenv("DAL_GCS_ENDPOINT"),
+				Root:           os.Getenv("DAL_GCS_ROOT"),
+				CredentialPath: os.Getenv("DAL_GCS_CREDENTIAL_PATH"),
+			})
+			if err != nil {
+				log.Fatal(err)
+			}
+			return acc
+		},
+		"AZBLOB": func() interfaces.Accessor {
+			acc, err := azblob.NewDriver(context.TODO(), azblob.Options{
+				Container:   os.Getenv("DAL_AZBLOB_CONTAINER"),
+				Endpoint:    os.Getenv("DAL_AZBLOB_ENDPOINT"),
+				Root:        os.Getenv("DAL_AZBLOB_ROOT"),
+				AccountName: os.Getenv("DAL_AZBLOB_ACCOUNT_NAME"),
+				AccountKey:  os.Getenv("DAL_AZBLOB_ACCOUNT_KEY"),
+				SASToken:    os.Getenv("DAL_AZBLOB_SAS_TOKEN"),
+			})
+			if err != nil {
+				log.Fatal(err)
+			}
+			return acc
+		},
+		"WEBDAV": func() interfaces.Accessor {
+			endpoint := os.Getenv("DAL_WEBDAV_ENDPOINT")
+			// tests against an in-process server if the endpoint is not set
+			if endpoint == "" {
+				endpoint = httptest.NewServer(&xwebdav.Handler{
+					FileSystem: xwebdav.NewMemFS(),
+					LockSystem: xwebdav.NewMemLS(),
+				}).URL
+			}
+			acc, err := webdav.NewDriver(context.TODO(), webdav.Options{
+				Endpoint: endpoint,
+				Root:     os.Getenv("DAL_WEBDAV_ROOT"),
+				Username: os.Getenv("DAL_WEBDAV_USERNAME"),
+				Password: os.Getenv("DAL_WEBDAV_PASSWORD"),
+			})
+			if err != nil {
+				log.Fatal(err)
+			}
+			return acc
+		},
+		"SFTP": func() interfaces.Accessor {
+			opt := sftp.Options{
+				Endpoint:       os.Getenv("DAL_SFTP_ENDPOINT"),
+				Root:           os.Getenv("DAL_SFTP_ROOT"),
+				User:           os.Getenv("DAL_SFTP_USER"),
+				Password:       os.Getenv("DAL_SFTP_PASSWORD"),
+				KeyPath:        os.Getenv("DAL_SFTP_KEY_PATH"),
+				KnownHostsPath: os.Getenv("DAL_SFTP_KNOWN_HOSTS_PATH"),
+			}
+			// tests against an in-process server serving a temp dir if the endpoint is not set
+			if opt.Endpoint == "" {
+				server, err := sftptest.NewServer("yadal", "yadal")
+				if err != nil {
+					log.Fatal(err)
+				}
+				dir, err := os.MkdirTemp("", "yadal-sftp-")
+				if err != nil {
+					log.Fatal(err)
+				}
+				knownHosts := filepath.Join(dir, "known_hosts")
+				if err = os.WriteFile(knownHosts, []byte(server.KnownHosts()+"\n"), 0600); err != nil {
+					log.Fatal(err)
+				}
+				opt.Endpoint, opt.User, opt.Password, opt.KnownHostsPath = server.Addr, "yadal", "yadal", knownHosts
+				opt.Root = filepath.Join(dir, opt.Root)
+			}
+			acc, err := sftp.NewDriver(context.TODO(), opt)
+			if err != nil {
+				log.Fatal(err)
+			}
+			return acc
+		},
+		"FTP": func() interfaces.Accessor {
+			opt := ftp.Options{
+				Endpoint: os.Getenv("DAL_FTP_ENDPOINT"),
+				Root:     os.Getenv("DAL_FTP_ROOT"),
+				User:     os.Getenv("DAL_FTP_USER"),
+				Password: os.Getenv("DAL_FTP_PASSWORD"),
+			}
+			// tests against an in-process server serving a temp dir if the endpoint is not set
+			if opt.Endpoint == "" {
+				dir, err := os.MkdirTemp("", "yadal-ftp-")
+				if err != nil {
+					log.Fatal(err)
+				}
+				server, err := ftptest.NewServer(ftptest.Options{Root: dir, User: "yadal", Password: "yadal"})
+				if err != nil {
+					log.Fatal(err)
+				}
+				opt.Endpoint, opt.User, opt.Password = server.Addr, "yadal", "yadal"
+			}
+			acc, err := ftp.NewDriver(context.TODO(), opt)
+			if err != nil {
+				log.Fatal(err)
+			}
+			return acc
+		},
+		"HTTP": func() interfaces.Accessor {
+			endpoint := os.Getenv("DAL_HTTP_ENDPOINT")
+			// tests against an in-process file server serving the fixtures in a temp dir if the endpoint is not set
+			if endpoint == "" {
+				dir, err := os.MkdirTemp("", "yadal-http-")
+				if err != nil {
+					log.Fatal(err)
+				}
+				writeFixtures(dir, os.Getenv("DAL_HTTP_ROOT"))
+				endpoint = httptest.NewServer(nethttp.FileServer(nethttp.Dir(dir))).URL
+			}
+			acc, err := http.NewDriver(context.TODO(), http.Options{
+				Endpoint:   endpoint,
+				Root:       os.Getenv("DAL_HTTP_ROOT"),
+				Username:   os.Getenv("DAL_HTTP_USERNAME"),
+				Password:   os.Getenv("DAL_HTTP_PASSWORD"),
+				ListFormat: http.ListAutoindex,
+			})
+			if err != nil {
+				log.Fatal(err)
+			}
+			return acc
+		},
+		"WEBHDFS": func() interfaces.Accessor {
+			opt := webhdfs.Options{
+				Endpoint:        os.Getenv("DAL_WEBHDFS_ENDPOINT"),
+				Root:            os.Getenv("DAL_WEBHDFS_ROOT"),
+				User:            os.Getenv("DAL_WEBHDFS_USER"),
+				DelegationToken: os.Getenv("DAL_WEBHDFS_DELEGATION_TOKEN"),
+			}
+			// tests against an in-process stub server serving a temp dir if the endpoint is not set
+			if opt.Endpoint == "" {
+				dir, err := os.MkdirTemp("", "yadal-webhdfs-")
+				if err != nil {
+					log.Fatal(err)
+				}
+				opt.Endpoint = httptest.NewServer(webhdfstest.NewHandler(webhdfstest.Options{Root: dir})).URL
+			}
+			acc, err := webhdfs.NewDriver(context.TODO(), opt)
+			if err != nil {
+				log.Fatal(err)
+			}
+			return acc
+		},
+		"BOLT": func() interfaces.Accessor {
+			opt := bolt.Options{
+				Path: os.Getenv("DAL_BOLT_PATH"),
+				Root: os.Getenv("DAL_BOLT_ROOT"),
+			}
+			// tests against a database file in a temp dir if the path is not set
+			if opt.Path == "" {
+				dir, err := os.MkdirTemp("", "yadal-bolt-")
+				if err != nil {
+					log.Fatal(err)
+				}
+				opt.Path = filepath.Join(dir, "yadal.db")
+			}
+			acc, err := bolt.NewDriver(context.TODO(), opt)
+			if err != nil {
+				log.Fatal(err)
+			}
+			return acc
+		},
+		"ARCHIVE": func() interfaces.Accessor {
+			opt := archive.Options{
+				Accessor: fs.NewDriver(fs.Options{Root: os.Getenv("DAL_ARCHIVE_FS_ROOT")}),
+				Path:     os.Getenv("DAL_ARCHIVE_PATH"),
+				Root:     os.Getenv("DAL_ARCHIVE_ROOT"),
+			}
+			// tests against a zip of the fixtures in a temp dir if the path is not set
+			if opt.Path == "" {
+				dir, err := os.MkdirTemp("", "yadal-archive-")
+				if err != nil {
+					log.Fatal(err)
+				}
+				if err = os.WriteFile(filepath.Join(dir, "archive.zip"), zipFixtures(opt.Root), 0644); err != nil {
+					log.Fatal(err)
+				}
+				if opt.Accessor, err = iofs.NewDriver(context.TODO(), iofs.Options{FS: os.DirFS(dir)}); err != nil {
+					log.Fatal(err)
+				}
+				opt.Path = "archive.zip"
+			}
+			acc, err := archive.NewDriver(context.TODO(), opt)
+			if err != nil {
+				log.Fatal(err)
+			}
+			return acc
+		},
+		"IOFS": func() interfaces.Accessor {
+			root := os.Getenv("DAL_IOFS_ROOT")
+			// tests against the fixtures in memory if the dir is not set
+			fsys := fstest.MapFS{}
+			for path, content := range fixtures {
+				fsys[fixtureName(root, path)] = &fstest.MapFile{Data: content, Mode: 0644}
+			}
+			opt := iofs.Options{
+				FS:   fsys,
+				Root: root,
+			}
+			if dir := os.Getenv("DAL_IOFS_DIR"); dir != "" {
+				opt.FS = os.DirFS(dir)
+			}
+			acc, err := iofs.NewDriver(context.TODO(), opt)
+			if err != nil {
+				log.Fatal(err)
+			}
+			return acc
+		},
 	}
 	s *zap.SugaredLogger
 )
@@ -138,11 +432,18 @@ func getFunctionName(i interface{}) string {
 }
 
 func runTests(t *testing.T, ops []*yadal.Operator, name string) {
-	testList := getTests(name)
+	set := getTestSet(name)
+	if set == nil {
+		return
+	}
 	log.Printf("---------------- running tests: %s ----------------", name)
 	for _, operator := range ops {
 		log.Printf("---------------- provider: %s ----------------", operator.Metadata().Provider().String())
-		for _, test := range testList {
+		if !set.strategy(operator) {
+			log.Printf("---------------- skipped: %s ----------------", operator.Metadata().Capability())
+			continue
+		}
+		for _, test := range set.tests {
 			log.Printf("---------------- running: %s ----------------", getFunctionName(test))
 			test(t, operator)
 		}
@@ -157,4 +458,10 @@ func TestRun(t *testing.T) {
 	t.Run("readWrite", func(t *testing.T) {
 		runTests(t, p, "readWrite")
 	})
+	t.Run("readOnly", func(t *testing.T) {
+		runTests(t, p, "readOnly")
+	})
+	t.Run("multipart", func(t *testing.T) {
+		runTests(t, p, "multipart")
+	})
 }
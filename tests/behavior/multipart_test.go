@@ -0,0 +1,108 @@
+package behavior
+
+import (
+	"bytes"
+	"context"
+	"github.com/google/uuid"
+	"github.com/senrok/yadal"
+	"github.com/senrok/yadal/errors"
+	"github.com/senrok/yadal/options"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+)
+
+var multipartTests = []testFunc{
+	testMultipartComplete,
+	testMultipartAbort,
+	testListMultipart,
+}
+
+// minPartSize the minimal size of non-last parts on s3
+const minPartSize = 5 * 1024 * 1024
+
+// writes parts and completes the upload should be success
+func testMultipartComplete(t *testing.T, op *yadal.Operator) {
+	path := uuid.New().String()
+	acc := op.Accessor()
+	uploadId, err := acc.CreateMultipart(context.TODO(), path, options.CreateMultipart{})
+	assert.Nilf(t, err, "%s", err)
+
+	contents := [][]byte{genBytes(minPartSize), genBytes(4096)}
+	var parts []options.ObjectPart
+	for i, content := range contents {
+		part, err := acc.WriteMultipart(context.TODO(), path, options.WriteMultipart{
+			UploadId:   uploadId,
+			PartNumber: uint(i + 1),
+			Size:       uint64(len(content)),
+		}, bytes.NewReader(content))
+		assert.Nilf(t, err, "%s", err)
+		parts = append(parts, part)
+	}
+
+	err = acc.CompleteMultipart(context.TODO(), path, options.CompleteMultipart{
+		UploadId:    uploadId,
+		ObjectParts: parts,
+	})
+	assert.Nilf(t, err, "%s", err)
+
+	o := op.Object(path)
+	reader, err := o.Read(context.TODO())
+	assert.Nilf(t, err, "%s", err)
+	b, err := io.ReadAll(reader)
+	assert.Nilf(t, err, "%s", err)
+	assert.Equal(t, bytes.Join(contents, nil), b)
+
+	err = o.Delete(context.TODO())
+	assert.Nilf(t, err, "%s", err)
+}
+
+// aborts an upload should be success, the object should not exist
+func testMultipartAbort(t *testing.T, op *yadal.Operator) {
+	path := uuid.New().String()
+	acc := op.Accessor()
+	uploadId, err := acc.CreateMultipart(context.TODO(), path, options.CreateMultipart{})
+	assert.Nilf(t, err, "%s", err)
+
+	content := genBytes(4096)
+	_, err = acc.WriteMultipart(context.TODO(), path, options.WriteMultipart{
+		UploadId:   uploadId,
+		PartNumber: 1,
+		Size:       uint64(len(content)),
+	}, bytes.NewReader(content))
+	assert.Nilf(t, err, "%s", err)
+
+	err = acc.AbortMultipart(context.TODO(), path, options.AbortMultipart{UploadId: uploadId})
+	assert.Nilf(t, err, "%s", err)
+
+	o := op.Object(path)
+	exist, err := o.IsExist(context.TODO())
+	assert.Nilf(t, err, "%s", err)
+	assert.False(t, exist)
+}
+
+// lists in-progress uploads should contain the created upload
+func testListMultipart(t *testing.T, op *yadal.Operator) {
+	dir := uuid.New().String() + "/"
+	path := dir + uuid.New().String()
+	acc := op.Accessor()
+	uploadId, err := acc.CreateMultipart(context.TODO(), path, options.CreateMultipart{})
+	assert.Nilf(t, err, "%s", err)
+
+	uploads, err := acc.ListMultipart(context.TODO(), dir, options.ListMultipart{WithSize: true})
+	if errors.Is(err, errors.ErrUnsupportedMethod) {
+		// e.g. the sessions of gcs resumable uploads and the uncommitted blocks of azblob can't be listed
+		_ = acc.AbortMultipart(context.TODO(), path, options.AbortMultipart{UploadId: uploadId})
+		return
+	}
+	assert.Nilf(t, err, "%s", err)
+	assert.Len(t, uploads, 1)
+	if len(uploads) == 1 {
+		assert.Equal(t, path, uploads[0].GetPath())
+		assert.Equal(t, uploadId, uploads[0].GetUploadId())
+		assert.Equal(t, uint64(0), *uploads[0].GetSize())
+	}
+
+	err = acc.AbortMultipart(context.TODO(), path, options.AbortMultipart{UploadId: uploadId})
+	assert.Nilf(t, err, "%s", err)
+}
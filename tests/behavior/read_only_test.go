@@ -0,0 +1,84 @@
+package behavior
+
+import (
+	"context"
+	"github.com/senrok/yadal"
+	"github.com/senrok/yadal/interfaces"
+	"github.com/senrok/yadal/options"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+var readOnlyTests = []testFunc{
+	testStatRoot,
+	testStatNotExists,
+	testReadNotExists,
+	testListAndReadFixtures,
+}
+
+// walks the fixtures from the root, each file should be stated, read and range read
+func testListAndReadFixtures(t *testing.T, op *yadal.Operator) {
+	files := map[string]int{}
+	walkFixtures(t, op, "/", files)
+	assert.NotEmpty(t, files)
+	// the fixtures of the in-process builders should be matched
+	for path, content := range fixtures {
+		if size, ok := files[path]; ok {
+			assert.Equal(t, len(content), size, path)
+		}
+	}
+}
+
+func walkFixtures(t *testing.T, op *yadal.Operator, dir string, files map[string]int) {
+	o := op.Object(dir)
+	stream, err := o.List(context.TODO())
+	assert.Nilf(t, err, "%s", err)
+	if err != nil {
+		return
+	}
+	for stream.HasNext() {
+		entry, err := stream.Next(context.TODO())
+		assert.Nilf(t, err, "%s", err)
+		if err != nil {
+			return
+		}
+		if strings.HasSuffix(entry.Path(), "/") {
+			sub := op.Object(entry.Path())
+			meta, err := sub.Metadata(context.TODO())
+			assert.Nilf(t, err, "%s", err)
+			assert.Equal(t, interfaces.DIR, meta.Mode())
+			walkFixtures(t, op, entry.Path(), files)
+			continue
+		}
+		files[entry.Path()] = readFixture(t, op, entry.Path())
+	}
+}
+
+// reads the file fully and in range, returns the size of the file
+func readFixture(t *testing.T, op *yadal.Operator, path string) int {
+	o := op.Object(path)
+	meta, err := o.Metadata(context.TODO())
+	assert.Nilf(t, err, "%s", err)
+	assert.Equal(t, interfaces.FILE, meta.Mode())
+
+	read, err := o.Read(context.TODO())
+	assert.Nilf(t, err, "%s", err)
+	content, err := io.ReadAll(read)
+	assert.Nilf(t, err, "%s", err)
+	_ = read.Close()
+	assert.Equal(t, *meta.ContentLength(), uint64(len(content)), path)
+	if len(content) == 0 {
+		return 0
+	}
+
+	off, l := genOffsetLen(int64(len(content)))
+	read, err = o.RangeRead(context.TODO(), options.NewRangeBounds(options.Range(off, l+off)))
+	assert.Nilf(t, err, "%s", err)
+	bytes, err := io.ReadAll(read)
+	assert.Nilf(t, err, "%s", err)
+	_ = read.Close()
+	assert.Equal(t, content[off:off+l], bytes, path)
+	return len(content)
+}
@@ -3,6 +3,8 @@ package utils
 import (
 	"encoding/hex"
 	"fmt"
+	"github.com/senrok/yadal/errors"
+	"path"
 	"regexp"
 	"strings"
 	"unicode/utf8"
@@ -18,13 +20,31 @@ import (
 // - All whitespace will be trimmed: ` abc/def ` => `abc/def`
 // - All leading / will be trimmed: `///abc` => `abc`
 // - Internal // will be replaced by /: `abc///def` => `abc/def`
+// - Internal . and .. will be resolved: `abc/./def/../ghi` => `abc/ghi`
+// - Leading .. escaping from root will be kept: `abc/../../def` => `../def`, BuildAbsPath rejects it.
+// - Path ends with . or .. is a dir path: `abc/def/..` => `abc/`
 // - Empty path will be `/`: `` => `/`
-func NormalizePath(path string) string {
-	path = strings.TrimLeft(path, "/")
-	if path == "" {
+func NormalizePath(p string) string {
+	p = strings.TrimLeft(strings.TrimSpace(p), "/")
+	if p == "" {
 		return "/"
 	}
-	return path
+	isDir := strings.HasSuffix(p, "/") || p == "." || p == ".." ||
+		strings.HasSuffix(p, "/.") || strings.HasSuffix(p, "/..")
+	p = path.Clean(p)
+	if p == "." {
+		return "/"
+	}
+	if isDir {
+		p += "/"
+	}
+	return p
+}
+
+// IsEscaped returns true if the relative path escapes from root via `..`.
+func IsEscaped(p string) bool {
+	p = path.Clean(strings.TrimLeft(p, "/"))
+	return p == ".." || strings.HasPrefix(p, "../")
 }
 
 // NormalizeRoot Make sure root is normalized to style like `/abc/def/`.
@@ -43,7 +63,7 @@ func NormalizeRoot(root string) string {
 	if !strings.HasPrefix(root, "/") {
 		root = "/" + root
 	}
-	if !strings.HasSuffix(root, "") {
+	if !strings.HasSuffix(root, "/") {
 		root += "/"
 	}
 	return root
@@ -72,6 +92,7 @@ func BuildRealPath(root, path string) (string, error) {
 // # Rules
 //
 // - Input root MUST be the format like `/abc/def/`
+// - Input path MUST NOT escape from root, otherwise errors.ErrPathEscaped is returned.
 // - Output will be the format like `path/to/root/path`.
 func BuildAbsPath(root string, path string) (string, error) {
 	if !strings.HasPrefix(root, "/") {
@@ -86,6 +107,9 @@ func BuildAbsPath(root string, path string) (string, error) {
 		if strings.HasPrefix(path, "/") {
 			return "", fmt.Errorf("path mut not start with /")
 		}
+		if IsEscaped(path) {
+			return "", errors.ErrPathEscaped
+		}
 		return root[1:] + path, nil
 	}
 }
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"errors"
+	dalErrors "github.com/senrok/yadal/errors"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -26,3 +28,40 @@ func TestEncodePath(t *testing.T) {
 
 	assert.Equal(t, "%E4%BD%A0%E5%A5%BD%EF%BC%8C%E4%B8%96%E7%95%8C%EF%BC%81%E2%9D%A4", EncodePath("你好，世界！❤"))
 }
+
+func TestNormalizePath(t *testing.T) {
+	assert.Equal(t, "/", NormalizePath(""))
+	assert.Equal(t, "/", NormalizePath(" / "))
+	assert.Equal(t, "abc", NormalizePath("///abc"))
+	assert.Equal(t, "abc/def", NormalizePath("abc///def"))
+	assert.Equal(t, "abc/def/", NormalizePath("abc/def/"))
+	assert.Equal(t, "abc/ghi", NormalizePath("abc/./def/../ghi"))
+	assert.Equal(t, "abc/", NormalizePath("abc/def/.."))
+	assert.Equal(t, "/", NormalizePath("abc/.."))
+	assert.Equal(t, "../def", NormalizePath("abc/../../def"))
+	assert.Equal(t, "../../etc/passwd", NormalizePath("../../etc/passwd"))
+}
+
+func TestNormalizeRoot(t *testing.T) {
+	assert.Equal(t, "/abc/", NormalizeRoot("abc"))
+	assert.Equal(t, "/abc/", NormalizeRoot("/abc/"))
+}
+
+func TestBuildAbsPath(t *testing.T) {
+	p, err := BuildAbsPath("/root/", "abc/def")
+	assert.Nil(t, err)
+	assert.Equal(t, "root/abc/def", p)
+
+	p, err = BuildAbsPath("/root/", "/")
+	assert.Nil(t, err)
+	assert.Equal(t, "root/", p)
+
+	_, err = BuildAbsPath("/root/", "../../etc/passwd")
+	assert.True(t, errors.Is(err, dalErrors.ErrPathEscaped))
+
+	_, err = BuildAbsPath("/root/", "abc/../../etc/passwd")
+	assert.True(t, errors.Is(err, dalErrors.ErrPathEscaped))
+
+	_, err = BuildAbsPath("/root/", "..")
+	assert.True(t, errors.Is(err, dalErrors.ErrPathEscaped))
+}
@@ -18,6 +18,12 @@ func (f fileLimit) Close() error {
 	return f.close()
 }
 
+// NewReadCloser returns a ReadCloser that reads from the reader and closes the closer,
+// e.g. the limited body of a response.
+func NewReadCloser(reader io.Reader, closer io.Closer) io.ReadCloser {
+	return &fileLimit{close: closer.Close, reader: reader}
+}
+
 func NewFileLimitReader(file *os.File, size int64) io.ReadCloser {
-	return &fileLimit{close: file.Close, reader: io.LimitReader(file, size)}
+	return NewReadCloser(io.LimitReader(file, size), file)
 }